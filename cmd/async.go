@@ -0,0 +1,299 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	stderrors "errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/docker/go-units"
+	"github.com/microcks/microcks-cli/pkg/config"
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+)
+
+const (
+	companionBroker = "broker"
+	companionMinion = "async-minion"
+
+	// Aliases of the containers on the instance network.
+	microcksAlias    = "microcks"
+	asyncMinionAlias = "microcks-async-minion"
+	asyncMinionPort  = "8081"
+)
+
+// asyncBroker is the broker container started for an async protocol, and
+// how the async minion reaches it.
+type asyncBroker struct {
+	image string
+	alias string
+	// port is bound to the BrokerPort of the instance, by default the same
+	// host port, for clients running on the host.
+	port string
+	env  []string
+	// advertisedEnv is the format of an environment variable given the
+	// address the host reaches the broker at.
+	advertisedEnv string
+	cmd           []string
+	minionEnv     []string
+}
+
+var asyncBrokers = map[string]asyncBroker{
+	"kafka": {
+		image: "apache/kafka:3.9.0",
+		alias: "kafka",
+		port:  "9092",
+		env: []string{
+			"KAFKA_NODE_ID=1",
+			"KAFKA_PROCESS_ROLES=broker,controller",
+			"KAFKA_CONTROLLER_QUORUM_VOTERS=1@kafka:9093",
+			"KAFKA_CONTROLLER_LISTENER_NAMES=CONTROLLER",
+			// The network reaches the broker at kafka:19092, the host at its
+			// BrokerPort, bound to 9092.
+			"KAFKA_LISTENERS=INTERNAL://:19092,EXTERNAL://:9092,CONTROLLER://:9093",
+			"KAFKA_LISTENER_SECURITY_PROTOCOL_MAP=INTERNAL:PLAINTEXT,EXTERNAL:PLAINTEXT,CONTROLLER:PLAINTEXT",
+			"KAFKA_INTER_BROKER_LISTENER_NAME=INTERNAL",
+			"KAFKA_OFFSETS_TOPIC_REPLICATION_FACTOR=1",
+		},
+		advertisedEnv: "KAFKA_ADVERTISED_LISTENERS=INTERNAL://kafka:19092,EXTERNAL://%s",
+		minionEnv:     []string{"ASYNC_PROTOCOLS=KAFKA", "KAFKA_BOOTSTRAP_SERVER=kafka:19092"},
+	},
+	"mqtt": {
+		image: "eclipse-mosquitto:2.0",
+		alias: "mqtt",
+		port:  "1883",
+		// The default configuration only listens on the loopback interface.
+		cmd:       []string{"mosquitto", "-c", "/mosquitto-no-auth.conf"},
+		minionEnv: []string{"ASYNC_PROTOCOLS=MQTT", "MQTT_SERVER=mqtt:1883", "MQTT_USERNAME=", "MQTT_PASSWORD="},
+	},
+	"amqp": {
+		image: "rabbitmq:3-management",
+		alias: "rabbitmq",
+		port:  "5672",
+		// RabbitMQ's guest user can only connect from localhost.
+		env:       []string{"RABBITMQ_DEFAULT_USER=microcks", "RABBITMQ_DEFAULT_PASS=microcks"},
+		minionEnv: []string{"ASYNC_PROTOCOLS=AMQP", "AMQP_SERVER=rabbitmq:5672", "AMQP_USERNAME=microcks", "AMQP_PASSWORD=microcks"},
+	},
+	"nats": {
+		image:     "nats:2.10",
+		alias:     "nats",
+		port:      "4222",
+		minionEnv: []string{"ASYNC_PROTOCOLS=NATS", "NATS_SERVER=nats:4222", "NATS_USERNAME=", "NATS_PASSWORD="},
+	},
+}
+
+func validateAsyncProtocol(protocol string) error {
+	if _, ok := asyncBrokers[protocol]; ok || protocol == "" {
+		return nil
+	}
+	protocols := make([]string, 0, len(asyncBrokers))
+	for p := range asyncBrokers {
+		protocols = append(protocols, p)
+	}
+	sort.Strings(protocols)
+	return errors.Wrapf(errors.KindUsage, "--async must be one of %s, got '%s'", strings.Join(protocols, ", "), protocol)
+}
+
+// asyncMinionImage derives the minion image from the Microcks one, like the
+// Microcks Testcontainers ensemble does: the minion has no native build.
+func asyncMinionImage(microcksImage string) string {
+	image := strings.Replace(microcksImage, "microcks-uber", "microcks-uber-async-minion", 1)
+	return strings.Replace(image, "-native", "", 1)
+}
+
+// instanceContainerOpts are the options the Microcks container of an
+// instance is created, or recreated, with.
+func instanceContainerOpts(instance config.Instance) (connectors.ContainerOpts, error) {
+	opts := connectors.ContainerOpts{
+		Image:      instance.Image,
+		Port:       instance.Port,
+		Name:       instance.Name,
+		AutoRemove: instance.AutoRemove,
+		Env:        append([]string(nil), instance.Env...),
+		Volumes:    instance.Volumes,
+		Network:    instance.Network,
+		HostIP:     instance.HostIP,
+		GrpcPort:   instance.GrpcPort,
+		CPUs:       instance.CPUs,
+	}
+	// A recreated container keeps running the pinned version, even if the
+	// tag has moved since.
+	if instance.ImageDigest != "" {
+		opts.Image = instance.ImageDigest
+	}
+	if instance.Memory != "" {
+		memory, err := units.RAMInBytes(instance.Memory)
+		if err != nil {
+			return opts, fmt.Errorf("invalid memory limit '%s' of instance %s: %w", instance.Memory, instance.Name, err)
+		}
+		opts.Memory = memory
+	}
+	if instance.Async != "" {
+		opts.NetworkAliases = []string{microcksAlias}
+		opts.Env = append(opts.Env, "ASYNC_MINION_URL=http://"+asyncMinionAlias+":"+asyncMinionPort)
+	}
+	return opts, nil
+}
+
+// brokerAddress is the host:port the broker of an async instance is reached
+// at from the host. Instances recorded before BrokerPort publish it on the
+// protocol port.
+func brokerAddress(instance config.Instance) string {
+	return instanceAddress(instance, brokerHostPort(instance))
+}
+
+func brokerHostPort(instance config.Instance) string {
+	if instance.BrokerPort == "" {
+		return asyncBrokers[instance.Async].port
+	}
+	return instance.BrokerPort
+}
+
+// setUpAsync creates the network, broker and async minion of an instance
+// started with --async, and starts the broker. The minion is started once
+// Microcks is ready, as it loads the async API mocks from it on boot.
+func setUpAsync(containerClient connectors.ContainerClient, instance *config.Instance) error {
+	instance.Network = instance.Name + "-network"
+	if _, err := containerClient.CreateNetwork(instance.Network); err != nil {
+		return fmt.Errorf("failed to create network %s: %w", instance.Network, err)
+	}
+	instance.Companions = nil
+	for _, role := range []string{companionBroker, companionMinion} {
+		companion, err := createCompanion(containerClient, *instance, role)
+		if err != nil {
+			return err
+		}
+		instance.Companions = append(instance.Companions, companion)
+	}
+	return startCompanions(containerClient, instance, companionBroker)
+}
+
+func createCompanion(containerClient connectors.ContainerClient, instance config.Instance, role string) (config.Companion, error) {
+	opts := companionContainerOpts(instance, role)
+	containerId, err := containerClient.CreateContainer(opts)
+	if err != nil {
+		return config.Companion{}, fmt.Errorf("failed to create %s container: %w", role, err)
+	}
+	return config.Companion{Role: role, Name: opts.Name, Image: opts.Image, ContainerID: containerId}, nil
+}
+
+// companionContainerOpts are the options of the broker or async minion
+// container of an instance.
+func companionContainerOpts(instance config.Instance, role string) connectors.ContainerOpts {
+	broker := asyncBrokers[instance.Async]
+	opts := connectors.ContainerOpts{
+		AutoRemove: instance.AutoRemove,
+		Network:    instance.Network,
+		HostIP:     instance.HostIP,
+	}
+	switch role {
+	case companionBroker:
+		opts.Image = broker.image
+		opts.Name = instance.Name + "-" + broker.alias
+		opts.NetworkAliases = []string{broker.alias}
+		opts.Env = broker.env
+		if broker.advertisedEnv != "" {
+			opts.Env = append(slices.Clone(broker.env), fmt.Sprintf(broker.advertisedEnv, brokerAddress(instance)))
+		}
+		opts.Cmd = broker.cmd
+		opts.Ports = map[string]string{broker.port + "/tcp": brokerHostPort(instance)}
+	case companionMinion:
+		opts.Image = asyncMinionImage(instance.Image)
+		opts.Name = instance.Name + "-" + companionMinion
+		opts.NetworkAliases = []string{asyncMinionAlias}
+		opts.Env = append([]string{"MICROCKS_HOST_PORT=" + microcksAlias + ":" + connectors.MICROCKS_DEFAULT_PORT}, broker.minionEnv...)
+	}
+	return opts
+}
+
+// recreateMissingCompanions recreates the companions whose container no
+// longer exists, like start does for the Microcks container.
+func recreateMissingCompanions(containerClient connectors.ContainerClient, instance *config.Instance) error {
+	if instance.Async == "" {
+		return nil
+	}
+	if _, err := containerClient.CreateNetwork(instance.Network); err != nil {
+		return fmt.Errorf("failed to create network %s: %w", instance.Network, err)
+	}
+	for i, companion := range instance.Companions {
+		_, err := containerClient.InspectContainer(companion.ContainerID)
+		if err == nil {
+			continue
+		}
+		if !stderrors.Is(err, connectors.ErrContainerNotFound) {
+			return err
+		}
+		fmt.Printf("Container %s no longer exists, recreating it\n", companion.Name)
+		if instance.Companions[i], err = createCompanion(containerClient, *instance, companion.Role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func startCompanions(containerClient connectors.ContainerClient, instance *config.Instance, role string) error {
+	for _, companion := range instance.Companions {
+		if companion.Role != role {
+			continue
+		}
+		if err := containerClient.StartContainer(companion.ContainerID); err != nil {
+			return fmt.Errorf("failed to start %s container: %w", role, err)
+		}
+	}
+	return nil
+}
+
+// stopCompanions stops the companions of a role; the ones already gone
+// (autoRemove) are skipped.
+func stopCompanions(containerClient connectors.ContainerClient, instance *config.Instance, role string) error {
+	for _, companion := range instance.Companions {
+		if companion.Role != role {
+			continue
+		}
+		if exists, err := containerClient.ContainerExists(companion.ContainerID); err != nil || !exists {
+			continue
+		}
+		if err := containerClient.StopContainer(companion.ContainerID); err != nil {
+			return fmt.Errorf("failed to stop %s container: %w", role, err)
+		}
+	}
+	return nil
+}
+
+// tearDownAsync removes the companions of an instance and its network. The
+// network of an instance started without --async is the user's: it is kept.
+func tearDownAsync(containerClient connectors.ContainerClient, instance *config.Instance) error {
+	if instance.Async == "" {
+		return nil
+	}
+	for _, companion := range instance.Companions {
+		err := containerClient.RemoveContainer(companion.ContainerID, true)
+		if err != nil && !stderrors.Is(err, connectors.ErrContainerNotFound) {
+			return fmt.Errorf("failed to remove %s container: %w", companion.Role, err)
+		}
+	}
+	if instance.Network != "" {
+		if err := containerClient.RemoveNetwork(instance.Network); err != nil {
+			return fmt.Errorf("failed to remove network %s: %w", instance.Network, err)
+		}
+	}
+	instance.Companions = nil
+	instance.Network = ""
+	return nil
+}
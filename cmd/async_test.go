@@ -0,0 +1,185 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/microcks/microcks-cli/pkg/config"
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/fakeserver"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startAsyncInstance runs `start --async` against a fake server standing for
+// the Microcks container, returning the config path and the fake port.
+func startAsyncInstance(t *testing.T, runtime *fakeRuntime, protocol string, extraArgs ...string) (string, string) {
+	t.Helper()
+	useFakeRuntime(t, runtime)
+	server := httptest.NewServer(fakeserver.New())
+	t.Cleanup(server.Close)
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	configPath := filepath.Join(t.TempDir(), "config")
+	args := append([]string{"--port", serverURL.Port(), "--async", protocol}, extraArgs...)
+	require.NoError(t, runLifecycleCommand(NewStartCommand, configPath, args...))
+	return configPath, serverURL.Port()
+}
+
+func runLifecycleCommand(newCmd func(*connectors.ClientOptions) *cobra.Command, configPath string, args ...string) error {
+	cmd := newCmd(&connectors.ClientOptions{ConfigPath: configPath})
+	cmd.SetArgs(args)
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	return cmd.Execute()
+}
+
+func TestStartAsyncCreatesBrokerAndMinion(t *testing.T) {
+	runtime := &fakeRuntime{states: map[string]string{}}
+	configPath, port := startAsyncInstance(t, runtime, "kafka")
+
+	require.Len(t, runtime.created, 3)
+	broker, minion, microcks := runtime.created[0], runtime.created[1], runtime.created[2]
+
+	assert.Equal(t, "apache/kafka:3.9.0", broker.Image)
+	assert.Equal(t, "microcks-kafka", broker.Name)
+	assert.Equal(t, "microcks-network", broker.Network)
+	assert.Equal(t, []string{"kafka"}, broker.NetworkAliases)
+	assert.Equal(t, map[string]string{"9092/tcp": "9092"}, broker.Ports)
+
+	assert.Equal(t, "quay.io/microcks/microcks-uber-async-minion:latest", minion.Image)
+	assert.Equal(t, []string{asyncMinionAlias}, minion.NetworkAliases)
+	assert.Contains(t, minion.Env, "MICROCKS_HOST_PORT=microcks:8080")
+	assert.Contains(t, minion.Env, "KAFKA_BOOTSTRAP_SERVER=kafka:19092")
+
+	assert.Equal(t, port, microcks.Port)
+	assert.Equal(t, "microcks-network", microcks.Network)
+	assert.Equal(t, []string{"microcks"}, microcks.NetworkAliases)
+	assert.Contains(t, microcks.Env, "ASYNC_MINION_URL=http://microcks-async-minion:8081")
+
+	assert.True(t, runtime.networks["microcks-network"])
+	for id, state := range runtime.states {
+		assert.Equal(t, "running", state, "container %s", id)
+	}
+
+	localCfg, err := config.ReadLocalConfig(configPath)
+	require.NoError(t, err)
+	instance, err := localCfg.GetInstance("microcks")
+	require.NoError(t, err)
+	assert.Equal(t, "kafka", instance.Async)
+	assert.Equal(t, "microcks-network", instance.Network)
+	require.Len(t, instance.Companions, 2)
+	assert.Equal(t, config.Companion{Role: "broker", Name: "microcks-kafka", Image: "apache/kafka:3.9.0", ContainerID: "new-1"}, instance.Companions[0])
+	assert.Equal(t, "async-minion", instance.Companions[1].Role)
+	assert.Equal(t, "new-2", instance.Companions[1].ContainerID)
+}
+
+func TestStartAsyncPortAutoPicksBrokerPort(t *testing.T) {
+	runtime := &fakeRuntime{states: map[string]string{}}
+	configPath, _ := startAsyncInstance(t, runtime, "kafka")
+
+	// Nothing answers on the picked port: the containers are created and
+	// recorded, then waiting for the server times out.
+	err := runLifecycleCommand(NewStartCommand, configPath, "--name", "billing", "--async", "kafka", "--port", "auto", "--ready-timeout", "100ms")
+	assert.Equal(t, errors.KindEnvironment, errors.KindOf(err))
+
+	require.Len(t, runtime.created, 6)
+	orders, billing := runtime.created[0], runtime.created[3]
+	assert.Equal(t, map[string]string{"9092/tcp": "9092"}, orders.Ports)
+	assert.Contains(t, orders.Env, "KAFKA_ADVERTISED_LISTENERS=INTERNAL://kafka:19092,EXTERNAL://localhost:9092")
+	assert.Equal(t, "billing-kafka", billing.Name)
+	brokerPort := billing.Ports["9092/tcp"]
+	assert.Regexp(t, `^[0-9]+$`, brokerPort)
+	assert.NotEqual(t, "9092", brokerPort)
+	assert.NotEqual(t, runtime.created[5].Port, brokerPort)
+	assert.Contains(t, billing.Env, "KAFKA_ADVERTISED_LISTENERS=INTERNAL://kafka:19092,EXTERNAL://localhost:"+brokerPort)
+
+	localCfg, err := config.ReadLocalConfig(configPath)
+	require.NoError(t, err)
+	instance, err := localCfg.GetInstance("billing")
+	require.NoError(t, err)
+	assert.Equal(t, brokerPort, instance.BrokerPort)
+}
+
+func TestStopStopsAsyncCompanionsInOrder(t *testing.T) {
+	runtime := &fakeRuntime{states: map[string]string{}}
+	configPath, _ := startAsyncInstance(t, runtime, "mqtt")
+
+	require.NoError(t, runLifecycleCommand(NewStopCommand, configPath))
+	// Minion, Microcks, then broker.
+	assert.Equal(t, []string{"new-2", "new-3", "new-1"}, runtime.stopped)
+
+	localCfg, err := config.ReadLocalConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "Exited", localCfg.Instances[0].Status)
+	assert.Len(t, localCfg.Instances[0].Companions, 2)
+	assert.True(t, runtime.networks["microcks-network"])
+}
+
+func TestStopTearsDownAutoRemovedAsyncInstance(t *testing.T) {
+	runtime := &fakeRuntime{states: map[string]string{}}
+	configPath, _ := startAsyncInstance(t, runtime, "nats", "--rm")
+	for _, opts := range runtime.created {
+		assert.True(t, opts.AutoRemove, opts.Name)
+	}
+
+	require.NoError(t, runLifecycleCommand(NewStopCommand, configPath))
+	assert.Empty(t, runtime.networks)
+
+	localCfg, err := config.ReadLocalConfig(configPath)
+	require.NoError(t, err)
+	assert.Empty(t, localCfg.Instances)
+}
+
+func TestInstancesRmRemovesAsyncCompanions(t *testing.T) {
+	runtime := &fakeRuntime{states: map[string]string{}}
+	configPath, _ := startAsyncInstance(t, runtime, "amqp")
+
+	_, err := runInstances(t, configPath, "rm", "microcks", "--force")
+	require.NoError(t, err)
+	assert.Empty(t, runtime.states)
+	assert.Empty(t, runtime.networks)
+}
+
+func TestStartAsyncValidation(t *testing.T) {
+	useFakeRuntime(t, &fakeRuntime{states: map[string]string{}})
+	configPath := filepath.Join(t.TempDir(), "config")
+
+	err := runLifecycleCommand(NewStartCommand, configPath, "--async", "jms")
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+	assert.Contains(t, err.Error(), "amqp, kafka, mqtt, nats")
+
+	err = runLifecycleCommand(NewStartCommand, configPath, "--async", "kafka", "--no-wait")
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+}
+
+func TestAsyncMinionImage(t *testing.T) {
+	assert.Equal(t, "quay.io/microcks/microcks-uber-async-minion:1.12.0", asyncMinionImage("quay.io/microcks/microcks-uber:1.12.0-native"))
+	assert.Equal(t, "quay.io/microcks/microcks-uber-async-minion:nightly", asyncMinionImage("quay.io/microcks/microcks-uber:nightly"))
+}
+
+func TestBrokerAddress(t *testing.T) {
+	assert.Equal(t, "localhost:19092", brokerAddress(config.Instance{Async: "kafka", BrokerPort: "19092"}))
+	assert.Equal(t, "192.168.1.10:1884", brokerAddress(config.Instance{Async: "mqtt", BrokerPort: "1884", HostIP: "192.168.1.10"}))
+	assert.Equal(t, "localhost:4222", brokerAddress(config.Instance{Async: "nats"}))
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"github.com/microcks/microcks-cli/pkg/config"
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+)
+
+// newMicrocksClientFromOptions builds an authenticated client either from the
+// --microcksURL and Keycloak service account flags, or from the current
+// context of the local config. It also returns the Microcks server address.
+func newMicrocksClientFromOptions(globalClientOpts *connectors.ClientOptions) (connectors.MicrocksClient, string, error) {
+	// Collect optional HTTPS transport flags.
+	config.InsecureTLS = globalClientOpts.InsecureTLS
+	config.CaCertPaths = globalClientOpts.CaCertPaths
+	config.Verbose = globalClientOpts.Verbose
+
+	if globalClientOpts.ServerAddr != "" && globalClientOpts.ClientId != "" && globalClientOpts.ClientSecret != "" {
+		// create client with server address
+		serverAddr := globalClientOpts.ServerAddr
+		mc, err := connectors.NewMicrocksClient(serverAddr)
+		if err != nil {
+			return nil, "", err
+		}
+
+		keycloakURL, err := mc.GetKeycloakURL()
+		if err != nil {
+			return nil, "", err
+		}
+
+		oauthToken := "unauthenticated-token"
+		if keycloakURL != "null" {
+			// If Keycloak is enabled, retrieve an OAuth token using Keycloak Client.
+			kc, err := connectors.NewKeycloakClient(keycloakURL, globalClientOpts.ClientId, globalClientOpts.ClientSecret)
+			if err != nil {
+				return nil, "", err
+			}
+
+			oauthToken, err = kc.ConnectAndGetToken()
+			if err != nil {
+				return nil, "", err
+			}
+		}
+		mc.SetOAuthToken(oauthToken)
+		return mc, serverAddr, nil
+	}
+
+	localConfig, err := config.ReadLocalConfig(globalClientOpts.ConfigPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if localConfig == nil {
+		return nil, "", errors.Wrapf(errors.KindUsage, "please login to perform this operation")
+	}
+
+	if globalClientOpts.Context == "" {
+		globalClientOpts.Context = localConfig.CurrentContext
+	}
+
+	ctx, err := localConfig.ResolveContext(globalClientOpts.Context)
+	if err != nil {
+		return nil, "", errors.Wrap(errors.KindNotFound, err)
+	}
+
+	mc, err := connectors.NewClient(*globalClientOpts)
+	if err != nil {
+		return nil, "", err
+	}
+	return mc, ctx.Server.Server, nil
+}
+
+// newContextClient builds a client for a named context of the local config,
+// ignoring the --microcksURL and service account flags, so that commands can
+// talk to several Microcks at once.
+func newContextClient(globalClientOpts *connectors.ClientOptions, contextName string) (connectors.MicrocksClient, string, error) {
+	opts := *globalClientOpts
+	opts.Context = contextName
+	opts.ServerAddr, opts.ClientId, opts.ClientSecret = "", "", ""
+	return newMicrocksClientFromOptions(&opts)
+}
@@ -18,6 +18,7 @@ package cmd
 import (
 	"github.com/microcks/microcks-cli/pkg/config"
 	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -40,8 +41,14 @@ func NewCommand() (*cobra.Command, error) {
 		},
 	}
 
+	// Malformed flag values (e.g. an unparsable --waitFor) are usage errors.
+	command.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return errors.Wrap(errors.KindUsage, err)
+	})
+
 	command.AddCommand(NewImportCommand(&clientOpts))
 	command.AddCommand(NewImportDirCommand(&clientOpts))
+	command.AddCommand(NewLintCommand())
 	command.AddCommand(NewVersionCommand())
 	command.AddCommand(NewTestCommand(&clientOpts))
 	command.AddCommand(NewImportURLCommand(&clientOpts))
@@ -50,6 +57,17 @@ func NewCommand() (*cobra.Command, error) {
 	command.AddCommand(NewContextCommand(&clientOpts))
 	command.AddCommand(NewLoginCommand(&clientOpts))
 	command.AddCommand(NewLogoutCommand(&clientOpts))
+	command.AddCommand(NewFakeServerCommand())
+	command.AddCommand(NewServeCommand())
+	command.AddCommand(NewConformanceCommand(&clientOpts))
+	command.AddCommand(NewSecretsCommand(&clientOpts))
+	command.AddCommand(NewJobsCommand(&clientOpts))
+	command.AddCommand(NewSnapshotCommand(&clientOpts))
+	command.AddCommand(NewPromoteCommand(&clientOpts))
+	command.AddCommand(NewInvokeCommand(&clientOpts))
+	command.AddCommand(NewMetricsCommand(&clientOpts))
+	command.AddCommand(NewServicesCommand(&clientOpts))
+	command.AddCommand(NewInstancesCommand(&clientOpts))
 
 	defaultLocalConfigPath, err := config.DefaultLocalConfigPath()
 	if err != nil {
@@ -0,0 +1,213 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func NewConformanceCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var (
+		minScore float64
+		output   string
+	)
+
+	var conformanceCmd = &cobra.Command{
+		Use:   "conformance <apiName:apiVersion>",
+		Short: "Show the conformance score and coverage of an API",
+		Long:  `Show the operation coverage (operations with examples, operations tested by the latest test) and the conformance index Microcks computes for an API`,
+		Example: `# Show the coverage of the petstore API
+microcks conformance petstore:2.0.0
+
+# Fail when the conformance index is under 80, with a JSON report for dashboards
+microcks conformance petstore:2.0.0 --min-score 80 -o json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "text" && output != "json" {
+				return errors.Wrapf(errors.KindUsage, "--output must be 'text' or 'json'")
+			}
+			threshold, err := minScoreFlag(cmd, minScore)
+			if err != nil {
+				return err
+			}
+
+			mc, _, err := newMicrocksClientFromOptions(globalClientOpts)
+			if err != nil {
+				return err
+			}
+
+			view, err := mc.GetServiceView(args[0])
+			if err != nil {
+				return err
+			}
+			var latest *connectors.TestResultSummary
+			results, err := mc.ListTestResults(view.Service.ID, 0, 1)
+			if err != nil {
+				return err
+			}
+			if len(results) > 0 {
+				latest = &results[0]
+			}
+
+			report, err := fetchCoverageReport(mc, view, latest)
+			if err != nil {
+				return err
+			}
+			if output == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(report); err != nil {
+					return err
+				}
+			} else {
+				printCoverageReport(os.Stdout, report)
+			}
+			return checkMinScore(report, threshold)
+		},
+	}
+
+	conformanceCmd.Flags().Float64Var(&minScore, "min-score", 0, "Minimum conformance index (0-100); the command fails below it")
+	conformanceCmd.Flags().StringVarP(&output, "output", "o", "text", "Output format: 'text' or 'json'")
+
+	return conformanceCmd
+}
+
+// coverageReport summarizes how well an API is covered by examples and tests.
+type coverageReport struct {
+	Service                   string   `json:"service"`
+	Operations                int      `json:"operations"`
+	OperationsWithExamples    int      `json:"operationsWithExamples"`
+	OperationsWithoutExamples []string `json:"operationsWithoutExamples"`
+	TestID                    string   `json:"testId,omitempty"`
+	OperationsTested          int      `json:"operationsTested"`
+	UntestedOperations        []string `json:"untestedOperations"`
+	ConformanceScore          *float64 `json:"conformanceScore"`
+	MaxPossibleScore          *float64 `json:"maxPossibleScore"`
+	Trend                     string   `json:"trend,omitempty"`
+}
+
+// fetchCoverageReport builds the coverage of a service for the given test
+// (nil when never tested) and its current conformance metric, if any.
+func fetchCoverageReport(mc connectors.MicrocksClient, view *connectors.ServiceView, test *connectors.TestResultSummary) (*coverageReport, error) {
+	metric, err := mc.GetConformanceMetric(view.Service.ID)
+	if err != nil && errors.KindOf(err) != errors.KindNotFound {
+		return nil, err
+	}
+	return buildCoverageReport(view, test, metric), nil
+}
+
+func buildCoverageReport(view *connectors.ServiceView, test *connectors.TestResultSummary, metric *connectors.ConformanceMetric) *coverageReport {
+	report := &coverageReport{
+		Service:                   view.Service.Name + ":" + view.Service.Version,
+		Operations:                len(view.Service.Operations),
+		OperationsWithoutExamples: []string{},
+		UntestedOperations:        []string{},
+	}
+
+	tested := map[string]bool{}
+	if test != nil {
+		report.TestID = test.ID
+		for _, tc := range test.TestCaseResults {
+			tested[tc.OperationName] = true
+		}
+	}
+	for _, op := range view.Service.Operations {
+		if len(view.MessagesMap[op.Name]) > 0 {
+			report.OperationsWithExamples++
+		} else {
+			report.OperationsWithoutExamples = append(report.OperationsWithoutExamples, op.Name)
+		}
+		if tested[op.Name] {
+			report.OperationsTested++
+		} else {
+			report.UntestedOperations = append(report.UntestedOperations, op.Name)
+		}
+	}
+
+	if metric != nil {
+		report.ConformanceScore = &metric.CurrentScore
+		report.MaxPossibleScore = &metric.MaxPossibleScore
+		report.Trend = metric.LatestTrend
+	}
+	return report
+}
+
+func printCoverageReport(out io.Writer, report *coverageReport) {
+	fmt.Fprintf(out, "Coverage of %s\n", report.Service)
+	fmt.Fprintf(out, "  Operations with examples: %s%s\n",
+		formatRatio(report.OperationsWithExamples, report.Operations), formatMissing(report.OperationsWithoutExamples))
+	if report.TestID == "" {
+		fmt.Fprintf(out, "  Operations tested:        never tested\n")
+	} else {
+		fmt.Fprintf(out, "  Operations tested:        %s in test %s%s\n",
+			formatRatio(report.OperationsTested, report.Operations), report.TestID, formatMissing(report.UntestedOperations))
+	}
+	if report.ConformanceScore == nil {
+		fmt.Fprintf(out, "  Conformance index:        not computed yet\n")
+	} else {
+		fmt.Fprintf(out, "  Conformance index:        %.1f (max possible %.1f, trend %s)\n",
+			*report.ConformanceScore, *report.MaxPossibleScore, report.Trend)
+	}
+}
+
+func formatRatio(n, total int) string {
+	if total == 0 {
+		return "0/0"
+	}
+	return fmt.Sprintf("%d/%d (%.1f%%)", n, total, float64(n)*100/float64(total))
+}
+
+func formatMissing(operations []string) string {
+	if len(operations) == 0 {
+		return ""
+	}
+	return ", missing: " + strings.Join(operations, ", ")
+}
+
+// minScoreFlag returns the --min-score value, or nil when it is not set.
+func minScoreFlag(cmd *cobra.Command, minScore float64) (*float64, error) {
+	if !cmd.Flags().Changed("min-score") {
+		return nil, nil
+	}
+	if minScore < 0 || minScore > 100 {
+		return nil, errors.Wrapf(errors.KindUsage, "--min-score must be between 0 and 100")
+	}
+	return &minScore, nil
+}
+
+// checkMinScore fails the command when the conformance index is below
+// minScore, or not computed at all. A nil minScore always passes.
+func checkMinScore(report *coverageReport, minScore *float64) error {
+	switch {
+	case minScore == nil:
+		return nil
+	case report.ConformanceScore == nil:
+		fmt.Printf("Conformance index of %s is not computed yet, below the minimum %.1f\n", report.Service, *minScore)
+		return errors.ErrTestFailed
+	case *report.ConformanceScore < *minScore:
+		fmt.Printf("Conformance index of %s is %.1f, below the minimum %.1f\n", report.Service, *report.ConformanceScore, *minScore)
+		return errors.ErrTestFailed
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/fakeserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildCoverageReport(t *testing.T) {
+	view := &connectors.ServiceView{
+		Service: connectors.Service{Name: "Orders", Version: "1.0", Operations: []connectors.Operation{
+			{Name: "GET /orders"}, {Name: "POST /orders"}, {Name: "DELETE /orders"},
+		}},
+		MessagesMap: map[string][]json.RawMessage{"GET /orders": {json.RawMessage(`{}`)}, "POST /orders": {json.RawMessage(`{}`)}},
+	}
+	test := &connectors.TestResultSummary{ID: "t1", TestCaseResults: []connectors.TestCaseResult{{OperationName: "GET /orders"}}}
+	metric := &connectors.ConformanceMetric{CurrentScore: 33.3, MaxPossibleScore: 66.7, LatestTrend: "UP"}
+
+	report := buildCoverageReport(view, test, metric)
+	assert.Equal(t, 2, report.OperationsWithExamples)
+	assert.Equal(t, []string{"DELETE /orders"}, report.OperationsWithoutExamples)
+	assert.Equal(t, 1, report.OperationsTested)
+	assert.Equal(t, []string{"POST /orders", "DELETE /orders"}, report.UntestedOperations)
+
+	var out bytes.Buffer
+	printCoverageReport(&out, report)
+	assert.Equal(t, "Coverage of Orders:1.0\n"+
+		"  Operations with examples: 2/3 (66.7%), missing: DELETE /orders\n"+
+		"  Operations tested:        1/3 (33.3%) in test t1, missing: POST /orders, DELETE /orders\n"+
+		"  Conformance index:        33.3 (max possible 66.7, trend UP)\n", out.String())
+
+	out.Reset()
+	printCoverageReport(&out, buildCoverageReport(view, nil, nil))
+	assert.Contains(t, out.String(), "never tested")
+	assert.Contains(t, out.String(), "not computed yet")
+}
+
+func TestTestOutcomeChecksMinScore(t *testing.T) {
+	fake := fakeserver.New()
+	fake.AddService(fakeserver.Service{Name: "Orders", Version: "1.0", Operations: []fakeserver.Operation{
+		{Name: "GET /orders", Method: "GET", Examples: []string{"all"}},
+		{Name: "POST /orders", Method: "POST"},
+	}})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	mc, err := connectors.NewMicrocksClient(server.URL)
+	require.NoError(t, err)
+
+	params := testParams{serviceRef: "Orders:1.0", testEndpoint: "http://localhost:9000", runnerType: "HTTP", waitFor: time.Second, coverage: true}
+	result, err := runTestAndWait(mc, params)
+	require.NoError(t, err)
+
+	// The fake scores 50: one operation of two has examples and passed.
+	minScore := 50.0
+	params.minScore = &minScore
+	require.NoError(t, testOutcome(mc, params, result))
+
+	minScore = 80
+	require.ErrorIs(t, testOutcome(mc, params, result), errors.ErrTestFailed)
+}
@@ -0,0 +1,263 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/util/duration"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// jsonRulesDispatchers are the dispatchers whose rules are a JSON document.
+var jsonRulesDispatchers = map[string]bool{"JSON_BODY": true, "FALLBACK": true, "PROXY_FALLBACK": true}
+
+// dispatcherFile is the YAML document `dispatcher get` prints and
+// `dispatcher set --file` reads.
+type dispatcherFile struct {
+	Dispatcher      string `yaml:"dispatcher,omitempty"`
+	DispatcherRules string `yaml:"dispatcherRules,omitempty"`
+	DefaultDelay    string `yaml:"defaultDelay,omitempty"`
+}
+
+func NewServicesDispatcherCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var dispatcherCmd = &cobra.Command{
+		Use:   "dispatcher",
+		Short: "Show or change how the mock of an operation picks its response",
+		Long: `Show or change the dispatcher of an operation, which decides the example a mock answers with (URI parts, script, JSON body, query match, fallback, proxy...),
+and the default delay of its responses`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.HelpFunc()(cmd, args)
+		},
+	}
+
+	dispatcherCmd.AddCommand(NewServicesDispatcherGetCommand(globalClientOpts))
+	dispatcherCmd.AddCommand(NewServicesDispatcherSetCommand(globalClientOpts))
+
+	return dispatcherCmd
+}
+
+func NewServicesDispatcherGetCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var getCmd = &cobra.Command{
+		Use:   "get <apiName:apiVersion> <operation>",
+		Short: "Print the dispatcher of an operation, as a file for 'set --file'",
+		Example: `# Save the dispatcher next to the spec
+microcks services dispatcher get "WeatherForecast API:1.1.0" "GET /forecast/{region}" > forecast.dispatcher.yaml`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mc, _, err := newMicrocksClientFromOptions(globalClientOpts)
+			if err != nil {
+				return err
+			}
+			svc, err := mc.GetService(args[0])
+			if err != nil {
+				return err
+			}
+			op, err := findOperation(*svc, args[1])
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "# %s:%s %s\n", svc.Name, svc.Version, op.Name)
+			return printDispatcherFile(cmd.OutOrStdout(), op)
+		},
+	}
+	return getCmd
+}
+
+func NewServicesDispatcherSetCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var (
+		file       string
+		dispatcher string
+		rules      string
+		rulesFile  string
+		delay      duration.Value
+	)
+
+	var setCmd = &cobra.Command{
+		Use:   "set <apiName:apiVersion> <operation>",
+		Short: "Change the dispatcher and default delay of an operation",
+		Long: `Change the dispatcher and default delay of an operation, from a file and/or flags; flags override the file.
+Settings not given are kept, except rules which are cleared when the dispatcher type changes.`,
+		Example: `# Apply a dispatcher versioned in Git
+microcks services dispatcher set "WeatherForecast API:1.1.0" "GET /forecast/{region}" -f forecast.dispatcher.yaml
+
+# Dispatch on a path part, answering after 200ms
+microcks services dispatcher set "WeatherForecast API:1.1.0" "GET /forecast/{region}" --dispatcher URI_PARTS --rules region --delay 200ms
+
+# Only change the delay
+microcks services dispatcher set "WeatherForecast API:1.1.0" "GET /forecast/{region}" --delay 1s`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if rules != "" && rulesFile != "" {
+				return errors.Wrapf(errors.KindUsage, "--rules and --rules-file cannot be combined")
+			}
+
+			var spec dispatcherFile
+			var specDelay *time.Duration
+			if file != "" {
+				loaded, d, err := loadDispatcherFile(file)
+				if err != nil {
+					return err
+				}
+				spec, specDelay = *loaded, d
+			}
+			if dispatcher != "" {
+				spec.Dispatcher = dispatcher
+			}
+			if rules != "" {
+				spec.DispatcherRules = rules
+			}
+			if rulesFile != "" {
+				data, err := os.ReadFile(rulesFile)
+				if err != nil {
+					return errors.Wrapf(errors.KindUsage, "--rules-file: %v", err)
+				}
+				spec.DispatcherRules = string(data)
+			}
+			if cmd.Flags().Changed("delay") {
+				d := delay.Duration()
+				specDelay = &d
+			}
+			if spec.Dispatcher == "" && spec.DispatcherRules == "" && specDelay == nil {
+				return errors.Wrapf(errors.KindUsage, "nothing to set: give --file, --dispatcher, --rules or --delay")
+			}
+
+			mc, _, err := newMicrocksClientFromOptions(globalClientOpts)
+			if err != nil {
+				return err
+			}
+			svc, err := mc.GetService(args[0])
+			if err != nil {
+				return err
+			}
+			op, err := findOperation(*svc, args[1])
+			if err != nil {
+				return err
+			}
+
+			override, err := mergeOperationOverride(op, spec, specDelay)
+			if err != nil {
+				return err
+			}
+			if err := mc.UpdateOperation(svc.ID, op.Name, override); err != nil {
+				return err
+			}
+
+			op.Dispatcher, op.DispatcherRules, op.DefaultDelay = override.Dispatcher, override.DispatcherRules, override.DefaultDelay
+			fmt.Fprintf(cmd.OutOrStdout(), "Operation '%s' of '%s:%s' updated\n", op.Name, svc.Name, svc.Version)
+			return printDispatcherFile(cmd.OutOrStdout(), op)
+		},
+	}
+
+	setCmd.Flags().StringVarP(&file, "file", "f", "", "YAML file with dispatcher, dispatcherRules and defaultDelay, as printed by 'get'")
+	setCmd.Flags().StringVar(&dispatcher, "dispatcher", "", "Dispatcher type, e.g. URI_PARTS, JSON_BODY, SCRIPT, FALLBACK, PROXY")
+	setCmd.Flags().StringVar(&rules, "rules", "", "Dispatcher rules")
+	setCmd.Flags().StringVar(&rulesFile, "rules-file", "", "File holding the dispatcher rules, e.g. a script")
+	setCmd.Flags().Var(&delay, "delay", "Default response delay, e.g. 200ms")
+
+	return setCmd
+}
+
+// loadDispatcherFile reads a dispatcher file, returning its delay apart as
+// it is unset when missing.
+func loadDispatcherFile(path string) (*dispatcherFile, *time.Duration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, errors.Wrapf(errors.KindUsage, "--file: cannot read %s: %v", path, err)
+	}
+	var spec dispatcherFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&spec); err != nil && err != io.EOF {
+		return nil, nil, errors.Wrapf(errors.KindUsage, "--file: invalid %s: %v", path, err)
+	}
+	if spec.DefaultDelay == "" {
+		return &spec, nil, nil
+	}
+	d, err := duration.Parse(spec.DefaultDelay)
+	if err != nil {
+		return nil, nil, errors.Wrapf(errors.KindUsage, "--file: defaultDelay: %v", err)
+	}
+	return &spec, &d, nil
+}
+
+// mergeOperationOverride applies the settings given on top of the current
+// ones of the operation and validates the result.
+func mergeOperationOverride(op *connectors.Operation, spec dispatcherFile, delay *time.Duration) (*connectors.OperationOverride, error) {
+	override := &connectors.OperationOverride{
+		Dispatcher:      op.Dispatcher,
+		DispatcherRules: op.DispatcherRules,
+		DefaultDelay:    op.DefaultDelay,
+	}
+	if spec.Dispatcher != "" {
+		spec.Dispatcher = strings.ToUpper(spec.Dispatcher)
+		if spec.Dispatcher != op.Dispatcher {
+			override.DispatcherRules = ""
+		}
+		override.Dispatcher = spec.Dispatcher
+	}
+	if spec.DispatcherRules != "" {
+		override.DispatcherRules = spec.DispatcherRules
+	}
+	if delay != nil {
+		if *delay < 0 {
+			return nil, errors.Wrapf(errors.KindUsage, "delay cannot be negative")
+		}
+		override.DefaultDelay = delay.Milliseconds()
+	}
+
+	needsRules, known := connectors.DispatcherTypes[override.Dispatcher]
+	switch {
+	case override.Dispatcher == "" && override.DispatcherRules != "":
+		return nil, errors.Wrapf(errors.KindUsage, "rules need a dispatcher type")
+	case override.Dispatcher == "":
+	case !known:
+		return nil, errors.Wrapf(errors.KindUsage, "unknown dispatcher '%s'", override.Dispatcher)
+	case needsRules && strings.TrimSpace(override.DispatcherRules) == "":
+		return nil, errors.Wrapf(errors.KindUsage, "dispatcher %s needs rules", override.Dispatcher)
+	case jsonRulesDispatchers[override.Dispatcher] && !json.Valid([]byte(override.DispatcherRules)):
+		return nil, errors.Wrapf(errors.KindUsage, "rules of dispatcher %s must be JSON", override.Dispatcher)
+	}
+	return override, nil
+}
+
+func printDispatcherFile(out io.Writer, op *connectors.Operation) error {
+	spec := dispatcherFile{Dispatcher: op.Dispatcher, DispatcherRules: op.DispatcherRules}
+	if op.DefaultDelay > 0 {
+		spec.DefaultDelay = (time.Duration(op.DefaultDelay) * time.Millisecond).String()
+	}
+	if spec == (dispatcherFile{}) {
+		_, err := fmt.Fprintln(out, "# No dispatcher nor delay set: Microcks uses its defaults")
+		return err
+	}
+	encoder := yaml.NewEncoder(out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(spec); err != nil {
+		return errors.Wrap(errors.KindGeneric, err)
+	}
+	return encoder.Close()
+}
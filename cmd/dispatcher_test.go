@@ -0,0 +1,76 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/fakeserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServicesDispatcherRoundTrip(t *testing.T) {
+	fake := fakeserver.New()
+	fake.AddService(fakeserver.Service{Name: "Weather", Version: "1.0", Operations: []fakeserver.Operation{
+		{Name: "GET /forecast/{region}", Method: "GET", Dispatcher: "URI_PARTS", DispatcherRules: "region"},
+	}})
+	const op = "GET /forecast/{region}"
+
+	out, err := runAgainstFake(t, fake, NewServicesCommand, "", "dispatcher", "get", "Weather:1.0", op)
+	require.NoError(t, err)
+	assert.Equal(t, "# Weather:1.0 GET /forecast/{region}\ndispatcher: URI_PARTS\ndispatcherRules: region\n", out)
+
+	_, err = runAgainstFake(t, fake, NewServicesCommand, "", "dispatcher", "set", "Weather:1.0", op, "--delay", "250ms")
+	require.NoError(t, err)
+	operation := fake.Services()[0].Operations[0]
+	assert.Equal(t, "URI_PARTS", operation.Dispatcher)
+	assert.Equal(t, "region", operation.DispatcherRules)
+	assert.Equal(t, int64(250), operation.DefaultDelay)
+
+	file := filepath.Join(t.TempDir(), "forecast.dispatcher.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`dispatcher: JSON_BODY
+dispatcherRules: |
+  {"exp": "/region", "operator": "equals", "cases": {"north": "North", "default": "South"}}
+`), 0o600))
+	out, err = runAgainstFake(t, fake, NewServicesCommand, "", "dispatcher", "set", "Weather:1.0", op, "-f", file)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Operation 'GET /forecast/{region}' of 'Weather:1.0' updated\ndispatcher: JSON_BODY\n")
+	assert.Contains(t, out, "defaultDelay: 250ms\n")
+	operation = fake.Services()[0].Operations[0]
+	assert.Equal(t, "JSON_BODY", operation.Dispatcher)
+	assert.Contains(t, operation.DispatcherRules, `"operator": "equals"`)
+
+	for _, args := range [][]string{
+		{"--dispatcher", "SEQUENCE_ALL"},
+		{"--dispatcher", "URI_PARAMS"},
+		{"--dispatcher", "FALLBACK", "--rules", "not json"},
+		{"--rules", "a", "--rules-file", file},
+		{},
+	} {
+		_, err = runAgainstFake(t, fake, NewServicesCommand, "", append([]string{"dispatcher", "set", "Weather:1.0", op}, args...)...)
+		assert.Equal(t, errors.KindUsage, errors.KindOf(err), "%v", args)
+	}
+
+	_, err = runAgainstFake(t, fake, NewServicesCommand, "", "dispatcher", "set", "Weather:1.0", op, "--dispatcher", "sequence")
+	require.NoError(t, err)
+	operation = fake.Services()[0].Operations[0]
+	assert.Equal(t, "SEQUENCE", operation.Dispatcher)
+	assert.Empty(t, operation.DispatcherRules)
+}
@@ -79,3 +79,15 @@ func TestUsageErrorIsKindUsage(t *testing.T) {
 		t.Fatalf("KindOf = %v, want KindUsage", got)
 	}
 }
+
+func TestInvalidFlagValueIsKindUsage(t *testing.T) {
+	cmd, err := NewCommand()
+	if err != nil {
+		t.Fatalf("NewCommand returned error: %v", err)
+	}
+	cmd.SetArgs([]string{"test", "my-api:1.0", "http://localhost:3000", "HTTP", "--waitFor", "5hours"})
+	err = cmd.Execute()
+	if got := errors.KindOf(err); got != errors.KindUsage {
+		t.Fatalf("KindOf = %v, want KindUsage (err: %v)", got, err)
+	}
+}
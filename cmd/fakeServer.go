@@ -0,0 +1,91 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/fakeserver"
+	"github.com/microcks/microcks-cli/pkg/util/duration"
+	"github.com/spf13/cobra"
+)
+
+func NewFakeServerCommand() *cobra.Command {
+	var (
+		port           int
+		testSuccess    bool
+		testInProgress duration.Value
+	)
+	var fakeServerCmd = &cobra.Command{
+		Use:   "fake-server",
+		Short: "Run an in-memory fake Microcks server for offline demos",
+		Long: `Run an in-memory fake Microcks server for offline demos.
+
+The fake server answers the Microcks API endpoints used by the CLI (artifact
+upload and download, services, tests and Keycloak config) from memory. Nothing
+is mocked or really tested: it is meant to rehearse pipelines without a real
+Microcks instance.`,
+		Example: `# Start a fake server on port 8080
+microcks fake-server
+
+# Make every test fail after running for 5 seconds
+microcks fake-server --port 9090 --test-success=false --test-in-progress 5s`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fake := fakeserver.New()
+			fake.SetDefaultTestOutcome(fakeserver.TestOutcome{
+				Success:       testSuccess,
+				InProgressFor: testInProgress.Duration(),
+			})
+
+			listener, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
+			if err != nil {
+				return errors.Wrap(errors.KindEnvironment, fmt.Errorf("cannot listen on port %d: %w", port, err))
+			}
+
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer cancel()
+
+			srv := &http.Server{Handler: fake}
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer shutdownCancel()
+				_ = srv.Shutdown(shutdownCtx)
+			}()
+
+			fmt.Printf("Fake Microcks server listening on http://%s — press Ctrl+C to stop.\n", listener.Addr())
+			if err := srv.Serve(listener); err != http.ErrServerClosed {
+				return errors.Wrap(errors.KindEnvironment, err)
+			}
+			return nil
+		},
+	}
+
+	fakeServerCmd.Flags().IntVar(&port, "port", 8080, "Port to listen on")
+	fakeServerCmd.Flags().BoolVar(&testSuccess, "test-success", true, "Whether launched tests succeed")
+	fakeServerCmd.Flags().Var(&testInProgress, "test-in-progress", "How long launched tests stay in progress")
+
+	return fakeServerCmd
+}
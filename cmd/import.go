@@ -17,6 +17,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
 
@@ -28,7 +29,10 @@ import (
 )
 
 func NewImportCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
-	var watch bool
+	var (
+		watch     bool
+		lintFirst bool
+	)
 
 	var importCmd = &cobra.Command{
 		Use:   "import",
@@ -43,6 +47,17 @@ func NewImportCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command
 
 			specificationFiles := args[0]
 
+			// Lint the artifacts before anything reaches the server.
+			if lintFirst {
+				var files []string
+				for _, f := range strings.Split(specificationFiles, ",") {
+					files = append(files, strings.Split(f, ":")[0])
+				}
+				if err := lintBeforeImport(cmd.OutOrStdout(), &RealFileSystem{}, files); err != nil {
+					return err
+				}
+			}
+
 			// Initialize config from command options.
 			config.InsecureTLS = globalClientOpts.InsecureTLS
 			config.CaCertPaths = globalClientOpts.CaCertPaths
@@ -140,6 +155,11 @@ func NewImportCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command
 				}
 				fmt.Printf("Microcks has %s '%s'\n", action, msg)
 
+				// Label the service if the artifact has a labels sidecar file.
+				if err := applyLabelsSidecar(os.Stdout, mc, f, msg); err != nil {
+					return err
+				}
+
 				// If watch flag is provided, update watch config.
 				if watch {
 					watchFile, err := config.DefaultLocalWatchPath()
@@ -194,5 +214,6 @@ func NewImportCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command
 	}
 
 	importCmd.Flags().BoolVar(&watch, "watch", false, "Keep watch on file changes and re-import it on change")
+	importCmd.Flags().BoolVar(&lintFirst, "lint", false, "Lint the artifacts first and import nothing if errors are found")
 	return importCmd
 }
@@ -17,11 +17,12 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
-	"github.com/microcks/microcks-cli/pkg/config"
 	"github.com/microcks/microcks-cli/pkg/connectors"
 	"github.com/microcks/microcks-cli/pkg/errors"
 	"github.com/spf13/cobra"
@@ -44,18 +45,23 @@ type ImportResult struct {
 	SuccessFiles []string
 	FailedFiles  []string
 	Errors       []string
+	// ServiceRefs maps imported files to the `name:version` of their service.
+	ServiceRefs map[string]string
 }
 
 type ImportConfig struct {
 	Recursive bool
 	Pattern   string
 	Verbose   bool
+	// Lint lints the files first and imports none if errors are found.
+	Lint bool
 }
 
 type FileSystem interface {
 	Stat(path string) (os.FileInfo, error)
 	Walk(root string, walkFn filepath.WalkFunc) error
 	ReadDir(name string) ([]os.DirEntry, error)
+	ReadFile(name string) ([]byte, error)
 }
 
 type RealFileSystem struct{}
@@ -72,6 +78,10 @@ func (fs *RealFileSystem) ReadDir(name string) ([]os.DirEntry, error) {
 	return os.ReadDir(name)
 }
 
+func (fs *RealFileSystem) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
 var supportedExtensions = map[string]bool{
 	".yaml": true,
 	".yml":  true,
@@ -101,6 +111,7 @@ func NewImportDirCommand(globalClientOpts *connectors.ClientOptions) *cobra.Comm
 		recursive bool
 		pattern   string
 		verbose   bool
+		lintFirst bool
 	)
 
 	var importDirCmd = &cobra.Command{
@@ -115,7 +126,9 @@ func NewImportDirCommand(globalClientOpts *connectors.ClientOptions) *cobra.Comm
 			microcks import-dir ./api-specs
 			microcks import-dir ./api-specs --recursive
 			microcks import-dir ./api-specs --pattern "*.yaml"
-			microcks import-dir ./api-specs --recursive --pattern "openapi.*"`,
+			microcks import-dir ./api-specs --recursive --pattern "openapi.*"
+			microcks import-dir ./api-specs --lint
+			microcks import-dir ./api-specs --microcksURL http://localhost:8080/api/ --keycloakClientId foo --keycloakClientSecret bar`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
 				return errors.Wrapf(errors.KindUsage, "import-dir requires a directory path argument")
@@ -123,25 +136,8 @@ func NewImportDirCommand(globalClientOpts *connectors.ClientOptions) *cobra.Comm
 
 			dirPath := args[0]
 
-			config.InsecureTLS = globalClientOpts.InsecureTLS
-			config.CaCertPaths = globalClientOpts.CaCertPaths
-			config.Verbose = globalClientOpts.Verbose
-
-			localConfig, err := config.ReadLocalConfig(globalClientOpts.ConfigPath)
-			if err != nil {
-				return err
-			}
-
-			if localConfig == nil {
-				return errors.Wrapf(errors.KindUsage, "please login to perform this operation")
-			}
-
-			if globalClientOpts.Context == "" {
-				globalClientOpts.Context = localConfig.CurrentContext
-			}
-
-			// Create client
-			mc, err := connectors.NewClient(*globalClientOpts)
+			// Create client, from the service account flags or the local config.
+			mc, _, err := newMicrocksClientFromOptions(globalClientOpts)
 			if err != nil {
 				return err
 			}
@@ -152,10 +148,11 @@ func NewImportDirCommand(globalClientOpts *connectors.ClientOptions) *cobra.Comm
 				Recursive: recursive,
 				Pattern:   pattern,
 				Verbose:   verbose,
+				Lint:      lintFirst,
 			}
 
 			// Execute business logic
-			result, err := ImportDirectory(mc, fs, dirPath, importConfig)
+			result, err := ImportDirectory(cmd.OutOrStdout(), mc, fs, dirPath, importConfig)
 			if err != nil {
 				if _, ok := err.(*ValidationError); ok {
 					return errors.Wrap(errors.KindUsage, err)
@@ -163,45 +160,58 @@ func NewImportDirCommand(globalClientOpts *connectors.ClientOptions) *cobra.Comm
 				return err
 			}
 
-			// Display results
-			if verbose {
-				fmt.Printf("Found %d specification files to import...\n", result.TotalFiles)
-				for i, file := range result.SuccessFiles {
-					fmt.Printf("[%d/%d] ✓ Imported: %s\n", i+1, result.TotalFiles, file)
-				}
-				for i, file := range result.FailedFiles {
-					errorMsg := "Unknown error"
-					if i < len(result.Errors) {
-						errorMsg = result.Errors[i]
-					}
-					fmt.Printf("✗ Failed: %s - %s\n", file, errorMsg)
-				}
-			} else {
-				fmt.Println("\nImport results:")
-				for _, file := range result.SuccessFiles {
-					fmt.Printf("✓ Imported: %s\n", file)
-				}
-				for i, file := range result.FailedFiles {
-					errorMsg := "Unknown error"
-					if i < len(result.Errors) {
-						errorMsg = result.Errors[i]
-					}
-					fmt.Printf("✗ Failed: %s - %s\n", file, errorMsg)
-				}
-			}
-
-			fmt.Printf("\nImport completed: %d/%d files imported successfully\n", result.SuccessCount, result.TotalFiles)
-			return importDirectoryPartialFailure(result)
+			return reportImportDirectory(mc, result, verbose)
 		},
 	}
 
 	importDirCmd.Flags().BoolVar(&recursive, "recursive", false, "Scan subdirectories recursively")
 	importDirCmd.Flags().StringVar(&pattern, "pattern", "", "File pattern to match (e.g., '*.yaml', 'openapi.*')")
 	importDirCmd.Flags().BoolVar(&verbose, "verbose", false, "Show detailed progress")
+	importDirCmd.Flags().BoolVar(&lintFirst, "lint", false, "Lint the files first and import nothing if errors are found")
 
 	return importDirCmd
 }
 
+// reportImportDirectory prints the results of ImportDirectory, labels the
+// imported services from their sidecar files and fails on partial imports.
+func reportImportDirectory(mc connectors.MicrocksClient, result ImportResult, verbose bool) error {
+	if verbose {
+		fmt.Printf("Found %d specification files to import...\n", result.TotalFiles)
+		for i, file := range result.SuccessFiles {
+			fmt.Printf("[%d/%d] ✓ Imported: %s\n", i+1, result.TotalFiles, file)
+		}
+		for i, file := range result.FailedFiles {
+			errorMsg := "Unknown error"
+			if i < len(result.Errors) {
+				errorMsg = result.Errors[i]
+			}
+			fmt.Printf("✗ Failed: %s - %s\n", file, errorMsg)
+		}
+	} else {
+		fmt.Println("\nImport results:")
+		for _, file := range result.SuccessFiles {
+			fmt.Printf("✓ Imported: %s\n", file)
+		}
+		for i, file := range result.FailedFiles {
+			errorMsg := "Unknown error"
+			if i < len(result.Errors) {
+				errorMsg = result.Errors[i]
+			}
+			fmt.Printf("✗ Failed: %s - %s\n", file, errorMsg)
+		}
+	}
+
+	fmt.Printf("\nImport completed: %d/%d files imported successfully\n", result.SuccessCount, result.TotalFiles)
+
+	// Label services from the sidecar files of the imported artifacts.
+	for _, file := range result.SuccessFiles {
+		if err := applyLabelsSidecar(os.Stdout, mc, file, result.ServiceRefs[file]); err != nil {
+			return err
+		}
+	}
+	return importDirectoryPartialFailure(result)
+}
+
 func importDirectoryPartialFailure(result ImportResult) error {
 	if result.FailedCount == 0 {
 		return nil
@@ -209,7 +219,7 @@ func importDirectoryPartialFailure(result ImportResult) error {
 	return errors.Wrapf(errors.KindAPI, "%d/%d files failed to import", result.FailedCount, result.TotalFiles)
 }
 
-func ImportDirectory(client MicrocksClient, fs FileSystem, dirPath string, config ImportConfig) (ImportResult, error) {
+func ImportDirectory(out io.Writer, client MicrocksClient, fs FileSystem, dirPath string, config ImportConfig) (ImportResult, error) {
 	if err := validateDirectory(fs, dirPath); err != nil {
 		return ImportResult{}, err
 	}
@@ -222,10 +232,18 @@ func ImportDirectory(client MicrocksClient, fs FileSystem, dirPath string, confi
 	if len(files) == 0 {
 		return ImportResult{}, &ValidationError{Message: fmt.Sprintf("no specification files found in directory: %s", dirPath)}
 	}
+	sortPrimaryFirst(files)
+
+	if config.Lint {
+		if err := lintBeforeImport(out, fs, files); err != nil {
+			return ImportResult{}, err
+		}
+	}
 
 	result := ImportResult{
 		TotalFiles:   len(files),
 		SuccessFiles: make([]string, 0, len(files)),
+		ServiceRefs:  make(map[string]string, len(files)),
 		FailedFiles:  make([]string, 0, len(files)),
 		Errors:       make([]string, 0, len(files)),
 	}
@@ -244,11 +262,12 @@ func ImportDirectory(client MicrocksClient, fs FileSystem, dirPath string, confi
 			if !fileType.IsPrimary {
 				action = "completed"
 			}
-			fmt.Printf("Microcks has %s '%s'\n", action, msg)
+			fmt.Fprintf(out, "Microcks has %s '%s'\n", action, msg)
 		}
 
 		result.SuccessCount++
 		result.SuccessFiles = append(result.SuccessFiles, file)
+		result.ServiceRefs[file] = strings.TrimSpace(msg)
 	}
 
 	return result, nil
@@ -288,7 +307,7 @@ func findSpecificationFiles(fs FileSystem, dirPath string, recursive bool, patte
 		}
 
 		ext := strings.ToLower(filepath.Ext(path))
-		if !supportedExtensions[ext] {
+		if !supportedExtensions[ext] || isLabelsSidecar(path) {
 			return nil
 		}
 
@@ -310,6 +329,15 @@ func findSpecificationFiles(fs FileSystem, dirPath string, recursive bool, patte
 	return files, err
 }
 
+// sortPrimaryFirst orders primary artifacts before secondary ones, keeping
+// the walk order otherwise: Microcks rejects a secondary artifact of a
+// service no primary artifact has defined yet.
+func sortPrimaryFirst(files []string) {
+	sort.SliceStable(files, func(i, j int) bool {
+		return detectFileType(files[i]).IsPrimary && !detectFileType(files[j]).IsPrimary
+	})
+}
+
 func detectFileType(filePath string) FileType {
 	fileName := strings.ToLower(filepath.Base(filePath))
 	ext := filepath.Ext(filePath)
@@ -17,6 +17,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
@@ -45,6 +46,7 @@ func (m *MockMicrocksClient) UploadArtifact(file string, main bool) (string, err
 
 type MockFileSystem struct {
 	Files      map[string]bool // path -> isDir
+	Contents   map[string][]byte
 	StatErrors map[string]error
 	WalkErrors map[string]error
 }
@@ -83,6 +85,14 @@ func (m *MockFileSystem) ReadDir(name string) ([]os.DirEntry, error) {
 	return nil, nil
 }
 
+func (m *MockFileSystem) ReadFile(name string) ([]byte, error) {
+	content, exists := m.Contents[name]
+	if !exists {
+		return nil, os.ErrNotExist
+	}
+	return content, nil
+}
+
 type MockFileInfo struct {
 	name  string
 	isDir bool
@@ -189,7 +199,7 @@ func TestImportDirectory(t *testing.T) {
 			}
 
 			// Execute
-			result, err := ImportDirectory(mockClient, mockFS, "/test", tt.config)
+			result, err := ImportDirectory(io.Discard, mockClient, mockFS, "/test", tt.config)
 
 			// Assertions
 			if tt.expectError {
@@ -207,6 +217,25 @@ func TestImportDirectory(t *testing.T) {
 	}
 }
 
+func TestImportDirectoryUploadsPrimaryFirst(t *testing.T) {
+	mockClient := &MockMicrocksClient{}
+	mockFS := &MockFileSystem{Files: map[string]bool{
+		"/test":                      true,
+		"/test/a-postman.json":       false,
+		"/test/b-openapi.yaml":       false,
+		"/test/c-api-metadata.yaml":  false,
+		"/test/d-asyncapi.yaml":      false,
+		"/test/e-collection.json":    false,
+		"/test/f-graphql-schema.yml": false,
+	}}
+
+	_, err := ImportDirectory(io.Discard, mockClient, mockFS, "/test", ImportConfig{})
+	require.NoError(t, err)
+	require.Len(t, mockClient.Uploaded, 6)
+	assert.ElementsMatch(t, []string{"/test/b-openapi.yaml", "/test/d-asyncapi.yaml", "/test/f-graphql-schema.yml"}, mockClient.Uploaded[:3])
+	assert.ElementsMatch(t, []string{"/test/a-postman.json", "/test/c-api-metadata.yaml", "/test/e-collection.json"}, mockClient.Uploaded[3:])
+}
+
 // TestValidateDirectory tests directory validation
 func TestValidateDirectory(t *testing.T) {
 	tests := []struct {
@@ -305,6 +334,16 @@ func TestFindSpecificationFilesWithFS(t *testing.T) {
 			pattern:   "*.yaml",
 			expected:  []string{"/test/openapi.yaml"},
 		},
+		{
+			name: "labels sidecars are skipped",
+			files: map[string]bool{
+				"/test":                     true, // Directory must exist
+				"/test/petstore.yaml":       false,
+				"/test/petstore.labels.yml": false,
+			},
+			recursive: false,
+			expected:  []string{"/test/petstore.yaml"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -417,7 +456,7 @@ func BenchmarkImportDirectory(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, err := ImportDirectory(mockClient, mockFS, "/test", config)
+		_, err := ImportDirectory(io.Discard, mockClient, mockFS, "/test", config)
 		require.NoError(b, err)
 	}
 }
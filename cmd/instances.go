@@ -0,0 +1,630 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/microcks/microcks-cli/pkg/config"
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/util/duration"
+	"github.com/spf13/cobra"
+)
+
+// newContainerClient builds the ContainerClient of a driver; tests swap it
+// for an in-memory runtime.
+var newContainerClient = connectors.NewContainerClient
+
+const (
+	instanceRunning = "Running"
+	instanceExited  = "Exited"
+	// instanceMissing is never persisted: it reports a recorded instance
+	// whose container the runtime no longer knows.
+	instanceMissing = "Missing"
+	instanceUnknown = "Unknown"
+)
+
+func NewInstancesCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var instancesCmd = &cobra.Command{
+		Use:     "instances",
+		Aliases: []string{"instance"},
+		Short:   "Manage local Microcks instances",
+		Long: `Manage the local Microcks instances created by 'microcks start': check their
+actual container state, read their logs, restart or remove them`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.HelpFunc()(cmd, args)
+		},
+	}
+
+	instancesCmd.AddCommand(NewInstancesListCommand(globalClientOpts))
+	instancesCmd.AddCommand(NewInstancesStatusCommand(globalClientOpts))
+	instancesCmd.AddCommand(NewInstancesLogsCommand(globalClientOpts))
+	instancesCmd.AddCommand(NewInstancesRestartCommand(globalClientOpts))
+	instancesCmd.AddCommand(NewInstancesRmCommand(globalClientOpts))
+	instancesCmd.AddCommand(NewInstancesUpgradeCommand(globalClientOpts))
+	instancesCmd.AddCommand(NewInstancesExportCommand(globalClientOpts))
+
+	return instancesCmd
+}
+
+func NewInstancesListCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List local instances with their actual container state",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			localConfig, err := readInstancesConfig(globalClientOpts)
+			if err != nil {
+				return err
+			}
+			if len(localConfig.Instances) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No Microcks instance found")
+				return nil
+			}
+
+			runtimes := newContainerClients()
+			defer runtimes.close()
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tSTATUS\tURL\tDRIVER\tIMAGE\tCONTEXT")
+			for i := range localConfig.Instances {
+				instance := &localConfig.Instances[i]
+				// One unreachable runtime (e.g. a stopped Podman machine)
+				// shouldn't hide the other instances.
+				status, err := reconcileInstance(runtimes, instance)
+				if err != nil {
+					status = instanceUnknown
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", instance.Name, status, instanceURL(*instance),
+					instanceDriver(*instance), instance.Image, instanceContext(localConfig, instance.Name))
+			}
+			w.Flush()
+			return config.WriteLocalConfig(*localConfig, globalClientOpts.ConfigPath)
+		},
+	}
+	return listCmd
+}
+
+func NewInstancesStatusCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var statusCmd = &cobra.Command{
+		Use:   "status [name]",
+		Short: "Show the state of an instance (the current context's one by default)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			localConfig, err := readInstancesConfig(globalClientOpts)
+			if err != nil {
+				return err
+			}
+			instance, err := resolveInstance(localConfig, args)
+			if err != nil {
+				return err
+			}
+
+			runtimes := newContainerClients()
+			defer runtimes.close()
+			details, status, err := inspectInstance(runtimes, instance)
+			if err != nil {
+				return errors.Wrap(errors.KindEnvironment, err)
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Name:       %s\n", instance.Name)
+			fmt.Fprintf(out, "Status:     %s\n", status)
+			fmt.Fprintf(out, "URL:        %s\n", instanceURL(*instance))
+			fmt.Fprintf(out, "Driver:     %s\n", instanceDriver(*instance))
+			fmt.Fprintf(out, "Image:      %s\n", instance.Image)
+			fmt.Fprintf(out, "Container:  %s\n", instance.ContainerID)
+			fmt.Fprintf(out, "Context:    %s\n", instanceContext(localConfig, instance.Name))
+			fmt.Fprintf(out, "AutoRemove: %t\n", instance.AutoRemove)
+			if details != nil {
+				printContainerDetails(out, details)
+			}
+			if err := printImageDrift(out, runtimes, instance, details); err != nil {
+				return errors.Wrap(errors.KindEnvironment, err)
+			}
+			if instance.Async != "" {
+				if err := printCompanions(out, runtimes, instance); err != nil {
+					return errors.Wrap(errors.KindEnvironment, err)
+				}
+			}
+			switch status {
+			case instanceRunning:
+				if err := waitForReady(instanceURL(*instance), time.Second); err != nil {
+					fmt.Fprintln(out, "Server:     not answering yet")
+				} else {
+					fmt.Fprintln(out, "Server:     ready")
+				}
+			case instanceMissing:
+				fmt.Fprintf(out, "The container no longer exists: run 'microcks start --name %s' to recreate it "+
+					"or 'microcks instances rm %s' to forget it\n", instance.Name, instance.Name)
+			}
+			return config.WriteLocalConfig(*localConfig, globalClientOpts.ConfigPath)
+		},
+	}
+	return statusCmd
+}
+
+func NewInstancesLogsCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var (
+		follow bool
+		tail   string
+	)
+
+	var logsCmd = &cobra.Command{
+		Use:   "logs [name]",
+		Short: "Print the container logs of an instance (the current context's one by default)",
+		Example: `# Last 100 lines of the current instance
+microcks instances logs --tail 100
+
+# Stream the logs of an instance until interrupted
+microcks instances logs microcks -f`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			localConfig, err := readInstancesConfig(globalClientOpts)
+			if err != nil {
+				return err
+			}
+			instance, err := resolveInstance(localConfig, args)
+			if err != nil {
+				return err
+			}
+
+			runtimes := newContainerClients()
+			defer runtimes.close()
+			status, err := reconcileInstance(runtimes, instance)
+			if err != nil {
+				return errors.Wrap(errors.KindEnvironment, err)
+			}
+			if status == instanceMissing {
+				return errors.Wrapf(errors.KindNotFound, "container of instance '%s' no longer exists", instance.Name)
+			}
+
+			containerClient, err := runtimes.get(instanceDriver(*instance))
+			if err != nil {
+				return errors.Wrap(errors.KindEnvironment, err)
+			}
+			if err := containerClient.ContainerLogs(instance.ContainerID, connectors.LogOpts{Follow: follow, Tail: tail}, cmd.OutOrStdout()); err != nil {
+				return errors.Wrap(errors.KindEnvironment, fmt.Errorf("failed to read container logs: %w", err))
+			}
+			return nil
+		},
+	}
+
+	logsCmd.Flags().BoolVarP(&follow, "follow", "f", false, "Keep streaming the logs until the container stops")
+	logsCmd.Flags().StringVar(&tail, "tail", "all", "Number of lines to show from the end of the logs")
+	return logsCmd
+}
+
+func NewInstancesRestartCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var (
+		readyTimeout = duration.Value(60 * time.Second)
+		noWait       bool
+	)
+
+	var restartCmd = &cobra.Command{
+		Use:   "restart [name]",
+		Short: "Restart an instance, recreating its container if it was removed",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			localConfig, err := readInstancesConfig(globalClientOpts)
+			if err != nil {
+				return err
+			}
+			instance, err := resolveInstance(localConfig, args)
+			if err != nil {
+				return err
+			}
+
+			if instance.Async != "" && noWait {
+				return errors.Wrapf(errors.KindUsage, "--no-wait cannot be used with an --async instance: the async minion is started once the server is ready")
+			}
+
+			runtimes := newContainerClients()
+			defer runtimes.close()
+			status, err := reconcileInstance(runtimes, instance)
+			if err != nil {
+				return errors.Wrap(errors.KindEnvironment, err)
+			}
+			containerClient, err := runtimes.get(instanceDriver(*instance))
+			if err != nil {
+				return errors.Wrap(errors.KindEnvironment, err)
+			}
+			if err := recreateMissingCompanions(containerClient, instance); err != nil {
+				return errors.Wrap(errors.KindEnvironment, err)
+			}
+
+			out := cmd.OutOrStdout()
+			switch status {
+			case instanceMissing:
+				fmt.Fprintf(out, "Container for instance %s no longer exists, recreating it\n", instance.Name)
+				opts, err := instanceContainerOpts(*instance)
+				if err != nil {
+					return errors.Wrap(errors.KindUsage, err)
+				}
+				containerId, err := containerClient.CreateContainer(opts)
+				if err != nil {
+					return errors.Wrap(errors.KindEnvironment, fmt.Errorf("failed to create container: %w", err))
+				}
+				instance.ContainerID = containerId
+				if err := pinImage(containerClient, instance); err != nil {
+					return errors.Wrap(errors.KindEnvironment, err)
+				}
+			case instanceRunning:
+				if err := stopCompanions(containerClient, instance, companionMinion); err != nil {
+					return errors.Wrap(errors.KindEnvironment, err)
+				}
+				if err := containerClient.StopContainer(instance.ContainerID); err != nil {
+					return errors.Wrap(errors.KindEnvironment, fmt.Errorf("failed to stop container: %w", err))
+				}
+				if err := stopCompanions(containerClient, instance, companionBroker); err != nil {
+					return errors.Wrap(errors.KindEnvironment, err)
+				}
+			}
+			if err := startCompanions(containerClient, instance, companionBroker); err != nil {
+				return errors.Wrap(errors.KindEnvironment, err)
+			}
+			if err := containerClient.StartContainer(instance.ContainerID); err != nil {
+				return errors.Wrap(errors.KindEnvironment, fmt.Errorf("failed to start container: %w", err))
+			}
+			instance.Status = instanceRunning
+			if err := config.WriteLocalConfig(*localConfig, globalClientOpts.ConfigPath); err != nil {
+				return err
+			}
+
+			server := instanceURL(*instance)
+			if !noWait {
+				fmt.Fprintf(out, "Waiting for Microcks to be ready at %s ...\n", server)
+				if err := waitForReady(server, readyTimeout.Duration()); err != nil {
+					return errors.Wrapf(errors.KindEnvironment, "Microcks container is restarted but the server is not ready: %v. "+
+						"It may still be booting — retry shortly or raise --ready-timeout", err)
+				}
+			}
+			if err := startCompanions(containerClient, instance, companionMinion); err != nil {
+				return errors.Wrap(errors.KindEnvironment, err)
+			}
+			fmt.Fprintf(out, "Instance %s restarted at %s\n", instance.Name, server)
+			return nil
+		},
+	}
+
+	restartCmd.Flags().Var(&readyTimeout, "ready-timeout", "how long to wait for the Microcks server to be ready before failing")
+	restartCmd.Flags().BoolVar(&noWait, "no-wait", false, "return as soon as the container is started, without waiting for the Microcks server to be ready")
+	return restartCmd
+}
+
+func NewInstancesRmCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var force bool
+
+	var rmCmd = &cobra.Command{
+		Use:   "rm <name>...",
+		Short: "Remove instances: their container and their context, server, user and auth entries",
+		Example: `# Forget an instance whose container is stopped or gone
+microcks instances rm microcks
+
+# Also stop it when it is running
+microcks instances rm microcks --force`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			localConfig, err := readInstancesConfig(globalClientOpts)
+			if err != nil {
+				return err
+			}
+
+			runtimes := newContainerClients()
+			defer runtimes.close()
+
+			// Check everything first so that a typo or a running instance
+			// doesn't leave the others half removed.
+			var (
+				instances []*config.Instance
+				statuses  []string
+			)
+			for _, name := range args {
+				instance, err := localConfig.GetInstance(name)
+				if err != nil {
+					return errors.Wrapf(errors.KindNotFound, "instance '%s' not found", name)
+				}
+				status, err := reconcileInstance(runtimes, instance)
+				if err != nil {
+					return errors.Wrap(errors.KindEnvironment, err)
+				}
+				if status == instanceRunning && !force {
+					return errors.Wrapf(errors.KindUsage, "instance '%s' is running: stop it first or use --force", name)
+				}
+				instances = append(instances, instance)
+				statuses = append(statuses, status)
+			}
+
+			for i, instance := range instances {
+				if statuses[i] != instanceMissing {
+					containerClient, err := runtimes.get(instanceDriver(*instance))
+					if err != nil {
+						return errors.Wrap(errors.KindEnvironment, err)
+					}
+					// Anonymous volumes are useless once their container is gone.
+					err = containerClient.RemoveContainer(instance.ContainerID, true)
+					if err != nil && !stderrors.Is(err, connectors.ErrContainerNotFound) {
+						return errors.Wrap(errors.KindEnvironment, fmt.Errorf("failed to remove container: %w", err))
+					}
+				}
+				if instance.Async != "" {
+					containerClient, err := runtimes.get(instanceDriver(*instance))
+					if err != nil {
+						return errors.Wrap(errors.KindEnvironment, err)
+					}
+					if err := tearDownAsync(containerClient, instance); err != nil {
+						return errors.Wrap(errors.KindEnvironment, err)
+					}
+				}
+				removeInstanceConfig(localConfig, instance.Name)
+				fmt.Fprintf(cmd.OutOrStdout(), "Instance %s removed\n", instance.Name)
+			}
+			return config.WriteLocalConfig(*localConfig, globalClientOpts.ConfigPath)
+		},
+	}
+
+	rmCmd.Flags().BoolVarP(&force, "force", "f", false, "Remove running instances too")
+	return rmCmd
+}
+
+func printContainerDetails(out io.Writer, details *connectors.ContainerDetails) {
+	if details.ImageDigest != "" {
+		fmt.Fprintf(out, "Digest:     %s\n", details.ImageDigest)
+	}
+	ports := make([]string, 0, len(details.Ports))
+	for port, binding := range details.Ports {
+		ports = append(ports, binding+"->"+port)
+	}
+	sort.Strings(ports)
+	fmt.Fprintf(out, "Ports:      %s\n", strings.Join(ports, ", "))
+	fmt.Fprintf(out, "State:      %s", details.State)
+	switch {
+	case details.State == "exited" || details.State == "dead":
+		fmt.Fprintf(out, " (exit code %d)", details.ExitCode)
+	case details.Health != "":
+		fmt.Fprintf(out, " (%s)", details.Health)
+	}
+	fmt.Fprintln(out)
+	if details.StartedAt != "" && !strings.HasPrefix(details.StartedAt, "0001-") {
+		fmt.Fprintf(out, "Started:    %s\n", details.StartedAt)
+	}
+}
+
+// printImageDrift reports the pinned image digest of an instance, and
+// whether its container or the local image of its tag moved from it.
+func printImageDrift(out io.Writer, runtimes *containerClients, instance *config.Instance, details *connectors.ContainerDetails) error {
+	if instance.ImageDigest == "" {
+		return nil
+	}
+	fmt.Fprintf(out, "Pinned:     %s\n", instance.ImageDigest)
+	if details != nil && details.ImageDigest != "" && details.ImageDigest != instance.ImageDigest {
+		fmt.Fprintf(out, "Drift:      the container runs %s, not the pinned image\n", details.ImageDigest)
+	}
+
+	containerClient, err := runtimes.get(instanceDriver(*instance))
+	if err != nil {
+		return err
+	}
+	images, err := containerClient.ListImages(instance.Image)
+	if err != nil {
+		return err
+	}
+	for _, image := range images {
+		if len(image.RepoDigests) > 0 && !slices.Contains(image.RepoDigests, instance.ImageDigest) {
+			fmt.Fprintf(out, "Drift:      %s now points to %s: run 'microcks instances upgrade %s' to use it\n",
+				instance.Image, image.RepoDigests[0], instance.Name)
+			break
+		}
+	}
+	return nil
+}
+
+func printCompanions(out io.Writer, runtimes *containerClients, instance *config.Instance) error {
+	containerClient, err := runtimes.get(instanceDriver(*instance))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Async:      %s on network %s\n", instance.Async, instance.Network)
+	for _, companion := range instance.Companions {
+		state := "missing"
+		details, err := containerClient.InspectContainer(companion.ContainerID)
+		switch {
+		case err == nil:
+			state = details.State
+		case !stderrors.Is(err, connectors.ErrContainerNotFound):
+			return err
+		}
+		fmt.Fprintf(out, "  %-13s %s (%s): %s\n", companion.Role, companion.Name, companion.Image, state)
+	}
+	return nil
+}
+
+func readInstancesConfig(globalClientOpts *connectors.ClientOptions) (*config.LocalConfig, error) {
+	localConfig, err := config.ReadLocalConfig(globalClientOpts.ConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	if localConfig == nil {
+		localConfig = &config.LocalConfig{}
+	}
+	return localConfig, nil
+}
+
+// resolveInstance returns the instance named in args, or the one of the
+// current context. It points into localConfig so updates get saved.
+func resolveInstance(localConfig *config.LocalConfig, args []string) (*config.Instance, error) {
+	var name string
+	if len(args) > 0 {
+		name = args[0]
+	} else {
+		ctx, err := localConfig.ResolveContext("")
+		if err != nil || ctx.Instance.Name == "" {
+			return nil, errors.Wrapf(errors.KindUsage, "no instance is associated with the current context, give an instance name")
+		}
+		name = ctx.Instance.Name
+	}
+	for i := range localConfig.Instances {
+		if localConfig.Instances[i].Name == name {
+			return &localConfig.Instances[i], nil
+		}
+	}
+	return nil, errors.Wrapf(errors.KindNotFound, "instance '%s' not found", name)
+}
+
+// reconcileInstance asks the runtime for the actual state of the instance
+// container and updates the recorded status, which drifts on system
+// restarts, autoRemove or a manual `docker rm`. A vanished container is
+// reported as Missing but keeps its recorded status.
+func reconcileInstance(runtimes *containerClients, instance *config.Instance) (string, error) {
+	_, status, err := inspectInstance(runtimes, instance)
+	return status, err
+}
+
+// inspectInstance is reconcileInstance also returning the container
+// details, nil when the container is missing.
+func inspectInstance(runtimes *containerClients, instance *config.Instance) (*connectors.ContainerDetails, string, error) {
+	if instance.ContainerID == "" {
+		return nil, instanceMissing, nil
+	}
+	containerClient, err := runtimes.get(instanceDriver(*instance))
+	if err != nil {
+		return nil, "", err
+	}
+	details, err := containerClient.InspectContainer(instance.ContainerID)
+	if stderrors.Is(err, connectors.ErrContainerNotFound) {
+		return nil, instanceMissing, nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	switch details.State {
+	case "running", "restarting":
+		instance.Status = instanceRunning
+	default:
+		instance.Status = instanceExited
+	}
+	return details, instance.Status, nil
+}
+
+// removeInstanceConfig drops an instance and the contexts pointing at it,
+// with their server, user and auth entries.
+func removeInstanceConfig(localConfig *config.LocalConfig, name string) {
+	removeInstanceContexts(localConfig, name)
+	localConfig.RemoveInstance(name)
+}
+
+// removeInstanceContexts drops the contexts pointing at an instance, with
+// their server, user and auth entries.
+func removeInstanceContexts(localConfig *config.LocalConfig, name string) {
+	for _, ctx := range append([]config.ContextRef(nil), localConfig.Contexts...) {
+		if ctx.Instance != name {
+			continue
+		}
+		localConfig.RemoveContext(ctx.Name)
+		localConfig.RemoveServer(ctx.Server)
+		localConfig.RemoveUser(ctx.User)
+		localConfig.RemoveAuth(ctx.Server)
+		if localConfig.CurrentContext == ctx.Name {
+			localConfig.CurrentContext = ""
+		}
+	}
+}
+
+// pinImage records the repository digest of the image the instance
+// container was created from. An existing pin is kept: only upgrade moves
+// it, by clearing it first.
+func pinImage(containerClient connectors.ContainerClient, instance *config.Instance) error {
+	if instance.ImageDigest != "" {
+		return nil
+	}
+	details, err := containerClient.InspectContainer(instance.ContainerID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect container: %w", err)
+	}
+	instance.ImageDigest = details.ImageDigest
+	return nil
+}
+
+func instanceContext(localConfig *config.LocalConfig, name string) string {
+	for _, ctx := range localConfig.Contexts {
+		if ctx.Instance == name {
+			return ctx.Name
+		}
+	}
+	return ""
+}
+
+// instanceURL is the URL of an instance from the host. Instances bound to a
+// specific interface (--host-ip) are only reachable on its address.
+func instanceURL(instance config.Instance) string {
+	return "http://" + instanceAddress(instance, instance.Port)
+}
+
+// instanceAddress returns the host:port a port published by the instance is
+// reached at: localhost unless it is bound to a specific --host-ip.
+func instanceAddress(instance config.Instance, port string) string {
+	switch instance.HostIP {
+	case "", connectors.LOCALHOST_IP, "0.0.0.0", "::":
+		return net.JoinHostPort("localhost", port)
+	}
+	return net.JoinHostPort(instance.HostIP, port)
+}
+
+// instanceDriver defaults to docker for instances recorded before the
+// driver was.
+func instanceDriver(instance config.Instance) string {
+	if instance.Driver == "" {
+		return "docker"
+	}
+	return instance.Driver
+}
+
+// containerClients opens one ContainerClient per driver, on first use.
+type containerClients struct {
+	clients map[string]connectors.ContainerClient
+}
+
+func newContainerClients() *containerClients {
+	return &containerClients{clients: map[string]connectors.ContainerClient{}}
+}
+
+func (c *containerClients) get(driver string) (connectors.ContainerClient, error) {
+	if client, ok := c.clients[driver]; ok {
+		return client, nil
+	}
+	client, err := newContainerClient(driver)
+	if err != nil {
+		return nil, err
+	}
+	c.clients[driver] = client
+	return client, nil
+}
+
+func (c *containerClients) close() {
+	for _, client := range c.clients {
+		client.CloseClient()
+	}
+}
@@ -0,0 +1,527 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/microcks/microcks-cli/pkg/config"
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// importerImage runs `microcks import-dir` to preload the artifacts of
+	// an exported instance.
+	importerImage = "quay.io/microcks/microcks-cli:latest"
+	importerAlias = "microcks-importer"
+	// artifactsMountPath is where the importer finds the artifacts.
+	artifactsMountPath = "/artifacts"
+)
+
+func NewInstancesExportCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var (
+		format string
+		output string
+	)
+
+	var exportCmd = &cobra.Command{
+		Use:   "export [name]",
+		Short: "Generate a docker-compose file or Kubernetes manifests reproducing an instance",
+		Example: `# Reproduce the current instance with Docker Compose
+microcks instances export --format compose -o compose.yaml
+docker compose -f compose.yaml up -d
+
+# Deploy an instance, its async broker and artifacts in a Kubernetes namespace
+microcks instances export billing --format k8s | kubectl apply -n billing -f -`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "compose" && format != "k8s" {
+				return errors.Wrapf(errors.KindUsage, "--format must be 'compose' or 'k8s', got '%s'", format)
+			}
+			localConfig, err := readInstancesConfig(globalClientOpts)
+			if err != nil {
+				return err
+			}
+			instance, err := resolveInstance(localConfig, args)
+			if err != nil {
+				return err
+			}
+
+			var manifest []byte
+			if format == "compose" {
+				manifest, err = composeManifest(*instance)
+			} else {
+				manifest, err = k8sManifests(*instance, cmd.ErrOrStderr())
+			}
+			if err != nil {
+				return err
+			}
+
+			if output == "" || output == "-" {
+				_, err := cmd.OutOrStdout().Write(manifest)
+				return err
+			}
+			if err := os.WriteFile(output, manifest, 0o644); err != nil {
+				return errors.Wrapf(errors.KindEnvironment, "cannot write %s: %v", output, err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Exported instance %s to %s\n", instance.Name, output)
+			return nil
+		},
+	}
+
+	exportCmd.Flags().StringVar(&format, "format", "compose", "Output format: 'compose' or 'k8s'")
+	exportCmd.Flags().StringVarP(&output, "output", "o", "", "File to write (default: stdout)")
+	return exportCmd
+}
+
+// exportedService is a container of an exported instance, named after its
+// alias on the instance network so that the environment recorded for the
+// local containers (MICROCKS_HOST_PORT, ASYNC_MINION_URL, broker listeners)
+// keeps resolving.
+type exportedService struct {
+	name string
+	opts connectors.ContainerOpts
+	// ports are the container ports, e.g. 8080, served by the service.
+	ports []string
+}
+
+// exportedServices lists the Microcks container of an instance, then its
+// async broker and minion.
+func exportedServices(instance config.Instance) ([]exportedService, error) {
+	opts, err := instanceContainerOpts(instance)
+	if err != nil {
+		return nil, errors.Wrap(errors.KindUsage, err)
+	}
+	microcks := exportedService{name: microcksAlias, opts: opts, ports: []string{connectors.MICROCKS_DEFAULT_PORT}}
+	if instance.GrpcPort != "" {
+		microcks.ports = append(microcks.ports, connectors.MICROCKS_GRPC_PORT)
+	}
+	services := []exportedService{microcks}
+	if instance.Async == "" {
+		return services, nil
+	}
+
+	broker := asyncBrokers[instance.Async]
+	services = append(services,
+		exportedService{name: broker.alias, opts: companionContainerOpts(instance, companionBroker), ports: []string{broker.port}},
+		exportedService{name: asyncMinionAlias, opts: companionContainerOpts(instance, companionMinion), ports: []string{asyncMinionPort}})
+	return services, nil
+}
+
+// importerCommand imports the mounted artifacts into the exported Microcks.
+// The uber image has no Keycloak: any service account is accepted.
+func importerCommand() []string {
+	return []string{"microcks", "import-dir", artifactsMountPath, "--recursive",
+		"--microcksURL", "http://" + microcksAlias + ":" + connectors.MICROCKS_DEFAULT_PORT + "/api/",
+		"--keycloakClientId", "foo", "--keycloakClientSecret", "bar"}
+}
+
+type composeFile struct {
+	Name     string                    `yaml:"name"`
+	Services map[string]composeService `yaml:"services"`
+	Networks map[string]composeNetwork `yaml:"networks,omitempty"`
+}
+
+type composeService struct {
+	Image       string   `yaml:"image"`
+	Command     []string `yaml:"command,omitempty"`
+	Environment []string `yaml:"environment,omitempty"`
+	Ports       []string `yaml:"ports,omitempty"`
+	Volumes     []string `yaml:"volumes,omitempty"`
+	Networks    []string `yaml:"networks,omitempty"`
+	DependsOn   []string `yaml:"depends_on,omitempty"`
+	Restart     string   `yaml:"restart,omitempty"`
+	CPUs        string   `yaml:"cpus,omitempty"`
+	MemLimit    string   `yaml:"mem_limit,omitempty"`
+}
+
+type composeNetwork struct {
+	External bool `yaml:"external,omitempty"`
+}
+
+// composeManifest is a docker-compose file running the instance, in a
+// project named after it.
+func composeManifest(instance config.Instance) ([]byte, error) {
+	services, err := exportedServices(instance)
+	if err != nil {
+		return nil, err
+	}
+	file := composeFile{Name: instance.Name, Services: map[string]composeService{}}
+
+	hostIP := instance.HostIP
+	if hostIP == "" {
+		hostIP = connectors.LOCALHOST_IP
+	}
+	for _, svc := range services {
+		service := composeService{
+			Image:       svc.opts.Image,
+			Command:     svc.opts.Cmd,
+			Environment: svc.opts.Env,
+			Volumes:     svc.opts.Volumes,
+			Restart:     "unless-stopped",
+		}
+		bindings := map[string]string{}
+		if svc.opts.Port != "" {
+			bindings[connectors.MICROCKS_DEFAULT_PORT] = svc.opts.Port
+		}
+		if svc.opts.GrpcPort != "" {
+			bindings[connectors.MICROCKS_GRPC_PORT] = svc.opts.GrpcPort
+		}
+		for containerPort, hostPort := range svc.opts.Ports {
+			bindings[strings.TrimSuffix(containerPort, "/tcp")] = hostPort
+		}
+		for containerPort, hostPort := range bindings {
+			service.Ports = append(service.Ports, hostIP+":"+hostPort+":"+containerPort)
+		}
+		sort.Strings(service.Ports)
+		if svc.opts.CPUs > 0 {
+			service.CPUs = strconv.FormatFloat(svc.opts.CPUs, 'f', -1, 64)
+		}
+		if svc.name == microcksAlias {
+			service.MemLimit = instance.Memory
+			if instance.Network != "" && instance.Async == "" {
+				// A network given with --network exists beforehand.
+				service.Networks = []string{instance.Network}
+				file.Networks = map[string]composeNetwork{instance.Network: {External: true}}
+			}
+		}
+		if svc.name == asyncMinionAlias {
+			service.DependsOn = []string{microcksAlias, asyncBrokers[instance.Async].alias}
+		}
+		file.Services[svc.name] = service
+	}
+
+	if instance.Artifacts != "" {
+		// Until Microcks answers, the importer fails and is restarted.
+		file.Services[importerAlias] = composeService{
+			Image:     importerImage,
+			Command:   importerCommand(),
+			Volumes:   []string{instance.Artifacts + ":" + artifactsMountPath + ":ro"},
+			Networks:  file.Services[microcksAlias].Networks,
+			DependsOn: []string{microcksAlias},
+			Restart:   "on-failure",
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(file); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type k8sObject struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   k8sMetadata       `yaml:"metadata"`
+	Spec       any               `yaml:"spec,omitempty"`
+	Data       map[string]string `yaml:"data,omitempty"`
+}
+
+type k8sMetadata struct {
+	Name   string            `yaml:"name,omitempty"`
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+type k8sDeploymentSpec struct {
+	Replicas int                `yaml:"replicas"`
+	Selector k8sSelector        `yaml:"selector"`
+	Template k8sPodTemplateSpec `yaml:"template"`
+}
+
+type k8sSelector struct {
+	MatchLabels map[string]string `yaml:"matchLabels"`
+}
+
+type k8sPodTemplateSpec struct {
+	Metadata k8sMetadata `yaml:"metadata"`
+	Spec     k8sPodSpec  `yaml:"spec"`
+}
+
+type k8sPodSpec struct {
+	RestartPolicy string         `yaml:"restartPolicy,omitempty"`
+	Containers    []k8sContainer `yaml:"containers"`
+	Volumes       []k8sVolume    `yaml:"volumes,omitempty"`
+}
+
+type k8sContainer struct {
+	Name         string           `yaml:"name"`
+	Image        string           `yaml:"image"`
+	Command      []string         `yaml:"command,omitempty"`
+	Args         []string         `yaml:"args,omitempty"`
+	Env          []k8sEnvVar      `yaml:"env,omitempty"`
+	Ports        []k8sPort        `yaml:"ports,omitempty"`
+	Resources    *k8sResources    `yaml:"resources,omitempty"`
+	VolumeMounts []k8sVolumeMount `yaml:"volumeMounts,omitempty"`
+}
+
+type k8sEnvVar struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+type k8sPort struct {
+	ContainerPort int `yaml:"containerPort"`
+}
+
+type k8sResources struct {
+	Limits map[string]string `yaml:"limits"`
+}
+
+type k8sVolumeMount struct {
+	Name      string `yaml:"name"`
+	MountPath string `yaml:"mountPath"`
+	ReadOnly  bool   `yaml:"readOnly,omitempty"`
+}
+
+type k8sVolume struct {
+	Name      string             `yaml:"name"`
+	ConfigMap k8sConfigMapVolume `yaml:"configMap"`
+}
+
+type k8sConfigMapVolume struct {
+	Name  string         `yaml:"name"`
+	Items []k8sKeyToPath `yaml:"items"`
+}
+
+type k8sKeyToPath struct {
+	Key  string `yaml:"key"`
+	Path string `yaml:"path"`
+}
+
+type k8sServiceSpec struct {
+	Selector map[string]string `yaml:"selector"`
+	Ports    []k8sServicePort  `yaml:"ports"`
+}
+
+type k8sServicePort struct {
+	Name       string `yaml:"name"`
+	Port       int    `yaml:"port"`
+	TargetPort int    `yaml:"targetPort"`
+}
+
+type k8sJobSpec struct {
+	BackoffLimit int                `yaml:"backoffLimit"`
+	Template     k8sPodTemplateSpec `yaml:"template"`
+}
+
+// k8sManifests are the Deployment and Service of each container of the
+// instance and, with artifacts, a ConfigMap holding them and a Job
+// importing them. Host bind mounts can't be reproduced: they are reported
+// on warnings.
+func k8sManifests(instance config.Instance, warnings io.Writer) ([]byte, error) {
+	services, err := exportedServices(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	var artifacts k8sVolume
+	var objects []k8sObject
+	if instance.Artifacts != "" {
+		configMap, volume, err := artifactsConfigMap(instance)
+		if err != nil {
+			return nil, err
+		}
+		artifacts = volume
+		objects = append(objects, configMap)
+	}
+
+	for _, svc := range services {
+		labels := k8sLabels(instance, svc.name)
+		container := k8sContainer{Name: svc.name, Image: svc.opts.Image, Args: svc.opts.Cmd}
+		for _, e := range svc.opts.Env {
+			name, value, _ := strings.Cut(e, "=")
+			container.Env = append(container.Env, k8sEnvVar{Name: name, Value: value})
+		}
+		for _, port := range svc.ports {
+			p, _ := strconv.Atoi(port)
+			container.Ports = append(container.Ports, k8sPort{ContainerPort: p})
+		}
+		pod := k8sPodSpec{}
+		if svc.name == microcksAlias {
+			container.Resources = k8sLimits(svc.opts)
+			for _, volume := range svc.opts.Volumes {
+				if strings.Contains(volume, ":"+artifactsContainerPath) && instance.Artifacts != "" {
+					container.VolumeMounts = append(container.VolumeMounts,
+						k8sVolumeMount{Name: artifacts.Name, MountPath: artifactsContainerPath, ReadOnly: true})
+					pod.Volumes = append(pod.Volumes, artifacts)
+					continue
+				}
+				fmt.Fprintf(warnings, "Volume %s is a host bind mount, it is not exported\n", volume)
+			}
+		}
+		pod.Containers = []k8sContainer{container}
+
+		objects = append(objects, k8sObject{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Metadata:   k8sMetadata{Name: svc.name, Labels: labels},
+			Spec: k8sDeploymentSpec{
+				Replicas: 1,
+				Selector: k8sSelector{MatchLabels: labels},
+				Template: k8sPodTemplateSpec{Metadata: k8sMetadata{Labels: labels}, Spec: pod},
+			},
+		})
+		serviceSpec := k8sServiceSpec{Selector: labels}
+		for _, port := range svc.ports {
+			p, _ := strconv.Atoi(port)
+			serviceSpec.Ports = append(serviceSpec.Ports, k8sServicePort{Name: k8sPortName(port), Port: p, TargetPort: p})
+		}
+		objects = append(objects, k8sObject{
+			APIVersion: "v1",
+			Kind:       "Service",
+			Metadata:   k8sMetadata{Name: svc.name, Labels: labels},
+			Spec:       serviceSpec,
+		})
+	}
+
+	if instance.Artifacts != "" {
+		labels := k8sLabels(instance, importerAlias)
+		objects = append(objects, k8sObject{
+			APIVersion: "batch/v1",
+			Kind:       "Job",
+			Metadata:   k8sMetadata{Name: importerAlias, Labels: labels},
+			Spec: k8sJobSpec{
+				// Until Microcks answers, the importer fails and is retried.
+				BackoffLimit: 10,
+				Template: k8sPodTemplateSpec{
+					Metadata: k8sMetadata{Labels: labels},
+					Spec: k8sPodSpec{
+						RestartPolicy: "OnFailure",
+						Containers: []k8sContainer{{
+							Name:         importerAlias,
+							Image:        importerImage,
+							Command:      importerCommand(),
+							VolumeMounts: []k8sVolumeMount{{Name: artifacts.Name, MountPath: artifactsMountPath, ReadOnly: true}},
+						}},
+						Volumes: []k8sVolume{artifacts},
+					},
+				},
+			},
+		})
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	for _, object := range objects {
+		if err := encoder.Encode(object); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func k8sLabels(instance config.Instance, component string) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":     component,
+		"app.kubernetes.io/instance": instance.Name,
+	}
+}
+
+func k8sPortName(port string) string {
+	switch port {
+	case connectors.MICROCKS_DEFAULT_PORT, asyncMinionPort:
+		return "http"
+	case connectors.MICROCKS_GRPC_PORT:
+		return "grpc"
+	}
+	return "broker"
+}
+
+// k8sLimits translates the CPU and memory limits of a container, nil when
+// it has none.
+func k8sLimits(opts connectors.ContainerOpts) *k8sResources {
+	limits := map[string]string{}
+	if opts.CPUs > 0 {
+		limits["cpu"] = strconv.FormatFloat(opts.CPUs, 'f', -1, 64)
+	}
+	if opts.Memory > 0 {
+		limits["memory"] = k8sQuantity(opts.Memory)
+	}
+	if len(limits) == 0 {
+		return nil
+	}
+	return &k8sResources{Limits: limits}
+}
+
+// k8sQuantity formats bytes with the largest binary suffix dividing them.
+func k8sQuantity(bytes int64) string {
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"Gi", 1 << 30}, {"Mi", 1 << 20}, {"Ki", 1 << 10}} {
+		if bytes%unit.size == 0 {
+			return strconv.FormatInt(bytes/unit.size, 10) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(bytes, 10)
+}
+
+var configMapKeyInvalid = regexp.MustCompile(`[^-._a-zA-Z0-9]`)
+
+// artifactsConfigMap holds the artifacts of the instance directory, with
+// the volume restoring their tree: ConfigMap keys can't hold a path.
+func artifactsConfigMap(instance config.Instance) (k8sObject, k8sVolume, error) {
+	name := microcksAlias + "-artifacts"
+	configMap := k8sObject{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata:   k8sMetadata{Name: name, Labels: k8sLabels(instance, name)},
+		Data:       map[string]string{},
+	}
+	volume := k8sVolume{Name: "artifacts", ConfigMap: k8sConfigMapVolume{Name: name}}
+
+	err := filepath.WalkDir(instance.Artifacts, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !supportedExtensions[strings.ToLower(filepath.Ext(path))] {
+			return err
+		}
+		rel, err := filepath.Rel(instance.Artifacts, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		key := configMapKeyInvalid.ReplaceAllString(strings.ReplaceAll(rel, "/", "."), "_")
+		if _, ok := configMap.Data[key]; ok {
+			return fmt.Errorf("artifacts %s and another file map to the same ConfigMap key %s", rel, key)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		configMap.Data[key] = string(content)
+		volume.ConfigMap.Items = append(volume.ConfigMap.Items, k8sKeyToPath{Key: key, Path: rel})
+		return nil
+	})
+	if err != nil {
+		return configMap, volume, errors.Wrapf(errors.KindEnvironment, "cannot read artifacts of instance %s: %v", instance.Name, err)
+	}
+	return configMap, volume, nil
+}
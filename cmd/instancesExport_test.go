@@ -0,0 +1,194 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/microcks/microcks-cli/pkg/config"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/fakeserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// exportableInstance is an async instance with container options and
+// artifacts, primary at the root and secondary in a subdirectory.
+func exportableInstance(t *testing.T) config.Instance {
+	dir := copySamples(t, map[string]string{
+		"weather-forecast-openapi.yml":  "weather-openapi.yml",
+		"weather-forecast-postman.json": "postman/weather postman.json",
+	})
+	return config.Instance{
+		Name: "billing", Image: "quay.io/microcks/microcks-uber:1.12.0", Port: "8686", ContainerID: "c1",
+		Async: "kafka", Network: "billing-network", Env: []string{"FEATURE=on"},
+		Volumes:  []string{dir + ":/deployments/artifacts:ro", "/etc/ssl/certs:/deployments/certs:ro"},
+		GrpcPort: "9191", CPUs: 1.5, Memory: "512m", Artifacts: dir,
+	}
+}
+
+func TestInstancesExportCompose(t *testing.T) {
+	instance := exportableInstance(t)
+	configPath := writeInstancesConfig(t, instance)
+
+	out, err := runInstances(t, configPath, "export", "billing", "--format", "compose")
+	require.NoError(t, err)
+
+	var compose composeFile
+	require.NoError(t, yaml.Unmarshal([]byte(out), &compose))
+	assert.Equal(t, "billing", compose.Name)
+	require.Len(t, compose.Services, 4)
+
+	microcks := compose.Services["microcks"]
+	assert.Equal(t, "quay.io/microcks/microcks-uber:1.12.0", microcks.Image)
+	assert.Equal(t, []string{"127.0.0.1:8686:8080", "127.0.0.1:9191:9090"}, microcks.Ports)
+	assert.Equal(t, []string{"FEATURE=on", "ASYNC_MINION_URL=http://microcks-async-minion:8081"}, microcks.Environment)
+	assert.Equal(t, instance.Volumes, microcks.Volumes)
+	assert.Equal(t, "1.5", microcks.CPUs)
+	assert.Equal(t, "512m", microcks.MemLimit)
+
+	assert.Equal(t, []string{"127.0.0.1:9092:9092"}, compose.Services["kafka"].Ports)
+	minion := compose.Services["microcks-async-minion"]
+	assert.Equal(t, "quay.io/microcks/microcks-uber-async-minion:1.12.0", minion.Image)
+	assert.Equal(t, []string{"microcks", "kafka"}, minion.DependsOn)
+
+	importer := compose.Services["microcks-importer"]
+	assert.Equal(t, []string{instance.Artifacts + ":/artifacts:ro"}, importer.Volumes)
+	assert.Contains(t, importer.Command, "http://microcks:8080/api/")
+	assert.Equal(t, "on-failure", importer.Restart)
+}
+
+func TestInstancesExportComposeOnUserNetwork(t *testing.T) {
+	configPath := writeInstancesConfig(t, config.Instance{Name: "microcks", Image: "microcks-uber", Port: "8585",
+		Network: "ci", HostIP: "0.0.0.0"})
+	output := filepath.Join(t.TempDir(), "compose.yaml")
+
+	out, err := runInstances(t, configPath, "export", "-o", output)
+	require.NoError(t, err)
+	assert.Equal(t, "Exported instance microcks to "+output+"\n", out)
+
+	content, err := os.ReadFile(output)
+	require.NoError(t, err)
+	var compose composeFile
+	require.NoError(t, yaml.Unmarshal(content, &compose))
+	require.Len(t, compose.Services, 1)
+	assert.Equal(t, []string{"0.0.0.0:8585:8080"}, compose.Services["microcks"].Ports)
+	assert.Equal(t, []string{"ci"}, compose.Services["microcks"].Networks)
+	assert.Equal(t, map[string]composeNetwork{"ci": {External: true}}, compose.Networks)
+}
+
+func TestInstancesExportKubernetes(t *testing.T) {
+	instance := exportableInstance(t)
+	var warnings bytes.Buffer
+	manifests, err := k8sManifests(instance, &warnings)
+	require.NoError(t, err)
+	assert.Equal(t, "Volume /etc/ssl/certs:/deployments/certs:ro is a host bind mount, it is not exported\n", warnings.String())
+
+	var objects []map[string]any
+	decoder := yaml.NewDecoder(bytes.NewReader(manifests))
+	for {
+		var object map[string]any
+		if err := decoder.Decode(&object); err == io.EOF {
+			break
+		} else {
+			require.NoError(t, err)
+		}
+		objects = append(objects, object)
+	}
+	var kinds []string
+	for _, object := range objects {
+		kinds = append(kinds, object["kind"].(string)+"/"+object["metadata"].(map[string]any)["name"].(string))
+	}
+	assert.Equal(t, []string{
+		"ConfigMap/microcks-artifacts",
+		"Deployment/microcks", "Service/microcks",
+		"Deployment/kafka", "Service/kafka",
+		"Deployment/microcks-async-minion", "Service/microcks-async-minion",
+		"Job/microcks-importer",
+	}, kinds)
+
+	data := objects[0]["data"].(map[string]any)
+	assert.Contains(t, data, "weather-openapi.yml")
+	assert.Contains(t, data, "postman.weather_postman.json")
+
+	var deployment struct {
+		Spec k8sDeploymentSpec `yaml:"spec"`
+	}
+	content, err := yaml.Marshal(objects[1])
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(content, &deployment))
+	pod := deployment.Spec.Template.Spec
+	require.Len(t, pod.Containers, 1)
+	assert.Equal(t, map[string]string{"cpu": "1.5", "memory": "512Mi"}, pod.Containers[0].Resources.Limits)
+	assert.Equal(t, []k8sPort{{ContainerPort: 8080}, {ContainerPort: 9090}}, pod.Containers[0].Ports)
+	require.Len(t, pod.Volumes, 1)
+	assert.Contains(t, pod.Volumes[0].ConfigMap.Items, k8sKeyToPath{Key: "postman.weather_postman.json", Path: "postman/weather postman.json"})
+}
+
+// TestImporterCommandRunsWithoutConfig runs the command of the exported
+// importer, which has no local config, against a fake Microcks.
+func TestImporterCommandRunsWithoutConfig(t *testing.T) {
+	instance := exportableInstance(t)
+	fake := fakeserver.New()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	args := importerCommand()
+	require.Equal(t, "microcks", args[0])
+	args = append([]string(nil), args[1:]...)
+	for i, arg := range args {
+		switch {
+		case arg == artifactsMountPath:
+			args[i] = instance.Artifacts
+		case strings.HasPrefix(arg, "http://"+microcksAlias+":"):
+			args[i] = server.URL + "/api/"
+		}
+	}
+	args = append(args, "--config", filepath.Join(t.TempDir(), "config"))
+
+	root, err := NewCommand()
+	require.NoError(t, err)
+	root.SetArgs(args)
+	root.SetOut(io.Discard)
+	require.NoError(t, root.Execute())
+
+	services := fake.Services()
+	require.Len(t, services, 1)
+	assert.Equal(t, "WeatherForecast API", services[0].Name)
+}
+
+func TestInstancesExportValidation(t *testing.T) {
+	configPath := writeInstancesConfig(t, config.Instance{Name: "microcks", Port: "8585"})
+
+	_, err := runInstances(t, configPath, "export", "--format", "helm")
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+
+	_, err = runInstances(t, configPath, "export", "unknown")
+	assert.Equal(t, errors.KindNotFound, errors.KindOf(err))
+}
+
+func TestK8sQuantity(t *testing.T) {
+	assert.Equal(t, "2Gi", k8sQuantity(2<<30))
+	assert.Equal(t, "1536Mi", k8sQuantity(1536<<20))
+	assert.Equal(t, "1000", k8sQuantity(1000))
+}
@@ -0,0 +1,257 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	stderrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/microcks/microcks-cli/pkg/config"
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/util/duration"
+	"github.com/spf13/cobra"
+)
+
+func NewInstancesUpgradeCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var (
+		image        string
+		readyTimeout = duration.Value(60 * time.Second)
+		noRestore    bool
+	)
+
+	var upgradeCmd = &cobra.Command{
+		Use:   "upgrade [name]",
+		Short: "Pull the image of an instance, or a new one, and recreate its container with the same options",
+		Example: `# Move the current instance to the latest build of its tag
+microcks instances upgrade
+
+# Switch an instance to another tag of its image
+microcks instances upgrade billing --image 1.12.1-native`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			localConfig, err := readInstancesConfig(globalClientOpts)
+			if err != nil {
+				return err
+			}
+			instance, err := resolveInstance(localConfig, args)
+			if err != nil {
+				return err
+			}
+			target := upgradeImage(instance.Image, image)
+
+			runtimes := newContainerClients()
+			defer runtimes.close()
+			status, err := reconcileInstance(runtimes, instance)
+			if err != nil {
+				return errors.Wrap(errors.KindEnvironment, err)
+			}
+			containerClient, err := runtimes.get(instanceDriver(*instance))
+			if err != nil {
+				return errors.Wrap(errors.KindEnvironment, err)
+			}
+
+			// The repository of Microcks lives in its container: snapshot
+			// it while the server answers, to restore it in the new one.
+			out := cmd.OutOrStdout()
+			contextName := instanceContext(localConfig, instance.Name)
+			if contextName == "" && !noRestore {
+				fmt.Fprintf(out, "Instance %s has no context, its mocks won't be restored\n", instance.Name)
+				noRestore = true
+			}
+			// The snapshot is saved to a file, kept until it is restored.
+			var snapshotPath string
+			if status == instanceRunning && !noRestore {
+				if snapshotPath, err = saveInstanceSnapshot(globalClientOpts, contextName, instance.Name); err != nil {
+					fmt.Fprintf(out, "Cannot snapshot instance %s, its mocks won't be restored: %s\n", instance.Name, err)
+				} else if snapshotPath != "" {
+					fmt.Fprintf(out, "Saved the mocks of instance %s to %s\n", instance.Name, snapshotPath)
+					defer func() {
+						if err != nil {
+							fmt.Fprintf(out, "The snapshot of instance %s is kept at %s: restore it with 'microcks snapshot import %s'\n",
+								instance.Name, snapshotPath, snapshotPath)
+						}
+					}()
+				}
+			}
+
+			fmt.Fprintf(out, "Pulling %s ...\n", target)
+			if err := containerClient.PullImage(target); err != nil {
+				return errors.Wrap(errors.KindEnvironment, fmt.Errorf("failed to pull %s: %w", target, err))
+			}
+
+			// The async minion image follows the Microcks one.
+			for _, companion := range instance.Companions {
+				if companion.Role != companionMinion {
+					continue
+				}
+				err := containerClient.RemoveContainer(companion.ContainerID, true)
+				if err != nil && !stderrors.Is(err, connectors.ErrContainerNotFound) {
+					return errors.Wrap(errors.KindEnvironment, fmt.Errorf("failed to remove %s container: %w", companion.Role, err))
+				}
+			}
+			// The volumes of the former container are only removed once the
+			// new one is ready.
+			var formerVolumes []string
+			if details, err := containerClient.InspectContainer(instance.ContainerID); err == nil {
+				formerVolumes = details.Volumes
+			}
+			err = containerClient.RemoveContainer(instance.ContainerID, false)
+			if err != nil && !stderrors.Is(err, connectors.ErrContainerNotFound) {
+				return errors.Wrap(errors.KindEnvironment, fmt.Errorf("failed to remove container: %w", err))
+			}
+
+			previous := instance.ImageDigest
+			instance.Image = target
+			instance.ImageDigest = ""
+			for i, companion := range instance.Companions {
+				if companion.Role != companionMinion {
+					continue
+				}
+				if instance.Companions[i], err = createCompanion(containerClient, *instance, companion.Role); err != nil {
+					return errors.Wrap(errors.KindEnvironment, err)
+				}
+			}
+			if err := recreateMissingCompanions(containerClient, instance); err != nil {
+				return errors.Wrap(errors.KindEnvironment, err)
+			}
+			opts, err := instanceContainerOpts(*instance)
+			if err != nil {
+				return errors.Wrap(errors.KindUsage, err)
+			}
+			containerId, err := containerClient.CreateContainer(opts)
+			if err != nil {
+				return errors.Wrap(errors.KindEnvironment, fmt.Errorf("failed to create container: %w", err))
+			}
+			instance.ContainerID = containerId
+			if err := pinImage(containerClient, instance); err != nil {
+				return errors.Wrap(errors.KindEnvironment, err)
+			}
+			if err := startCompanions(containerClient, instance, companionBroker); err != nil {
+				return errors.Wrap(errors.KindEnvironment, err)
+			}
+			if err := containerClient.StartContainer(containerId); err != nil {
+				return errors.Wrap(errors.KindEnvironment, fmt.Errorf("failed to start container: %w", err))
+			}
+			instance.Status = instanceRunning
+			if err := config.WriteLocalConfig(*localConfig, globalClientOpts.ConfigPath); err != nil {
+				return err
+			}
+
+			server := instanceURL(*instance)
+			fmt.Fprintf(out, "Waiting for Microcks to be ready at %s ...\n", server)
+			if err := waitForReady(server, readyTimeout.Duration()); err != nil {
+				return errors.Wrapf(errors.KindEnvironment, "Microcks container is upgraded but the server is not ready: %v. "+
+					"Its mocks are not restored yet: import them again once it answers", err)
+			}
+			for _, volume := range formerVolumes {
+				if err := containerClient.RemoveVolume(volume); err != nil {
+					fmt.Fprintf(out, "Cannot remove volume %s of the former container: %s\n", volume, err)
+				}
+			}
+			if err := startCompanions(containerClient, instance, companionMinion); err != nil {
+				return errors.Wrap(errors.KindEnvironment, err)
+			}
+
+			switch {
+			case snapshotPath != "":
+				if err := importInstanceSnapshot(globalClientOpts, contextName, snapshotPath); err != nil {
+					return err
+				}
+				os.Remove(snapshotPath)
+				fmt.Fprintf(out, "Restored the mocks of instance %s from its snapshot\n", instance.Name)
+			case instance.Artifacts != "" && !noRestore:
+				if err := importStartArtifacts(globalClientOpts, contextName, instance.Artifacts, false); err != nil {
+					return err
+				}
+			}
+
+			digest := instance.ImageDigest
+			if digest == "" {
+				digest = "a local image"
+			}
+			if previous != "" && previous == instance.ImageDigest {
+				fmt.Fprintf(out, "Instance %s is already on %s, recreated\n", instance.Name, digest)
+				return nil
+			}
+			fmt.Fprintf(out, "Instance %s upgraded to %s (%s)\n", instance.Name, target, digest)
+			return nil
+		},
+	}
+
+	upgradeCmd.Flags().StringVar(&image, "image", "", "image, or tag of the current image, to upgrade to (default: the current image)")
+	upgradeCmd.Flags().Var(&readyTimeout, "ready-timeout", "how long to wait for the Microcks server to be ready before failing")
+	upgradeCmd.Flags().BoolVar(&noRestore, "no-restore", false, "start the new container empty, without restoring its mocks")
+	return upgradeCmd
+}
+
+// upgradeImage is the image to upgrade to: the current one, another
+// reference, or the current repository at another tag.
+func upgradeImage(current, image string) string {
+	switch {
+	case image == "":
+		return current
+	case strings.ContainsAny(image, "/:@"):
+		return image
+	}
+	repository := current
+	if i := strings.Index(repository, "@"); i >= 0 {
+		repository = repository[:i]
+	}
+	// A colon after the last slash separates the tag, not a registry port.
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository = repository[:i]
+	}
+	return repository + ":" + image
+}
+
+// saveInstanceSnapshot exports the services of an instance to a snapshot
+// file and returns its path, empty when the instance has no service.
+func saveInstanceSnapshot(globalClientOpts *connectors.ClientOptions, contextName, name string) (string, error) {
+	mc, _, err := newContextClient(globalClientOpts, contextName)
+	if err != nil {
+		return "", err
+	}
+	services, err := selectServices(mc, serviceSelection{all: true})
+	if err != nil || len(services) == 0 {
+		return "", err
+	}
+	ids := make([]string, 0, len(services))
+	for _, svc := range services {
+		ids = append(ids, svc.ID)
+	}
+	snapshot, err := mc.ExportSnapshot(ids)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(os.TempDir(), fmt.Sprintf("microcks-%s-snapshot-%d.json", name, time.Now().UnixNano()))
+	if err := os.WriteFile(path, snapshot, 0o600); err != nil {
+		return "", errors.Wrapf(errors.KindEnvironment, "cannot write snapshot: %v", err)
+	}
+	return path, nil
+}
+
+func importInstanceSnapshot(globalClientOpts *connectors.ClientOptions, contextName, path string) error {
+	mc, _, err := newContextClient(globalClientOpts, contextName)
+	if err != nil {
+		return err
+	}
+	return mc.ImportSnapshot(path)
+}
@@ -0,0 +1,184 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/microcks/microcks-cli/pkg/config"
+	"github.com/microcks/microcks-cli/pkg/fakeserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	oldDigest = "quay.io/microcks/microcks-uber@sha256:0ld"
+	newDigest = "quay.io/microcks/microcks-uber@sha256:n3w"
+)
+
+func TestInstancesUpgradeRestoresSnapshotAndPins(t *testing.T) {
+	fake := fakeserver.New()
+	fake.AddService(fakeserver.Service{Name: "Petstore API", Version: "1.0", Type: "REST"})
+	var mu sync.Mutex
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		fake.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	runtime := &fakeRuntime{
+		states:  map[string]string{"c1": "running"},
+		digests: map[string]string{"quay.io/microcks/microcks-uber:1.12.0-native": newDigest},
+		volumes: map[string][]string{"c1": {"4f1e"}},
+	}
+	useFakeRuntime(t, runtime)
+	configPath := writeInstancesConfig(t, config.Instance{Name: "microcks", ContainerID: "c1", Port: serverURL.Port(),
+		Status: "Running", Image: "quay.io/microcks/microcks-uber:1.11.0-native", ImageDigest: oldDigest,
+		Env: []string{"FEATURE=on"}, CPUs: 2})
+
+	out, err := runInstances(t, configPath, "upgrade", "--image", "1.12.0-native")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Restored the mocks of instance microcks from its snapshot")
+	assert.NoFileExists(t, savedSnapshot(t, out))
+	assert.Equal(t, []string{"4f1e"}, runtime.removedVolumes)
+	assert.Contains(t, out, "Instance microcks upgraded to quay.io/microcks/microcks-uber:1.12.0-native ("+newDigest+")")
+
+	assert.Equal(t, []string{"quay.io/microcks/microcks-uber:1.12.0-native"}, runtime.pulled)
+	assert.NotContains(t, runtime.states, "c1")
+	require.Len(t, runtime.created, 1)
+	assert.Equal(t, "quay.io/microcks/microcks-uber:1.12.0-native", runtime.created[0].Image)
+	assert.Equal(t, []string{"FEATURE=on"}, runtime.created[0].Env)
+	assert.Equal(t, 2.0, runtime.created[0].CPUs)
+	assert.Equal(t, "running", runtime.states["new-1"])
+	assert.Contains(t, calls, "GET /api/export")
+	assert.Contains(t, calls, "POST /api/import")
+
+	localCfg, err := config.ReadLocalConfig(configPath)
+	require.NoError(t, err)
+	instance := localCfg.Instances[0]
+	assert.Equal(t, "new-1", instance.ContainerID)
+	assert.Equal(t, "quay.io/microcks/microcks-uber:1.12.0-native", instance.Image)
+	assert.Equal(t, newDigest, instance.ImageDigest)
+
+	// Recreating a pinned instance keeps the pinned version.
+	delete(runtime.states, "new-1")
+	_, err = runInstances(t, configPath, "restart", "--no-wait")
+	require.NoError(t, err)
+	require.Len(t, runtime.created, 2)
+	assert.Equal(t, newDigest, runtime.created[1].Image)
+}
+
+func TestInstancesUpgradeKeepsSnapshotAndVolumesUntilReady(t *testing.T) {
+	fake := fakeserver.New()
+	fake.AddService(fakeserver.Service{Name: "Petstore API", Version: "1.0", Type: "REST"})
+	var exported atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The new container never answers.
+		if exported.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path == "/api/export" {
+			exported.Store(true)
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	runtime := &fakeRuntime{
+		states:  map[string]string{"c1": "running"},
+		volumes: map[string][]string{"c1": {"4f1e"}},
+	}
+	useFakeRuntime(t, runtime)
+	configPath := writeInstancesConfig(t, config.Instance{Name: "microcks", ContainerID: "c1", Port: serverURL.Port(),
+		Status: "Running", Image: "quay.io/microcks/microcks-uber:latest-native"})
+
+	out, err := runInstances(t, configPath, "upgrade", "--ready-timeout", "1s")
+	require.Error(t, err)
+	snapshot := savedSnapshot(t, out)
+	t.Cleanup(func() { os.Remove(snapshot) })
+	assert.FileExists(t, snapshot)
+	assert.Contains(t, out, "The snapshot of instance microcks is kept at "+snapshot+": restore it with 'microcks snapshot import "+snapshot+"'")
+	assert.Empty(t, runtime.removedVolumes)
+}
+
+// savedSnapshot is the snapshot file upgrade reported saving.
+func savedSnapshot(t *testing.T, out string) string {
+	t.Helper()
+	match := regexp.MustCompile(`Saved the mocks of instance \S+ to (\S+)\n`).FindStringSubmatch(out)
+	require.NotNil(t, match, out)
+	return match[1]
+}
+
+func TestInstancesStatusReportsImageDrift(t *testing.T) {
+	useFakeRuntime(t, &fakeRuntime{
+		states:  map[string]string{"c1": "exited"},
+		images:  map[string]string{"c1": oldDigest},
+		digests: map[string]string{"quay.io/microcks/microcks-uber:latest-native": newDigest},
+	})
+	configPath := writeInstancesConfig(t, config.Instance{Name: "microcks", ContainerID: "c1", Port: "8585",
+		Image: "quay.io/microcks/microcks-uber:latest-native", ImageDigest: oldDigest})
+
+	out, err := runInstances(t, configPath, "status")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Pinned:     "+oldDigest+"\n")
+	assert.NotContains(t, out, "the container runs")
+	assert.Contains(t, out, "Drift:      quay.io/microcks/microcks-uber:latest-native now points to "+newDigest+
+		": run 'microcks instances upgrade microcks' to use it\n")
+}
+
+func TestPinImageKeepsExistingPin(t *testing.T) {
+	runtime := &fakeRuntime{
+		states:  map[string]string{"c1": "running"},
+		images:  map[string]string{"c1": "quay.io/microcks/microcks-uber:latest-native"},
+		digests: map[string]string{"quay.io/microcks/microcks-uber:latest-native": newDigest},
+	}
+
+	instance := config.Instance{ContainerID: "c1", ImageDigest: oldDigest}
+	require.NoError(t, pinImage(runtime, &instance))
+	assert.Equal(t, oldDigest, instance.ImageDigest)
+
+	instance.ImageDigest = ""
+	require.NoError(t, pinImage(runtime, &instance))
+	assert.Equal(t, newDigest, instance.ImageDigest)
+}
+
+func TestUpgradeImage(t *testing.T) {
+	cases := []struct{ current, image, expected string }{
+		{"quay.io/microcks/microcks-uber:latest-native", "", "quay.io/microcks/microcks-uber:latest-native"},
+		{"quay.io/microcks/microcks-uber:latest-native", "1.12.0-native", "quay.io/microcks/microcks-uber:1.12.0-native"},
+		{"localhost:5000/microcks-uber", "nightly", "localhost:5000/microcks-uber:nightly"},
+		{"quay.io/microcks/microcks-uber@sha256:0ld", "1.12.0", "quay.io/microcks/microcks-uber:1.12.0"},
+		{"quay.io/microcks/microcks-uber:latest", "microcks/microcks-uber:1.12.0", "microcks/microcks-uber:1.12.0"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, upgradeImage(c.current, c.image), "%s with %s", c.current, c.image)
+	}
+}
@@ -0,0 +1,344 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/microcks/microcks-cli/pkg/config"
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRuntime is an in-memory container runtime: container ID to state.
+type fakeRuntime struct {
+	states   map[string]string
+	logs     map[string]string
+	created  []connectors.ContainerOpts
+	stopped  []string
+	networks map[string]bool
+	// images are the images of containers, digests the repository digests
+	// of image references.
+	images  map[string]string
+	digests map[string]string
+	pulled  []string
+	// volumes are the volumes of containers, and the removed ones.
+	volumes        map[string][]string
+	removedVolumes []string
+}
+
+func (f *fakeRuntime) CreateContainer(opts connectors.ContainerOpts) (string, error) {
+	f.created = append(f.created, opts)
+	id := fmt.Sprintf("new-%d", len(f.created))
+	f.states[id] = "created"
+	if f.images == nil {
+		f.images = map[string]string{}
+	}
+	f.images[id] = opts.Image
+	return id, nil
+}
+
+func (f *fakeRuntime) StartContainer(containerId string) error {
+	if _, ok := f.states[containerId]; !ok {
+		return fmt.Errorf("no such container: %s", containerId)
+	}
+	f.states[containerId] = "running"
+	return nil
+}
+
+func (f *fakeRuntime) StopContainer(containerId string) error {
+	f.states[containerId] = "exited"
+	f.stopped = append(f.stopped, containerId)
+	return nil
+}
+
+func (f *fakeRuntime) ContainerExists(containerId string) (bool, error) {
+	_, ok := f.states[containerId]
+	return ok, nil
+}
+
+func (f *fakeRuntime) InspectContainer(containerId string) (*connectors.ContainerDetails, error) {
+	state, ok := f.states[containerId]
+	if !ok {
+		return nil, connectors.ErrContainerNotFound
+	}
+	details := &connectors.ContainerDetails{ID: containerId, State: state, Ports: map[string]string{"8080/tcp": "127.0.0.1:8585"},
+		Image: f.images[containerId], ImageDigest: f.imageDigest(f.images[containerId]), Volumes: f.volumes[containerId]}
+	if state == "exited" {
+		details.ExitCode = 137
+	}
+	return details, nil
+}
+
+// imageDigest is the digest of a reference, the reference itself when it
+// is a digest one.
+func (f *fakeRuntime) imageDigest(reference string) string {
+	if strings.Contains(reference, "@") {
+		return reference
+	}
+	return f.digests[reference]
+}
+
+func (f *fakeRuntime) ContainerLogs(containerId string, opts connectors.LogOpts, out io.Writer) error {
+	_, err := io.WriteString(out, f.logs[containerId])
+	return err
+}
+
+func (f *fakeRuntime) RemoveContainer(containerId string, removeVolumes bool) error {
+	if _, ok := f.states[containerId]; !ok {
+		return connectors.ErrContainerNotFound
+	}
+	if removeVolumes {
+		f.removedVolumes = append(f.removedVolumes, f.volumes[containerId]...)
+	}
+	delete(f.states, containerId)
+	return nil
+}
+
+func (f *fakeRuntime) RemoveVolume(name string) error {
+	f.removedVolumes = append(f.removedVolumes, name)
+	return nil
+}
+
+func (f *fakeRuntime) WaitForHealthy(containerId string, timeout time.Duration) error {
+	return nil
+}
+
+func (f *fakeRuntime) ListImages(reference string) ([]connectors.ImageSummary, error) {
+	digest, ok := f.digests[reference]
+	if !ok {
+		return nil, nil
+	}
+	return []connectors.ImageSummary{{ID: "sha256:" + reference, RepoTags: []string{reference}, RepoDigests: []string{digest}}}, nil
+}
+
+func (f *fakeRuntime) PullImage(reference string) error {
+	f.pulled = append(f.pulled, reference)
+	return nil
+}
+
+func (f *fakeRuntime) CreateNetwork(name string) (string, error) {
+	if f.networks == nil {
+		f.networks = map[string]bool{}
+	}
+	f.networks[name] = true
+	return "net-" + name, nil
+}
+
+func (f *fakeRuntime) RemoveNetwork(name string) error {
+	delete(f.networks, name)
+	return nil
+}
+
+func (f *fakeRuntime) CloseClient() error {
+	return nil
+}
+
+// useFakeRuntime routes every driver to runtime for the test duration.
+func useFakeRuntime(t *testing.T, runtime *fakeRuntime) {
+	t.Helper()
+	previous := newContainerClient
+	newContainerClient = func(driver string) (connectors.ContainerClient, error) {
+		return runtime, nil
+	}
+	t.Cleanup(func() { newContainerClient = previous })
+}
+
+// writeInstancesConfig records one instance per name, each with the context,
+// server, user and auth entries `microcks start` creates.
+func writeInstancesConfig(t *testing.T, instances ...config.Instance) string {
+	t.Helper()
+	var localCfg config.LocalConfig
+	for _, instance := range instances {
+		server := instanceURL(instance)
+		localCfg.Instances = append(localCfg.Instances, instance)
+		localCfg.Servers = append(localCfg.Servers, config.Server{Name: instance.Name, Server: server, InsecureTLS: true})
+		localCfg.Auths = append(localCfg.Auths, config.Auth{Server: server})
+		localCfg.Users = append(localCfg.Users, config.User{Name: server})
+		localCfg.Contexts = append(localCfg.Contexts, config.ContextRef{Name: server, Server: server, User: server, Instance: instance.Name})
+		localCfg.CurrentContext = server
+	}
+	configPath := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, config.WriteLocalConfig(localCfg, configPath))
+	return configPath
+}
+
+func runInstances(t *testing.T, configPath string, args ...string) (string, error) {
+	t.Helper()
+	cmd := NewInstancesCommand(&connectors.ClientOptions{ConfigPath: configPath})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs(args)
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestInstancesListReconcilesStatus(t *testing.T) {
+	runtime := &fakeRuntime{states: map[string]string{"c1": "exited", "c2": "running"}}
+	useFakeRuntime(t, runtime)
+	configPath := writeInstancesConfig(t,
+		config.Instance{Name: "rebooted", ContainerID: "c1", Port: "8585", Status: "Running", Image: "microcks-uber"},
+		config.Instance{Name: "podman", ContainerID: "c2", Port: "8686", Status: "Exited", Driver: "podman"},
+		config.Instance{Name: "removed", ContainerID: "c3", Port: "8787", Status: "Running", AutoRemove: true},
+	)
+
+	out, err := runInstances(t, configPath, "list")
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 4)
+	assert.Regexp(t, `^NAME\s+STATUS\s+URL\s+DRIVER\s+IMAGE\s+CONTEXT$`, lines[0])
+	assert.Regexp(t, `^rebooted\s+Exited\s+http://localhost:8585\s+docker\s+microcks-uber\s+http://localhost:8585$`, lines[1])
+	assert.Regexp(t, `^podman\s+Running\s+http://localhost:8686\s+podman\s+`, lines[2])
+	assert.Regexp(t, `^removed\s+Missing\s+`, lines[3])
+
+	localCfg, err := config.ReadLocalConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "Exited", localCfg.Instances[0].Status)
+	assert.Equal(t, "Running", localCfg.Instances[1].Status)
+	// A vanished container is reported, not forgotten.
+	assert.Equal(t, "Running", localCfg.Instances[2].Status)
+	assert.Equal(t, "c3", localCfg.Instances[2].ContainerID)
+}
+
+func TestInstancesListWithoutInstance(t *testing.T) {
+	useFakeRuntime(t, &fakeRuntime{states: map[string]string{}})
+
+	out, err := runInstances(t, filepath.Join(t.TempDir(), "config"), "list")
+	require.NoError(t, err)
+	assert.Equal(t, "No Microcks instance found\n", out)
+}
+
+func TestInstancesStatusDefaultsToCurrentContext(t *testing.T) {
+	useFakeRuntime(t, &fakeRuntime{states: map[string]string{"c1": "exited"}})
+	configPath := writeInstancesConfig(t,
+		config.Instance{Name: "other", ContainerID: "c0", Port: "8484"},
+		config.Instance{Name: "microcks", ContainerID: "c1", Port: "8585", Status: "Running", Image: "microcks-uber"},
+	)
+
+	out, err := runInstances(t, configPath, "status")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Name:       microcks\n")
+	assert.Contains(t, out, "Status:     Exited\n")
+	assert.Contains(t, out, "Context:    http://localhost:8585\n")
+	assert.Contains(t, out, "Ports:      127.0.0.1:8585->8080/tcp\n")
+	assert.Contains(t, out, "State:      exited (exit code 137)\n")
+
+	out, err = runInstances(t, configPath, "status", "other")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Status:     Missing\n")
+	assert.Contains(t, out, "microcks start --name other")
+
+	_, err = runInstances(t, configPath, "status", "unknown")
+	assert.Equal(t, errors.KindNotFound, errors.KindOf(err))
+}
+
+func TestInstancesLogs(t *testing.T) {
+	useFakeRuntime(t, &fakeRuntime{
+		states: map[string]string{"c1": "running"},
+		logs:   map[string]string{"c1": "Started MicrocksApplication\n"},
+	})
+	configPath := writeInstancesConfig(t,
+		config.Instance{Name: "microcks", ContainerID: "c1", Port: "8585"},
+		config.Instance{Name: "gone", ContainerID: "c2", Port: "8686"},
+	)
+
+	out, err := runInstances(t, configPath, "logs", "microcks")
+	require.NoError(t, err)
+	assert.Equal(t, "Started MicrocksApplication\n", out)
+
+	_, err = runInstances(t, configPath, "logs", "gone")
+	assert.Equal(t, errors.KindNotFound, errors.KindOf(err))
+}
+
+func TestInstancesRestartRecreatesMissingContainer(t *testing.T) {
+	runtime := &fakeRuntime{states: map[string]string{}}
+	useFakeRuntime(t, runtime)
+	configPath := writeInstancesConfig(t, config.Instance{Name: "microcks", ContainerID: "c1", Port: "8585",
+		Image: "microcks-uber", Status: "Running", AutoRemove: true})
+
+	out, err := runInstances(t, configPath, "restart", "microcks", "--no-wait")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Container for instance microcks no longer exists, recreating it")
+	assert.Contains(t, out, "Instance microcks restarted at http://localhost:8585")
+	require.Len(t, runtime.created, 1)
+	assert.Equal(t, connectors.ContainerOpts{Image: "microcks-uber", Port: "8585", Name: "microcks", AutoRemove: true}, runtime.created[0])
+	assert.Equal(t, "running", runtime.states["new-1"])
+
+	localCfg, err := config.ReadLocalConfig(configPath)
+	require.NoError(t, err)
+	require.Len(t, localCfg.Instances, 1)
+	assert.Equal(t, "new-1", localCfg.Instances[0].ContainerID)
+	assert.Equal(t, "Running", localCfg.Instances[0].Status)
+}
+
+func TestInstancesRestartRecreatesWithRecordedOptions(t *testing.T) {
+	runtime := &fakeRuntime{states: map[string]string{}}
+	useFakeRuntime(t, runtime)
+	configPath := writeInstancesConfig(t, config.Instance{Name: "microcks", ContainerID: "c1", Port: "8585",
+		Image: "microcks-uber", Env: []string{"FEATURE=on"}, Volumes: []string{"/etc/ssl/certs:/deployments/certs:ro"},
+		HostIP: "0.0.0.0", GrpcPort: "9090", CPUs: 2, Memory: "512m"})
+
+	_, err := runInstances(t, configPath, "restart", "microcks", "--no-wait")
+	require.NoError(t, err)
+	require.Len(t, runtime.created, 1)
+	assert.Equal(t, connectors.ContainerOpts{Image: "microcks-uber", Port: "8585", Name: "microcks",
+		Env: []string{"FEATURE=on"}, Volumes: []string{"/etc/ssl/certs:/deployments/certs:ro"},
+		HostIP: "0.0.0.0", GrpcPort: "9090", CPUs: 2, Memory: 512 << 20}, runtime.created[0])
+}
+
+func TestInstancesRmRemovesContainerAndConfig(t *testing.T) {
+	runtime := &fakeRuntime{states: map[string]string{"c1": "running", "c2": "exited"}}
+	useFakeRuntime(t, runtime)
+	configPath := writeInstancesConfig(t,
+		config.Instance{Name: "keep", ContainerID: "c2", Port: "8686"},
+		config.Instance{Name: "microcks", ContainerID: "c1", Port: "8585"},
+	)
+
+	_, err := runInstances(t, configPath, "rm", "microcks")
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+	assert.Contains(t, err.Error(), "use --force")
+	assert.Equal(t, "running", runtime.states["c1"])
+
+	out, err := runInstances(t, configPath, "rm", "microcks", "--force")
+	require.NoError(t, err)
+	assert.Equal(t, "Instance microcks removed\n", out)
+	assert.NotContains(t, runtime.states, "c1")
+
+	localCfg, err := config.ReadLocalConfig(configPath)
+	require.NoError(t, err)
+	assert.Empty(t, localCfg.CurrentContext)
+	require.Len(t, localCfg.Instances, 1)
+	assert.Equal(t, "keep", localCfg.Instances[0].Name)
+	require.Len(t, localCfg.Contexts, 1)
+	assert.Equal(t, "http://localhost:8686", localCfg.Contexts[0].Name)
+	assert.Len(t, localCfg.Servers, 1)
+	assert.Len(t, localCfg.Users, 1)
+	assert.Len(t, localCfg.Auths, 1)
+
+	_, err = runInstances(t, configPath, "rm", "microcks")
+	assert.Equal(t, errors.KindNotFound, errors.KindOf(err))
+}
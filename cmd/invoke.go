@@ -0,0 +1,350 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/microcks/microcks-cli/pkg/config"
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// defaultGRPCPort is the port Microcks serves gRPC mocks on.
+const defaultGRPCPort = 9090
+
+func NewInvokeCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var (
+		example  string
+		headers  []string
+		include  bool
+		grpcAddr string
+		grpcTLS  bool
+	)
+
+	var invokeCmd = &cobra.Command{
+		Use:   "invoke <apiName:apiVersion> <operation>",
+		Short: "Call the mock of an API operation",
+		Long: `Call the mock of an API operation on the Microcks of the current context, sending the request of one of its examples,
+and print the mock response. The mock URL is built from the API type: REST, SOAP or GraphQL.
+
+gRPC mocks are not invoked: the matching grpcurl command is printed instead, to run yourself. It targets
+--grpc-addr, else the gRPC port published by the local instance of the context (see 'microcks start --grpc-port'),
+else port 9090 on the host of the server.`,
+		Example: `# Call the mock with the first example of the operation
+microcks invoke "WeatherForecast API:1.1.0" "GET /forecast/{region}"
+
+# Pick an example, show the status and headers
+microcks invoke "WeatherForecast API:1.1.0" "GET /forecast/{region}" --example north -i
+
+# Print the grpcurl command of a gRPC mock exposed over TLS
+microcks invoke "HelloService:v1" "greeting" --grpc-addr grpc.microcks.example.com:443 --grpc-tls`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			extraHeaders, err := parseHeaders(headers)
+			if err != nil {
+				return err
+			}
+
+			mc, serverAddr, err := newMicrocksClientFromOptions(globalClientOpts)
+			if err != nil {
+				return err
+			}
+			view, err := mc.GetServiceView(args[0])
+			if err != nil {
+				return err
+			}
+			op, err := findOperation(view.Service, args[1])
+			if err != nil {
+				return err
+			}
+			pair, err := findExample(view, op, example)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if view.Service.Type == "GRPC" {
+				addr := grpcAddr
+				if addr == "" {
+					if addr, err = grpcAddress(globalClientOpts, serverAddr); err != nil {
+						return err
+					}
+				}
+				fmt.Fprintln(out, grpcurlCommand(addr, grpcTLS, view.Service, op, pair))
+				return nil
+			}
+
+			req, err := buildMockRequest(mockBaseURL(serverAddr), view.Service, op, pair)
+			if err != nil {
+				return err
+			}
+			for name, value := range extraHeaders {
+				req.Header.Set(name, value)
+			}
+
+			resp, err := mc.HttpClient().Do(req)
+			if err != nil {
+				return errors.Wrap(errors.KindConnection, err)
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return errors.Wrap(errors.KindConnection, fmt.Errorf("reading mock response: %w", err))
+			}
+
+			if include {
+				fmt.Fprintf(out, "%s %s\n%s %s\n", req.Method, req.URL, resp.Proto, resp.Status)
+				names := make([]string, 0, len(resp.Header))
+				for name := range resp.Header {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					fmt.Fprintf(out, "%s: %s\n", name, strings.Join(resp.Header[name], ", "))
+				}
+				fmt.Fprintln(out)
+			}
+			out.Write(body)
+			if len(body) > 0 && body[len(body)-1] != '\n' {
+				fmt.Fprintln(out)
+			}
+
+			return checkMockStatus(cmd.ErrOrStderr(), pair, resp.StatusCode)
+		},
+	}
+
+	invokeCmd.Flags().StringVar(&example, "example", "", "Name of the example whose request is sent (default: the first one)")
+	invokeCmd.Flags().StringArrayVarP(&headers, "header", "H", nil, "Extra request header as 'Name: value' (repeatable)")
+	invokeCmd.Flags().BoolVarP(&include, "include", "i", false, "Print the request line, response status and headers")
+	invokeCmd.Flags().StringVar(&grpcAddr, "grpc-addr", "", "host:port of the gRPC mocks, printed in the grpcurl command (default: the one of the context's instance, else port 9090 of the server)")
+	invokeCmd.Flags().BoolVar(&grpcTLS, "grpc-tls", false, "The gRPC mocks are served over TLS: print the grpcurl command without -plaintext")
+
+	return invokeCmd
+}
+
+// parseHeaders parses `Name: value` headers.
+func parseHeaders(headers []string) (map[string]string, error) {
+	parsed := map[string]string{}
+	for _, header := range headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, errors.Wrapf(errors.KindUsage, "--header: %q must be 'Name: value'", header)
+		}
+		parsed[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return parsed, nil
+}
+
+// findOperation resolves an operation by exact name, then ignoring case.
+func findOperation(svc connectors.Service, name string) (*connectors.Operation, error) {
+	for i, op := range svc.Operations {
+		if op.Name == name {
+			return &svc.Operations[i], nil
+		}
+	}
+	for i, op := range svc.Operations {
+		if strings.EqualFold(op.Name, name) {
+			return &svc.Operations[i], nil
+		}
+	}
+	names := make([]string, 0, len(svc.Operations))
+	for _, op := range svc.Operations {
+		names = append(names, op.Name)
+	}
+	return nil, errors.Wrapf(errors.KindNotFound, "operation '%s' not found in '%s:%s', available: %s", name, svc.Name, svc.Version, strings.Join(names, ", "))
+}
+
+// findExample returns the named example of the operation, or its first one.
+// An operation without example is called with an empty request.
+func findExample(view *connectors.ServiceView, op *connectors.Operation, name string) (*connectors.RequestResponsePair, error) {
+	pairs, err := view.Exchanges(op.Name)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		if len(pairs) == 0 {
+			return nil, nil
+		}
+		return &pairs[0], nil
+	}
+	names := make([]string, 0, len(pairs))
+	for i, pair := range pairs {
+		if pair.Request.Name == name {
+			return &pairs[i], nil
+		}
+		names = append(names, pair.Request.Name)
+	}
+	return nil, errors.Wrapf(errors.KindNotFound, "example '%s' not found for '%s', available: %s", name, op.Name, strings.Join(names, ", "))
+}
+
+// mockBaseURL returns the root URL of the mocks from the API server address.
+func mockBaseURL(serverAddr string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(strings.TrimSuffix(serverAddr, "/"), "/api"), "/")
+}
+
+// buildMockRequest builds the request of an example for the mock endpoint
+// Microcks exposes for the type of the service.
+func buildMockRequest(baseURL string, svc connectors.Service, op *connectors.Operation, pair *connectors.RequestResponsePair) (*http.Request, error) {
+	var example connectors.ExampleMessage
+	if pair != nil {
+		example = pair.Request
+	}
+	serviceRoot := url.PathEscape(svc.Name) + "/" + url.PathEscape(svc.Version)
+
+	var method, target, contentType string
+	switch svc.Type {
+	case "REST", "GENERIC_REST":
+		root := "/rest/"
+		if svc.Type == "GENERIC_REST" {
+			root = "/dynarest/"
+		}
+		path, query, err := resolveMockPath(op, example.QueryParameters)
+		if err != nil {
+			return nil, err
+		}
+		method, target = op.Method, baseURL+root+serviceRoot+path
+		if len(query) > 0 {
+			target += "?" + query.Encode()
+		}
+		contentType = "application/json"
+	case "SOAP_HTTP":
+		method, target, contentType = http.MethodPost, baseURL+"/soap/"+serviceRoot, "text/xml; charset=utf-8"
+	case "GRAPHQL":
+		method, target, contentType = http.MethodPost, baseURL+"/graphql/"+serviceRoot, "application/json"
+	case "EVENT", "GENERIC_EVENT":
+		return nil, errors.Wrapf(errors.KindUsage, "'%s:%s' is an event-based API: Microcks publishes its messages, they cannot be invoked", svc.Name, svc.Version)
+	default:
+		return nil, errors.Wrapf(errors.KindUsage, "cannot invoke mocks of %s APIs", svc.Type)
+	}
+
+	var body io.Reader
+	if example.Content != "" {
+		body = strings.NewReader(example.Content)
+	}
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		return nil, errors.Wrap(errors.KindUsage, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if svc.Type == "SOAP_HTTP" && op.Action != "" {
+		req.Header.Set("SOAPAction", op.Action)
+	}
+	for _, header := range example.Headers {
+		req.Header.Set(header.Name, strings.Join(header.Values, ","))
+	}
+	return req, nil
+}
+
+// resolveMockPath fills the `{param}` or `:param` placeholders of a REST
+// operation with the example parameters; the others go to the query string.
+func resolveMockPath(op *connectors.Operation, params []connectors.QueryParameter) (string, url.Values, error) {
+	values := map[string]string{}
+	for _, p := range params {
+		values[p.Name] = p.Value
+	}
+	path := strings.TrimSpace(strings.TrimPrefix(op.Name, op.Method))
+
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		name := ""
+		switch {
+		case strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}"):
+			name = part[1 : len(part)-1]
+		case strings.HasPrefix(part, ":"):
+			name = part[1:]
+		default:
+			continue
+		}
+		value, ok := values[name]
+		if !ok {
+			return "", nil, errors.Wrapf(errors.KindUsage, "no value for path parameter '%s' of '%s', pick an example with --example", name, op.Name)
+		}
+		parts[i] = url.PathEscape(value)
+		delete(values, name)
+	}
+
+	query := url.Values{}
+	for name, value := range values {
+		query.Set(name, value)
+	}
+	return strings.Join(parts, "/"), query, nil
+}
+
+// grpcAddress returns the host:port of the gRPC mocks of the server: the
+// gRPC port published by the local instance of the context, or the default
+// gRPC port on the host of the server.
+func grpcAddress(globalClientOpts *connectors.ClientOptions, serverAddr string) (string, error) {
+	localConfig, err := config.ReadLocalConfig(globalClientOpts.ConfigPath)
+	if err != nil {
+		return "", err
+	}
+	if localConfig != nil {
+		ctx, err := localConfig.ResolveContext(globalClientOpts.Context)
+		if err == nil && ctx.Instance.Name != "" && ctx.Server.Server == serverAddr {
+			if ctx.Instance.GrpcPort == "" {
+				return "", errors.Wrapf(errors.KindUsage, "instance %s does not publish gRPC: recreate it with 'microcks start --grpc-port', "+
+					"or give the address with --grpc-addr", ctx.Instance.Name)
+			}
+			return instanceAddress(ctx.Instance, ctx.Instance.GrpcPort), nil
+		}
+	}
+
+	host := serverAddr
+	if u, err := url.Parse(serverAddr); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	return net.JoinHostPort(host, strconv.Itoa(defaultGRPCPort)), nil
+}
+
+// grpcurlCommand returns the grpcurl command calling a gRPC mock at addr.
+func grpcurlCommand(addr string, tls bool, svc connectors.Service, op *connectors.Operation, pair *connectors.RequestResponsePair) string {
+	command := "grpcurl"
+	if !tls {
+		command += " -plaintext"
+	}
+	if pair != nil && pair.Request.Content != "" {
+		command += " -d '" + strings.ReplaceAll(pair.Request.Content, "'", `'\''`) + "'"
+	}
+	return fmt.Sprintf("%s %s %s/%s", command, addr, svc.Name, op.Name)
+}
+
+// checkMockStatus fails when the mock does not answer the status of the
+// example, or an error when there is no example.
+func checkMockStatus(errOut io.Writer, pair *connectors.RequestResponsePair, status int) error {
+	if pair != nil && pair.Response.Status != "" {
+		if expected, err := strconv.Atoi(pair.Response.Status); err == nil && expected != status {
+			fmt.Fprintf(errOut, "Mock answered HTTP %d, example '%s' expects %d\n", status, pair.Response.Name, expected)
+			return errors.ErrTestFailed
+		}
+		return nil
+	}
+	if status >= 400 {
+		fmt.Fprintf(errOut, "Mock answered HTTP %d\n", status)
+		return errors.ErrTestFailed
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/microcks/microcks-cli/pkg/config"
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/fakeserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pastriesOpenAPI = `openapi: 3.0.2
+info:
+  title: API Pastries
+  version: 0.0.1
+paths:
+  /pastries/{name}:
+    get:
+      parameters:
+        - name: name
+          in: path
+          examples:
+            Millefeuille:
+              value: Millefeuille
+            Eclair:
+              value: Eclair Chocolat
+      responses:
+        "200":
+          content:
+            application/json:
+              examples:
+                Millefeuille:
+                  value: {"name": "Millefeuille", "price": 4.4}
+        "404":
+          content:
+            application/json:
+              examples:
+                Eclair:
+                  value: {"error": "sold out"}
+`
+
+func newPastriesFake(t *testing.T) *fakeserver.Server {
+	t.Helper()
+	fake := fakeserver.New()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	specPath := filepath.Join(t.TempDir(), "pastries.yaml")
+	require.NoError(t, os.WriteFile(specPath, []byte(pastriesOpenAPI), 0o600))
+	mc, err := connectors.NewMicrocksClient(server.URL)
+	require.NoError(t, err)
+	_, err = mc.UploadArtifact(specPath, true)
+	require.NoError(t, err)
+	return fake
+}
+
+func TestInvokeSendsExampleRequest(t *testing.T) {
+	fake := newPastriesFake(t)
+
+	out, err := runAgainstFake(t, fake, NewInvokeCommand, "", "API Pastries:0.0.1", "GET /pastries/{name}")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name": "Millefeuille", "price": 4.4}`, out)
+
+	out, err = runAgainstFake(t, fake, NewInvokeCommand, "", "API Pastries:0.0.1", "get /pastries/{name}", "--example", "Eclair", "-i")
+	require.NoError(t, err)
+	assert.Regexp(t, `GET http://.*/rest/API%20Pastries/0.0.1/pastries/Eclair%20Chocolat\n`, out)
+	assert.Contains(t, out, "404 Not Found")
+	assert.Contains(t, out, "Content-Type: application/json")
+
+	_, err = runAgainstFake(t, fake, NewInvokeCommand, "", "API Pastries:0.0.1", "GET /pastries/{name}", "--example", "Tarte")
+	assert.Equal(t, errors.KindNotFound, errors.KindOf(err))
+	_, err = runAgainstFake(t, fake, NewInvokeCommand, "", "API Pastries:0.0.1", "DELETE /pastries")
+	assert.Equal(t, errors.KindNotFound, errors.KindOf(err))
+}
+
+func TestBuildMockRequestByServiceType(t *testing.T) {
+	pair := &connectors.RequestResponsePair{Request: connectors.ExampleMessage{Name: "hello", Content: "<hello/>"}}
+
+	req, err := buildMockRequest("http://localhost:8080", connectors.Service{Name: "Hello Service", Version: "1.0", Type: "SOAP_HTTP"},
+		&connectors.Operation{Name: "sayHello", Action: "urn:sayHello"}, pair)
+	require.NoError(t, err)
+	assert.Equal(t, "POST", req.Method)
+	assert.Equal(t, "http://localhost:8080/soap/Hello%20Service/1.0", req.URL.String())
+	assert.Equal(t, "urn:sayHello", req.Header.Get("SOAPAction"))
+	body, _ := io.ReadAll(req.Body)
+	assert.Equal(t, "<hello/>", string(body))
+
+	req, err = buildMockRequest("http://localhost:8080", connectors.Service{Name: "Films", Version: "1.0", Type: "GRAPHQL"},
+		&connectors.Operation{Name: "allFilms", Method: "QUERY"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/graphql/Films/1.0", req.URL.String())
+
+	req, err = buildMockRequest("http://localhost:8080", connectors.Service{Name: "Orders", Version: "1.0", Type: "REST"},
+		&connectors.Operation{Name: "GET /orders/:id", Method: "GET"},
+		&connectors.RequestResponsePair{Request: connectors.ExampleMessage{QueryParameters: []connectors.QueryParameter{{Name: "id", Value: "42"}, {Name: "full", Value: "true"}}}})
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/rest/Orders/1.0/orders/42?full=true", req.URL.String())
+
+	_, err = buildMockRequest("http://localhost:8080", connectors.Service{Name: "Orders", Version: "1.0", Type: "REST"},
+		&connectors.Operation{Name: "GET /orders/{id}", Method: "GET"}, nil)
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+
+	_, err = buildMockRequest("http://localhost:8080", connectors.Service{Name: "Events", Version: "1.0", Type: "EVENT"},
+		&connectors.Operation{Name: "SUBSCRIBE orders"}, nil)
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+
+	assert.Equal(t, "http://localhost:8080", mockBaseURL("http://localhost:8080/api/"))
+}
+
+func TestInvokePrintsGrpcurlForInstanceGrpcPort(t *testing.T) {
+	fake := fakeserver.New()
+	fake.AddService(fakeserver.Service{Name: "HelloService", Version: "v1", Type: "GRPC", Operations: []fakeserver.Operation{{Name: "greeting"}}})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	invoke := func(instance config.Instance, args ...string) (string, error) {
+		cmd := NewInvokeCommand(&connectors.ClientOptions{ConfigPath: writeInstancesConfig(t, instance)})
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetArgs(append([]string{"HelloService:v1", "greeting"}, args...))
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	out, err := invoke(config.Instance{Name: "grpc", Port: u.Port(), GrpcPort: "9191", Status: "Running"})
+	require.NoError(t, err)
+	assert.Equal(t, "grpcurl -plaintext localhost:9191 HelloService/greeting\n", out)
+
+	out, err = invoke(config.Instance{Name: "grpc", Port: u.Port(), Status: "Running"}, "--grpc-addr", "grpc.example.com:443", "--grpc-tls")
+	require.NoError(t, err)
+	assert.Equal(t, "grpcurl grpc.example.com:443 HelloService/greeting\n", out)
+
+	_, err = invoke(config.Instance{Name: "grpc", Port: u.Port(), Status: "Running"})
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+}
@@ -0,0 +1,312 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const jobsPageSize = 100
+
+func NewJobsCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var jobsCmd = &cobra.Command{
+		Use:     "jobs",
+		Aliases: []string{"job"},
+		Short:   "Manage importer jobs",
+		Long: `Manage Microcks importer jobs: repository URLs that Microcks re-imports on a schedule
+while the job is active, unlike the one-shot import-url`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.HelpFunc()(cmd, args)
+		},
+	}
+
+	jobsCmd.AddCommand(NewJobsCreateCommand(globalClientOpts))
+	jobsCmd.AddCommand(NewJobsListCommand(globalClientOpts))
+	jobsCmd.AddCommand(newJobActionCommand(globalClientOpts, "activate", "Activate a job: import now, then on schedule", "activated",
+		connectors.MicrocksClient.ActivateImportJob))
+	jobsCmd.AddCommand(newJobActionCommand(globalClientOpts, "start", "Run the import of a job now", "started",
+		connectors.MicrocksClient.StartImportJob))
+	jobsCmd.AddCommand(newJobActionCommand(globalClientOpts, "stop", "Deactivate a job, stopping scheduled imports", "stopped",
+		connectors.MicrocksClient.StopImportJob))
+	jobsCmd.AddCommand(NewJobsDeleteCommand(globalClientOpts))
+
+	return jobsCmd
+}
+
+func NewJobsCreateCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var (
+		mainArtifact bool
+		secretName   string
+		labels       []string
+		disableSSL   bool
+		activate     bool
+	)
+
+	var createCmd = &cobra.Command{
+		Use:   "create <name> <repositoryURL>",
+		Short: "Create an importer job",
+		Example: `# Import an OpenAPI spec from Git on schedule, starting now
+microcks jobs create petstore https://raw.githubusercontent.com/acme/apis/main/petstore.yaml --activate
+
+# A secondary artifact from a private repository, with labels
+microcks jobs create petstore-examples https://git.acme.com/raw/apis/petstore-examples.yaml \
+        --main-artifact=false --secret git-token --label domain=pets --label team=alpha`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if u, err := url.Parse(args[1]); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				return errors.Wrapf(errors.KindUsage, "<repositoryURL> must be an http(s) URL, got '%s'", args[1])
+			}
+			parsedLabels, err := parseLabels(labels)
+			if err != nil {
+				return err
+			}
+
+			mc, _, err := newMicrocksClientFromOptions(globalClientOpts)
+			if err != nil {
+				return err
+			}
+			if existing, err := findImportJob(mc, args[0]); err == nil {
+				return errors.Wrapf(errors.KindUsage, "importer job '%s' already exists (id %s)", args[0], existing.ID)
+			} else if errors.KindOf(err) != errors.KindNotFound {
+				return err
+			}
+
+			job := &connectors.ImportJob{
+				Name:                           args[0],
+				RepositoryURL:                  args[1],
+				MainArtifact:                   mainArtifact,
+				RepositoryDisableSSLValidation: disableSSL,
+			}
+			if len(parsedLabels) > 0 {
+				job.Metadata = &connectors.Metadata{Labels: parsedLabels}
+			}
+			if secretName != "" {
+				secret, err := findSecret(mc, secretName)
+				if err != nil {
+					return err
+				}
+				job.SecretRef = &connectors.SecretRef{SecretID: secret.ID, Name: secret.Name}
+			}
+
+			created, err := mc.CreateImportJob(job)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Importer job '%s' created with id %s\n", created.Name, created.ID)
+
+			if activate {
+				activated, err := mc.ActivateImportJob(created.ID)
+				if err != nil {
+					return err
+				}
+				printJobActionResult(cmd.OutOrStdout(), activated, "activated")
+			}
+			return nil
+		},
+	}
+
+	createCmd.Flags().BoolVar(&mainArtifact, "main-artifact", true, "Whether the repository holds a main (primary) artifact")
+	createCmd.Flags().StringVar(&secretName, "secret", "", "Name of the secret used to fetch the repository")
+	createCmd.Flags().StringArrayVar(&labels, "label", nil, "Label of the job as key=value (repeatable)")
+	createCmd.Flags().BoolVar(&disableSSL, "disable-ssl-validation", false, "Do not validate the repository TLS certificate")
+	createCmd.Flags().BoolVar(&activate, "activate", false, "Activate the job right away (the first import runs immediately)")
+
+	return createCmd
+}
+
+func NewJobsListCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var selector string
+
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List importer jobs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			labels, err := parseSelector(selector)
+			if err != nil {
+				return err
+			}
+			mc, _, err := newMicrocksClientFromOptions(globalClientOpts)
+			if err != nil {
+				return err
+			}
+			all, err := listAllImportJobs(mc)
+			if err != nil {
+				return err
+			}
+			var jobs []connectors.ImportJob
+			for _, job := range all {
+				if matchLabels(job.Metadata, labels) {
+					jobs = append(jobs, job)
+				}
+			}
+			if len(jobs) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No importer job found")
+				return nil
+			}
+			printImportJobs(cmd.OutOrStdout(), jobs)
+			return nil
+		},
+	}
+
+	listCmd.Flags().StringVarP(&selector, "selector", "l", "", "Label selector, e.g. 'domain=pets,team=alpha'")
+	return listCmd
+}
+
+func NewJobsDeleteCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var deleteCmd = &cobra.Command{
+		Use:   "delete <name|id>",
+		Short: "Delete an importer job",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mc, _, err := newMicrocksClientFromOptions(globalClientOpts)
+			if err != nil {
+				return err
+			}
+			job, err := findImportJob(mc, args[0])
+			if err != nil {
+				return err
+			}
+			if err := mc.DeleteImportJob(job.ID); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Importer job '%s' deleted\n", job.Name)
+			return nil
+		},
+	}
+	return deleteCmd
+}
+
+// newJobActionCommand builds the activate, start and stop commands, which
+// only differ by the client method they call.
+func newJobActionCommand(globalClientOpts *connectors.ClientOptions, action, short, done string,
+	call func(connectors.MicrocksClient, string) (*connectors.ImportJob, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   action + " <name|id>",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mc, _, err := newMicrocksClientFromOptions(globalClientOpts)
+			if err != nil {
+				return err
+			}
+			job, err := findImportJob(mc, args[0])
+			if err != nil {
+				return err
+			}
+			updated, err := call(mc, job.ID)
+			if err != nil {
+				return err
+			}
+			printJobActionResult(cmd.OutOrStdout(), updated, done)
+			return nil
+		},
+	}
+}
+
+func printJobActionResult(out io.Writer, job *connectors.ImportJob, done string) {
+	fmt.Fprintf(out, "Importer job '%s' %s\n", job.Name, done)
+	if job.LastImportError != "" {
+		fmt.Fprintf(out, "Last import failed: %s\n", job.LastImportError)
+	}
+	for _, ref := range job.ServiceRefs {
+		fmt.Fprintf(out, "Microcks has discovered '%s:%s'\n", ref.Name, ref.Version)
+	}
+}
+
+func printImportJobs(out io.Writer, jobs []connectors.ImportJob) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tID\tACTIVE\tMAIN\tLAST IMPORT\tURL\tLABELS")
+	for _, job := range jobs {
+		lastImport := "never"
+		switch {
+		case job.LastImportError != "":
+			lastImport = "failed " + formatTestDate(job.LastImportDate)
+		case job.LastImportDate != 0:
+			lastImport = formatTestDate(job.LastImportDate)
+		}
+		var labels map[string]string
+		if job.Metadata != nil {
+			labels = job.Metadata.Labels
+		}
+		fmt.Fprintf(w, "%s\t%s\t%t\t%t\t%s\t%s\t%s\n", job.Name, job.ID, job.Active, job.MainArtifact, lastImport, job.RepositoryURL, formatLabels(labels))
+	}
+	w.Flush()
+}
+
+func listAllImportJobs(mc connectors.MicrocksClient) ([]connectors.ImportJob, error) {
+	var all []connectors.ImportJob
+	for page := 0; ; page++ {
+		jobs, err := mc.ListImportJobs(page, jobsPageSize)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, jobs...)
+		if len(jobs) < jobsPageSize {
+			return all, nil
+		}
+	}
+}
+
+// findImportJob looks a job up by name, or by ID.
+func findImportJob(mc connectors.MicrocksClient, ref string) (*connectors.ImportJob, error) {
+	jobs, err := listAllImportJobs(mc)
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs {
+		if jobs[i].Name == ref || jobs[i].ID == ref {
+			return &jobs[i], nil
+		}
+	}
+	return nil, errors.Wrapf(errors.KindNotFound, "importer job '%s' not found", ref)
+}
+
+// parseLabels parses repeated key=value label flags.
+func parseLabels(values []string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, value := range values {
+		key, val, ok := strings.Cut(value, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, errors.Wrapf(errors.KindUsage, "--label: %q must be key=value", value)
+		}
+		labels[strings.TrimSpace(key)] = strings.TrimSpace(val)
+	}
+	return labels, nil
+}
+
+func formatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+labels[k])
+	}
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/fakeserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobsLifecycle(t *testing.T) {
+	const repoURL = "https://raw.githubusercontent.com/acme/apis/main/weather.yaml"
+	content, err := os.ReadFile("../samples/weather-forecast-openapi.yml")
+	require.NoError(t, err)
+
+	fake := fakeserver.New()
+	fake.AddRemoteArtifact(repoURL, content)
+	fake.AddSecret(fakeserver.Secret{Name: "git-token", Token: "t0k3n"})
+
+	out, err := runAgainstFake(t, fake, NewJobsCommand, "", "create", "weather", repoURL, "--secret", "git-token", "--label", "domain=weather", "--activate")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Importer job 'weather' created")
+	assert.Contains(t, out, "Microcks has discovered 'WeatherForecast API:1.1.0'")
+
+	jobs := fake.ImportJobs()
+	require.Len(t, jobs, 1)
+	assert.True(t, jobs[0].Active)
+	assert.Equal(t, "git-token", jobs[0].SecretRef.Name)
+	assert.Equal(t, map[string]string{"domain": "weather"}, jobs[0].Metadata.Labels)
+	assert.Equal(t, "git-token", fake.Artifacts()[0].Secret)
+
+	_, err = runAgainstFake(t, fake, NewJobsCommand, "", "create", "weather", repoURL)
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+
+	_, err = runAgainstFake(t, fake, NewJobsCommand, "", "stop", "weather")
+	require.NoError(t, err)
+	assert.False(t, fake.ImportJobs()[0].Active)
+
+	out, err = runAgainstFake(t, fake, NewJobsCommand, "", "list")
+	require.NoError(t, err)
+	assert.Regexp(t, `weather\s+\w+\s+false\s+true\s+\d{4}-\d\d-\d\d .*\s+`+repoURL+`\s+domain=weather`, out)
+
+	_, err = runAgainstFake(t, fake, NewJobsCommand, "", "start", "weather")
+	require.NoError(t, err)
+	assert.Len(t, fake.Artifacts(), 2)
+
+	_, err = runAgainstFake(t, fake, NewJobsCommand, "", "delete", "weather")
+	require.NoError(t, err)
+	assert.Empty(t, fake.ImportJobs())
+
+	_, err = runAgainstFake(t, fake, NewJobsCommand, "", "activate", "weather")
+	assert.Equal(t, errors.KindNotFound, errors.KindOf(err))
+}
+
+func TestJobsCreateValidation(t *testing.T) {
+	fake := fakeserver.New()
+	for _, args := range [][]string{
+		{"create", "j", "ftp://example.com/api.yaml"},
+		{"create", "j", "https://example.com/api.yaml", "--label", "novalue"},
+	} {
+		_, err := runAgainstFake(t, fake, NewJobsCommand, "", args...)
+		assert.Equal(t, errors.KindUsage, errors.KindOf(err), args)
+	}
+
+	_, err := runAgainstFake(t, fake, NewJobsCommand, "", "create", "j", "https://example.com/api.yaml", "--secret", "unknown")
+	assert.Equal(t, errors.KindNotFound, errors.KindOf(err))
+	assert.Empty(t, fake.ImportJobs())
+}
+
+func TestJobsListSelector(t *testing.T) {
+	fake := fakeserver.New()
+	_, err := runAgainstFake(t, fake, NewJobsCommand, "", "create", "petstore", "https://acme.com/petstore.yaml", "--label", "domain=pets")
+	require.NoError(t, err)
+	_, err = runAgainstFake(t, fake, NewJobsCommand, "", "create", "orders", "https://acme.com/orders.yaml", "--label", "domain=sales")
+	require.NoError(t, err)
+
+	out, err := runAgainstFake(t, fake, NewJobsCommand, "", "list", "-l", "domain=pets")
+	require.NoError(t, err)
+	assert.Contains(t, out, "petstore")
+	assert.NotContains(t, out, "orders")
+}
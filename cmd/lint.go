@@ -0,0 +1,105 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/lint"
+	"github.com/spf13/cobra"
+)
+
+func NewLintCommand() *cobra.Command {
+	var strict bool
+
+	var lintCmd = &cobra.Command{
+		Use:   "lint <file|directory>...",
+		Short: "Check API artifacts locally before importing them",
+		Long: `Check API artifacts locally before importing them.
+
+OpenAPI and AsyncAPI documents, Postman collections and APIMetadata/APIExamples
+files are checked for what Microcks needs to import them, and for its
+conventions: examples paired by name across requests and responses, named
+message examples and valid x-microcks extensions. Problems are reported as
+file:line:column diagnostics; directories are scanned recursively. SoapUI
+projects, WSDL, Protobuf and GraphQL artifacts are not checked.
+
+The command fails when errors are found, or warnings too with --strict.`,
+		Example: `# Check every artifact of a directory
+microcks lint ./specs
+
+# Fail on warnings too, e.g. in a CI pipeline
+microcks lint api-openapi.yml api-postman.json --strict`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			files, err := artifactFiles(args)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			errorCount, warningCount, err := lintFiles(out, &RealFileSystem{}, files)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "%d file(s) checked: %d error(s), %d warning(s)\n", len(files), errorCount, warningCount)
+			if errorCount > 0 || (strict && warningCount > 0) {
+				return errors.ErrTestFailed
+			}
+			return nil
+		},
+	}
+
+	lintCmd.Flags().BoolVar(&strict, "strict", false, "Fail on warnings too")
+
+	return lintCmd
+}
+
+// lintFiles prints the diagnostics of artifacts read from fs and counts
+// them.
+func lintFiles(out io.Writer, fs FileSystem, files []string) (int, int, error) {
+	var errorCount, warningCount int
+	for _, file := range files {
+		content, err := fs.ReadFile(file)
+		if err != nil {
+			return 0, 0, errors.Wrapf(errors.KindUsage, "cannot read %s: %v", file, err)
+		}
+		for _, d := range lint.Document(file, content) {
+			fmt.Fprintln(out, d)
+			switch d.Severity {
+			case lint.SeverityError:
+				errorCount++
+			case lint.SeverityWarning:
+				warningCount++
+			}
+		}
+	}
+	return errorCount, warningCount, nil
+}
+
+// lintBeforeImport lints artifacts about to be imported, and fails when
+// Microcks would reject or misread some of them.
+func lintBeforeImport(out io.Writer, fs FileSystem, files []string) error {
+	errorCount, _, err := lintFiles(out, fs, files)
+	if err != nil {
+		return err
+	}
+	if errorCount > 0 {
+		return errors.Wrapf(errors.KindUsage, "lint found %d error(s), nothing was imported", errorCount)
+	}
+	return nil
+}
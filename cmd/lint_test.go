@@ -0,0 +1,121 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"bytes"
+	stderrors "errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runLint(args ...string) (string, error) {
+	cmd := NewLintCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs(args)
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestLintReportsDiagnosticsOfDirectory(t *testing.T) {
+	dir := copySamples(t, map[string]string{
+		"weather-forecast-openapi.yml":  "weather-openapi.yml",
+		"weather-forecast-postman.json": "postman/weather-postman.json",
+	})
+	out, err := runLint(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "2 file(s) checked: 0 error(s), 0 warning(s)\n", out)
+
+	broken := filepath.Join(dir, "postman", "orders-postman.json")
+	require.NoError(t, os.WriteFile(broken, []byte(`{"info": {"name": "Orders", "_postman_id": "1"}, "item": []}`), 0o600))
+	out, err = runLint(dir)
+	assert.True(t, stderrors.Is(err, errors.ErrTestFailed))
+	assert.Contains(t, out, broken+":1:10: error: info.description must contain 'version=<version>'")
+	assert.Contains(t, out, "3 file(s) checked: 1 error(s), 0 warning(s)\n")
+}
+
+func TestLintSkipsSoapUIProjects(t *testing.T) {
+	dir := copySamples(t, map[string]string{"weather-forecast-openapi.yml": "weather-openapi.yml"})
+	project := filepath.Join(dir, "orders-soapui-project.xml")
+	require.NoError(t, os.WriteFile(project, []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<con:soapui-project name="Orders" xmlns:con="http://eviware.com/soapui/config"/>
+`), 0o600))
+
+	out, err := runLint(dir, "--strict")
+	require.NoError(t, err)
+	assert.Contains(t, out, project+":1:1: info: not checked")
+	assert.Contains(t, out, "2 file(s) checked: 0 error(s), 0 warning(s)\n")
+
+	mockClient := &MockMicrocksClient{}
+	result, err := ImportDirectory(io.Discard, mockClient, &RealFileSystem{}, dir, ImportConfig{Lint: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.SuccessCount)
+}
+
+func TestLintStrictFailsOnWarnings(t *testing.T) {
+	dir := copySamples(t, map[string]string{"ecommerce-api-openapi.yml": "ecommerce-openapi.yml"})
+
+	out, err := runLint(dir)
+	require.NoError(t, err)
+	assert.Contains(t, out, "warning: request example 'newProduct' of POST /products has no response example")
+
+	_, err = runLint(dir, "--strict")
+	assert.True(t, stderrors.Is(err, errors.ErrTestFailed))
+}
+
+func TestImportDirectoryLintsFirst(t *testing.T) {
+	dir := copySamples(t, map[string]string{
+		"weather-forecast-openapi.yml":  "weather-openapi.yml",
+		"ecommerce-api-postman.json":    "ecommerce-postman.json",
+		"weather-forecast-postman.json": "weather-postman.json",
+	})
+	mockClient := &MockMicrocksClient{}
+
+	_, err := ImportDirectory(io.Discard, mockClient, &RealFileSystem{}, dir, ImportConfig{Lint: true})
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+	assert.EqualError(t, err, "lint found 1 error(s), nothing was imported")
+	assert.Empty(t, mockClient.Uploaded)
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "ecommerce-postman.json")))
+	result, err := ImportDirectory(io.Discard, mockClient, &RealFileSystem{}, dir, ImportConfig{Lint: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.SuccessCount)
+}
+
+func TestImportDirectoryLintsThroughFileSystem(t *testing.T) {
+	mockFS := &MockFileSystem{
+		Files: map[string]bool{"/specs": true, "/specs/orders-postman.json": false},
+		Contents: map[string][]byte{
+			"/specs/orders-postman.json": []byte(`{"info": {"name": "Orders", "_postman_id": "1"}, "item": []}`),
+		},
+	}
+	mockClient := &MockMicrocksClient{}
+
+	var out bytes.Buffer
+	_, err := ImportDirectory(&out, mockClient, mockFS, "/specs", ImportConfig{Lint: true})
+	assert.EqualError(t, err, "lint found 1 error(s), nothing was imported")
+	assert.Contains(t, out.String(), "/specs/orders-postman.json:1:10: error: info.description must contain 'version=<version>'")
+	assert.Empty(t, mockClient.Uploaded)
+}
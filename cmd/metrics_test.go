@@ -0,0 +1,75 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/fakeserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsInvocations(t *testing.T) {
+	fake := fakeserver.New()
+	fake.AddService(fakeserver.Service{Name: "Orders API", Version: "1.0"})
+	fake.AddService(fakeserver.Service{Name: "Billing API", Version: "2.0"})
+	day := time.Date(2026, 10, 19, 9, 30, 0, 0, time.Local)
+	fake.AddInvocations("Orders API", "1.0", day, 3)
+	fake.AddInvocations("Orders API", "1.0", day.Add(5*time.Hour), 1)
+	fake.AddInvocations("Billing API", "2.0", day, 8)
+
+	out, err := runAgainstFake(t, fake, NewMetricsCommand, "", "invocations", "--day", "20261019")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Invocations of all mocks on 2026-10-19: 12")
+	assert.Regexp(t, `09:00\s+11\s+#{40}\n14:00\s+1\s+#{4}\n`, out)
+	assert.Regexp(t, `1\s+Billing API\s+2.0\s+8\n2\s+Orders API\s+1.0\s+4\n`, out)
+
+	out, err = runAgainstFake(t, fake, NewMetricsCommand, "", "invocations", "Orders API:1.0", "--day", "20261019", "-o", "json")
+	require.NoError(t, err)
+	var report invocationsReport
+	require.NoError(t, json.Unmarshal([]byte(out), &report))
+	assert.Equal(t, int64(4), report.DailyCount)
+	assert.Equal(t, map[string]int64{"9": 3, "14": 1}, report.HourlyCount)
+	assert.Empty(t, report.Top)
+
+	out, err = runAgainstFake(t, fake, NewMetricsCommand, "", "invocations", "Billing API:2.0", "--day", "20261018")
+	require.NoError(t, err)
+	assert.Equal(t, "No invocation of 'Billing API:2.0' on 2026-10-18\n", out)
+
+	_, err = runAgainstFake(t, fake, NewMetricsCommand, "", "invocations", "Unknown API:1.0")
+	assert.Equal(t, errors.KindNotFound, errors.KindOf(err))
+	_, err = runAgainstFake(t, fake, NewMetricsCommand, "", "invocations", "--day", "2026-10-19")
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+}
+
+func TestInvokeIsCountedInMetrics(t *testing.T) {
+	fake := newPastriesFake(t)
+	// A fixed clock keeps the recorded day and the expected one the same,
+	// even when the test runs around midnight.
+	fake.SetClock(func() time.Time { return time.Date(2026, 10, 19, 23, 59, 59, 0, time.Local) })
+	for i := 0; i < 2; i++ {
+		_, err := runAgainstFake(t, fake, NewInvokeCommand, "", "API Pastries:0.0.1", "GET /pastries/{name}")
+		require.NoError(t, err)
+	}
+
+	out, err := runAgainstFake(t, fake, NewMetricsCommand, "", "invocations", "API Pastries:0.0.1", "--day", "20261019")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Invocations of 'API Pastries:0.0.1' on 2026-10-19: 2")
+}
@@ -0,0 +1,318 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// primaryResourceTypes are the resource types that define a service; they are
+// imported as main artifacts. Other types complete them as secondary ones.
+var primaryResourceTypes = map[string]bool{
+	"OPEN_API_SPEC":   true,
+	"ASYNC_API_SPEC":  true,
+	"GRAPHQL_SCHEMA":  true,
+	"PROTOBUF_SCHEMA": true,
+	"WSDL":            true,
+	"SOAP_UI_PROJECT": true,
+}
+
+// secondaryResourceTypes are artifacts imported on top of a main one.
+// Resources of other types (e.g. JSON schemas) are parts of a main artifact
+// and come back with it.
+var secondaryResourceTypes = map[string]bool{
+	"POSTMAN_COLLECTION": true,
+	"API_METADATA":       true,
+	"API_EXAMPLES":       true,
+}
+
+// promotedArtifact is an artifact to re-import and how it compares with the
+// target.
+type promotedArtifact struct {
+	Name    string
+	Main    bool
+	Change  string
+	Import  bool
+	Content string
+}
+
+func NewPromoteCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var (
+		from   string
+		to     string
+		dryRun bool
+		yes    bool
+	)
+
+	var promoteCmd = &cobra.Command{
+		Use:   "promote <apiName:apiVersion>",
+		Short: "Copy the artifacts of an API from one context to another",
+		Long: `Copy the artifacts backing an API from the Microcks of a context to the Microcks of another one,
+main artifacts first, after showing what changes on the target`,
+		Example: `# Promote the petstore API from a local instance to the team server
+microcks promote "Petstore API:1.0" --from local --to team
+
+# Only show what would change
+microcks promote "Petstore API:1.0" --from local --to team --dry-run`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			serviceRef := args[0]
+			if !strings.Contains(serviceRef, ":") {
+				return errors.Wrapf(errors.KindUsage, "API must be referenced as 'name:version', got %q", serviceRef)
+			}
+			if from == "" || to == "" {
+				return errors.Wrapf(errors.KindUsage, "both --from and --to contexts are required")
+			}
+			if from == to {
+				return errors.Wrapf(errors.KindUsage, "--from and --to must be different contexts")
+			}
+
+			source, _, err := newContextClient(globalClientOpts, from)
+			if err != nil {
+				return err
+			}
+			target, _, err := newContextClient(globalClientOpts, to)
+			if err != nil {
+				return err
+			}
+
+			svc, err := source.GetService(serviceRef)
+			if err != nil {
+				return err
+			}
+			resources, err := source.GetServiceResources(svc.ID)
+			if err != nil {
+				return err
+			}
+
+			var targetSvc *connectors.Service
+			var targetResources []connectors.Resource
+			targetSvc, err = target.GetService(serviceRef)
+			switch {
+			case errors.KindOf(err) == errors.KindNotFound:
+				targetSvc = nil
+			case err != nil:
+				return err
+			default:
+				if targetResources, err = target.GetServiceResources(targetSvc.ID); err != nil {
+					return err
+				}
+			}
+
+			artifacts := planPromotion(resources, targetResources)
+			if len(artifacts) == 0 {
+				return errors.Wrapf(errors.KindNotFound, "no artifact of '%s' can be re-imported from %s", serviceRef, from)
+			}
+
+			out := cmd.OutOrStdout()
+			printPromotionPreview(out, serviceRef, from, to, svc, targetSvc, artifacts)
+
+			pending := 0
+			for _, artifact := range artifacts {
+				if artifact.Import {
+					pending++
+				}
+			}
+			if pending == 0 {
+				fmt.Fprintf(out, "Nothing to promote: '%s' is up to date in %s\n", serviceRef, to)
+				return nil
+			}
+			if dryRun {
+				return nil
+			}
+			if !yes && !confirm(cmd.InOrStdin(), out, fmt.Sprintf("Promote %d artifacts to %s?", pending, to)) {
+				fmt.Fprintln(out, "Promotion cancelled")
+				return nil
+			}
+
+			return importPromotedArtifacts(out, target, artifacts)
+		},
+	}
+
+	promoteCmd.Flags().StringVar(&from, "from", "", "Context to copy the API from")
+	promoteCmd.Flags().StringVar(&to, "to", "", "Context to copy the API to")
+	promoteCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only show what would change on the target")
+	promoteCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Do not ask for confirmation")
+
+	return promoteCmd
+}
+
+// planPromotion lists the artifacts to re-import, main ones first, compared
+// with the resources of the target. Secondary artifacts are re-imported with
+// a changed main artifact, as they complete it.
+func planPromotion(resources, targetResources []connectors.Resource) []promotedArtifact {
+	existing := map[string]string{}
+	for _, res := range targetResources {
+		existing[resourceFileName(res)] = res.Content
+	}
+
+	var mains, secondaries []promotedArtifact
+	mainChanged := false
+	for _, res := range resources {
+		main := primaryResourceTypes[res.Type]
+		if !main && !secondaryResourceTypes[res.Type] {
+			continue
+		}
+		artifact := promotedArtifact{Name: resourceFileName(res), Main: main, Content: res.Content}
+		previous, found := existing[artifact.Name]
+		switch {
+		case !found:
+			artifact.Change, artifact.Import = "new", true
+		case previous == res.Content:
+			artifact.Change = "unchanged"
+		default:
+			added, removed := countLineChanges(previous, res.Content)
+			artifact.Change, artifact.Import = fmt.Sprintf("changed (+%d -%d lines)", added, removed), true
+		}
+		if main {
+			mainChanged = mainChanged || artifact.Import
+			mains = append(mains, artifact)
+		} else {
+			secondaries = append(secondaries, artifact)
+		}
+	}
+
+	// A Postman-only service has no contract: its collection is the main one.
+	if len(mains) == 0 && len(secondaries) > 0 {
+		secondaries[0].Main = true
+		mainChanged = secondaries[0].Import
+	}
+	if mainChanged {
+		for i := range secondaries {
+			secondaries[i].Import = true
+		}
+	}
+	return append(mains, secondaries...)
+}
+
+func resourceFileName(res connectors.Resource) string {
+	if res.SourceArtifact != "" {
+		return res.SourceArtifact
+	}
+	return res.Name
+}
+
+// countLineChanges returns how many lines were added and removed between two
+// versions of a document, regardless of their order.
+func countLineChanges(before, after string) (int, int) {
+	lines := map[string]int{}
+	for _, line := range strings.Split(before, "\n") {
+		lines[line]++
+	}
+	added := 0
+	for _, line := range strings.Split(after, "\n") {
+		if lines[line] > 0 {
+			lines[line]--
+		} else {
+			added++
+		}
+	}
+	removed := 0
+	for _, n := range lines {
+		removed += n
+	}
+	return added, removed
+}
+
+func printPromotionPreview(out io.Writer, serviceRef, from, to string, svc, targetSvc *connectors.Service, artifacts []promotedArtifact) {
+	fmt.Fprintf(out, "Promoting '%s' from %s to %s\n", serviceRef, from, to)
+	if targetSvc == nil {
+		fmt.Fprintf(out, "  New in %s, with %d operations\n", to, len(svc.Operations))
+	} else {
+		before := map[string]bool{}
+		for _, op := range targetSvc.Operations {
+			before[op.Name] = true
+		}
+		var lines []string
+		for _, op := range svc.Operations {
+			if !before[op.Name] {
+				lines = append(lines, "  + "+op.Name)
+			}
+			delete(before, op.Name)
+		}
+		for _, op := range targetSvc.Operations {
+			if before[op.Name] {
+				lines = append(lines, "  - "+op.Name)
+			}
+		}
+		if len(lines) == 0 {
+			fmt.Fprintf(out, "  Same operations in %s\n", to)
+		} else {
+			fmt.Fprintf(out, "  Operations changed in %s:\n%s\n", to, strings.Join(lines, "\n"))
+		}
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, artifact := range artifacts {
+		kind := "secondary"
+		if artifact.Main {
+			kind = "main"
+		}
+		change := artifact.Change
+		if artifact.Import && artifact.Change == "unchanged" {
+			change = "unchanged, re-imported after main"
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", kind, artifact.Name, change)
+	}
+	w.Flush()
+}
+
+// confirm asks a yes/no question on in, defaulting to no.
+func confirm(in io.Reader, out io.Writer, question string) bool {
+	fmt.Fprintf(out, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// importPromotedArtifacts uploads the artifacts to import, in order, through
+// files named like the originals as Microcks uses the name to detect types.
+func importPromotedArtifacts(out io.Writer, target connectors.MicrocksClient, artifacts []promotedArtifact) error {
+	dir, err := os.MkdirTemp("", "microcks-promote-")
+	if err != nil {
+		return errors.Wrap(errors.KindEnvironment, err)
+	}
+	defer os.RemoveAll(dir)
+
+	for i, artifact := range artifacts {
+		if !artifact.Import {
+			continue
+		}
+		path := filepath.Join(dir, fmt.Sprint(i), filepath.Base(artifact.Name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return errors.Wrap(errors.KindEnvironment, err)
+		}
+		if err := os.WriteFile(path, []byte(artifact.Content), 0o600); err != nil {
+			return errors.Wrap(errors.KindEnvironment, err)
+		}
+		msg, err := target.UploadArtifact(path, artifact.Main)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Microcks has discovered '%s' from %s\n", strings.TrimSpace(msg), artifact.Name)
+	}
+	return nil
+}
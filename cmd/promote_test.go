@@ -0,0 +1,123 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/microcks/microcks-cli/pkg/config"
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/fakeserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeContextsConfig writes a local config with one context per fake
+// server, named after the map keys, and returns its path.
+func writeContextsConfig(t *testing.T, fakes map[string]*fakeserver.Server) string {
+	t.Helper()
+	var localCfg config.LocalConfig
+	for name, fake := range fakes {
+		server := httptest.NewServer(fake)
+		t.Cleanup(server.Close)
+		localCfg.Contexts = append(localCfg.Contexts, config.ContextRef{Name: name, Server: server.URL, User: server.URL})
+		localCfg.Servers = append(localCfg.Servers, config.Server{Name: name, Server: server.URL})
+		localCfg.Users = append(localCfg.Users, config.User{Name: server.URL})
+		localCfg.CurrentContext = name
+	}
+	configPath := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, config.WriteLocalConfig(localCfg, configPath))
+	return configPath
+}
+
+func runPromote(t *testing.T, configPath, stdin string, args ...string) (string, error) {
+	t.Helper()
+	cmd := NewPromoteCommand(&connectors.ClientOptions{ConfigPath: configPath})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetIn(strings.NewReader(stdin))
+	cmd.SetArgs(args)
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestPromoteCopiesArtifactsMainFirst(t *testing.T) {
+	local, team := fakeserver.New(), fakeserver.New()
+	configPath := writeContextsConfig(t, map[string]*fakeserver.Server{"local": local, "team": team})
+
+	server := httptest.NewServer(local)
+	defer server.Close()
+	mc, err := connectors.NewMicrocksClient(server.URL)
+	require.NoError(t, err)
+	_, err = mc.UploadArtifact("../samples/weather-forecast-openapi.yml", true)
+	require.NoError(t, err)
+	_, err = mc.UploadArtifact("../samples/weather-forecast-postman.json", false)
+	require.NoError(t, err)
+
+	out, err := runPromote(t, configPath, "", "WeatherForecast API:1.1.0", "--from", "local", "--to", "team", "--dry-run")
+	require.NoError(t, err)
+	assert.Contains(t, out, "New in team")
+	assert.Regexp(t, `main\s+weather-forecast-openapi.yml\s+new`, out)
+	assert.Regexp(t, `secondary\s+weather-forecast-postman.json\s+new`, out)
+	assert.Empty(t, team.Services())
+
+	out, err = runPromote(t, configPath, "n\n", "WeatherForecast API:1.1.0", "--from", "local", "--to", "team")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Promotion cancelled")
+	assert.Empty(t, team.Services())
+
+	out, err = runPromote(t, configPath, "y\n", "WeatherForecast API:1.1.0", "--from", "local", "--to", "team")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Microcks has discovered 'WeatherForecast API:1.1.0' from weather-forecast-openapi.yml")
+
+	artifacts := team.Artifacts()
+	require.Len(t, artifacts, 2)
+	assert.Equal(t, "weather-forecast-openapi.yml", artifacts[0].Name)
+	assert.True(t, artifacts[0].MainArtifact)
+	assert.Equal(t, "weather-forecast-postman.json", artifacts[1].Name)
+	assert.False(t, artifacts[1].MainArtifact)
+
+	out, err = runPromote(t, configPath, "", "WeatherForecast API:1.1.0", "--from", "local", "--to", "team")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Same operations in team")
+	assert.Contains(t, out, "Nothing to promote")
+}
+
+func TestPromoteValidation(t *testing.T) {
+	configPath := writeContextsConfig(t, map[string]*fakeserver.Server{"local": fakeserver.New(), "team": fakeserver.New()})
+
+	_, err := runPromote(t, configPath, "", "petstore", "--from", "local", "--to", "team")
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+	_, err = runPromote(t, configPath, "", "petstore:1.0", "--from", "local", "--to", "local")
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+	_, err = runPromote(t, configPath, "", "petstore:1.0", "--from", "local", "--to", "prod")
+	assert.Equal(t, errors.KindNotFound, errors.KindOf(err))
+	_, err = runPromote(t, configPath, "", "petstore:1.0", "--from", "local", "--to", "team")
+	assert.Equal(t, errors.KindNotFound, errors.KindOf(err))
+}
+
+func TestCountLineChanges(t *testing.T) {
+	added, removed := countLineChanges("a\nb\nc", "a\nc\nd\ne")
+	assert.Equal(t, 2, added)
+	assert.Equal(t, 1, removed)
+}
@@ -0,0 +1,377 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	secretsPageSize = 100
+	maskedValue     = "********"
+)
+
+func NewSecretsCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var secretsCmd = &cobra.Command{
+		Use:     "secrets",
+		Aliases: []string{"secret"},
+		Short:   "Manage Microcks secrets",
+		Long: `Manage the Microcks secrets used to reach secured test endpoints (--secretName on test)
+and remote artifacts (the :secret suffix of import-url)`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.HelpFunc()(cmd, args)
+		},
+	}
+
+	secretsCmd.AddCommand(NewSecretsCreateCommand(globalClientOpts))
+	secretsCmd.AddCommand(NewSecretsListCommand(globalClientOpts))
+	secretsCmd.AddCommand(NewSecretsGetCommand(globalClientOpts))
+	secretsCmd.AddCommand(NewSecretsUpdateCommand(globalClientOpts))
+	secretsCmd.AddCommand(NewSecretsDeleteCommand(globalClientOpts))
+
+	return secretsCmd
+}
+
+// secretInputs holds the secret flags shared by create and update. Sensitive
+// values are only read from files, or stdin with "-", so that they never show
+// in shell history or process listings.
+type secretInputs struct {
+	description  string
+	username     string
+	passwordFile string
+	tokenFile    string
+	tokenHeader  string
+	caCertFile   string
+}
+
+func (in *secretInputs) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&in.description, "description", "", "Description of the secret")
+	cmd.Flags().StringVar(&in.username, "username", "", "Username for basic authentication")
+	cmd.Flags().StringVar(&in.passwordFile, "password-file", "", "File holding the basic authentication password, '-' for stdin")
+	cmd.Flags().StringVar(&in.tokenFile, "token-file", "", "File holding the token, '-' for stdin")
+	cmd.Flags().StringVar(&in.tokenHeader, "token-header", "", "Custom header carrying the token (default: 'Authorization: Bearer')")
+	cmd.Flags().StringVar(&in.caCertFile, "ca-cert-file", "", "PEM file of the CA certificate of the endpoint, '-' for stdin")
+}
+
+// apply sets the provided values on secret, leaving the others untouched.
+func (in *secretInputs) apply(cmd *cobra.Command, secret *connectors.Secret) error {
+	stdinUsers := 0
+	for _, path := range []string{in.passwordFile, in.tokenFile, in.caCertFile} {
+		if path == "-" {
+			stdinUsers++
+		}
+	}
+	if stdinUsers > 1 {
+		return errors.Wrapf(errors.KindUsage, "only one of --password-file, --token-file and --ca-cert-file can read stdin")
+	}
+
+	if cmd.Flags().Changed("description") {
+		secret.Description = in.description
+	}
+	if in.username != "" {
+		secret.Username = in.username
+	}
+	if in.tokenHeader != "" {
+		secret.TokenHeader = in.tokenHeader
+	}
+	var err error
+	if in.passwordFile != "" {
+		if secret.Password, err = readSecretValue(cmd.InOrStdin(), "--password-file", in.passwordFile, true); err != nil {
+			return err
+		}
+	}
+	if in.tokenFile != "" {
+		if secret.Token, err = readSecretValue(cmd.InOrStdin(), "--token-file", in.tokenFile, true); err != nil {
+			return err
+		}
+	}
+	if in.caCertFile != "" {
+		if secret.CaCertPem, err = readSecretValue(cmd.InOrStdin(), "--ca-cert-file", in.caCertFile, false); err != nil {
+			return err
+		}
+		if !strings.Contains(secret.CaCertPem, "-----BEGIN CERTIFICATE-----") {
+			return errors.Wrapf(errors.KindUsage, "--ca-cert-file: %s is not a PEM certificate", in.caCertFile)
+		}
+	}
+	return validateSecret(secret)
+}
+
+// readSecretValue reads a file, or stdin for "-". Single-line values drop
+// their trailing newline, which editors and `echo` add.
+func readSecretValue(stdin io.Reader, flag, path string, singleLine bool) (string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", errors.Wrapf(errors.KindUsage, "%s: cannot read %s: %v", flag, path, err)
+	}
+	value := string(data)
+	if singleLine {
+		value = strings.TrimRight(value, "\r\n")
+	}
+	if value == "" {
+		return "", errors.Wrapf(errors.KindUsage, "%s: %s is empty", flag, path)
+	}
+	return value, nil
+}
+
+func validateSecret(secret *connectors.Secret) error {
+	switch {
+	case (secret.Username == "") != (secret.Password == ""):
+		return errors.Wrapf(errors.KindUsage, "basic authentication needs both --username and --password-file")
+	case secret.TokenHeader != "" && secret.Token == "":
+		return errors.Wrapf(errors.KindUsage, "--token-header needs a token from --token-file")
+	case secret.Username == "" && secret.Token == "" && secret.CaCertPem == "":
+		return errors.Wrapf(errors.KindUsage, "a secret needs basic authentication, a token or a CA certificate")
+	}
+	return nil
+}
+
+func NewSecretsCreateCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var inputs secretInputs
+
+	var createCmd = &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a secret",
+		Example: `# Basic authentication, password typed on stdin
+microcks secrets create petstore-basic --username admin --password-file -
+
+# Bearer token from a file
+microcks secrets create petstore-token --token-file ./token.txt
+
+# Token sent in a custom header
+microcks secrets create petstore-key --token-file ./key.txt --token-header X-Api-Key
+
+# CA certificate of a self-signed endpoint
+microcks secrets create internal-ca --ca-cert-file ./ca.pem`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			secret := &connectors.Secret{Name: args[0]}
+			if err := inputs.apply(cmd, secret); err != nil {
+				return err
+			}
+
+			mc, _, err := newMicrocksClientFromOptions(globalClientOpts)
+			if err != nil {
+				return err
+			}
+			// Secrets are referenced by name: keep names unique.
+			if existing, err := findSecret(mc, secret.Name); err == nil {
+				return errors.Wrapf(errors.KindUsage, "secret '%s' already exists (id %s), use 'microcks secrets update'", secret.Name, existing.ID)
+			} else if errors.KindOf(err) != errors.KindNotFound {
+				return err
+			}
+
+			created, err := mc.CreateSecret(secret)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Secret '%s' created with id %s\n", created.Name, created.ID)
+			return nil
+		},
+	}
+	inputs.register(createCmd)
+
+	return createCmd
+}
+
+func NewSecretsListCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List secrets, without their sensitive values",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mc, _, err := newMicrocksClientFromOptions(globalClientOpts)
+			if err != nil {
+				return err
+			}
+			secrets, err := listAllSecrets(mc)
+			if err != nil {
+				return err
+			}
+
+			if len(secrets) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No secret found")
+				return nil
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tID\tTYPE\tDESCRIPTION")
+			for _, s := range secrets {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Name, s.ID, secretType(&s), s.Description)
+			}
+			return w.Flush()
+		},
+	}
+	return listCmd
+}
+
+func NewSecretsGetCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var getCmd = &cobra.Command{
+		Use:   "get <name|id>",
+		Short: "Show a secret, with its sensitive values masked",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mc, _, err := newMicrocksClientFromOptions(globalClientOpts)
+			if err != nil {
+				return err
+			}
+			secret, err := findSecret(mc, args[0])
+			if err != nil {
+				return err
+			}
+			printSecret(cmd.OutOrStdout(), secret)
+			return nil
+		},
+	}
+	return getCmd
+}
+
+func NewSecretsUpdateCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var inputs secretInputs
+
+	var updateCmd = &cobra.Command{
+		Use:   "update <name|id>",
+		Short: "Update the provided values of a secret",
+		Example: `# Rotate a token
+microcks secrets update petstore-token --token-file ./new-token.txt`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mc, _, err := newMicrocksClientFromOptions(globalClientOpts)
+			if err != nil {
+				return err
+			}
+			secret, err := findSecret(mc, args[0])
+			if err != nil {
+				return err
+			}
+			if err := inputs.apply(cmd, secret); err != nil {
+				return err
+			}
+			if err := mc.UpdateSecret(secret); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Secret '%s' updated\n", secret.Name)
+			return nil
+		},
+	}
+	inputs.register(updateCmd)
+
+	return updateCmd
+}
+
+func NewSecretsDeleteCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var deleteCmd = &cobra.Command{
+		Use:   "delete <name|id>",
+		Short: "Delete a secret",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mc, _, err := newMicrocksClientFromOptions(globalClientOpts)
+			if err != nil {
+				return err
+			}
+			secret, err := findSecret(mc, args[0])
+			if err != nil {
+				return err
+			}
+			if err := mc.DeleteSecret(secret.ID); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Secret '%s' deleted\n", secret.Name)
+			return nil
+		},
+	}
+	return deleteCmd
+}
+
+func listAllSecrets(mc connectors.MicrocksClient) ([]connectors.Secret, error) {
+	var all []connectors.Secret
+	for page := 0; ; page++ {
+		secrets, err := mc.ListSecrets(page, secretsPageSize)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, secrets...)
+		if len(secrets) < secretsPageSize {
+			return all, nil
+		}
+	}
+}
+
+// findSecret looks a secret up by name, or by ID.
+func findSecret(mc connectors.MicrocksClient, ref string) (*connectors.Secret, error) {
+	secrets, err := listAllSecrets(mc)
+	if err != nil {
+		return nil, err
+	}
+	for i := range secrets {
+		if secrets[i].Name == ref || secrets[i].ID == ref {
+			return &secrets[i], nil
+		}
+	}
+	return nil, errors.Wrapf(errors.KindNotFound, "secret '%s' not found", ref)
+}
+
+// secretType describes what a secret provides, e.g. "basic,ca-cert".
+func secretType(secret *connectors.Secret) string {
+	var kinds []string
+	if secret.Username != "" {
+		kinds = append(kinds, "basic")
+	}
+	if secret.Token != "" && secret.TokenHeader != "" {
+		kinds = append(kinds, "header")
+	} else if secret.Token != "" {
+		kinds = append(kinds, "token")
+	}
+	if secret.CaCertPem != "" {
+		kinds = append(kinds, "ca-cert")
+	}
+	return strings.Join(kinds, ",")
+}
+
+func printSecret(out io.Writer, secret *connectors.Secret) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", secret.Name)
+	fmt.Fprintf(w, "ID:\t%s\n", secret.ID)
+	fmt.Fprintf(w, "Description:\t%s\n", secret.Description)
+	if secret.Username != "" {
+		fmt.Fprintf(w, "Username:\t%s\n", secret.Username)
+		fmt.Fprintf(w, "Password:\t%s\n", maskedValue)
+	}
+	if secret.Token != "" {
+		header := "Authorization: Bearer"
+		if secret.TokenHeader != "" {
+			header = secret.TokenHeader
+		}
+		fmt.Fprintf(w, "Token:\t%s (sent in %s)\n", maskedValue, header)
+	}
+	if secret.CaCertPem != "" {
+		fmt.Fprintf(w, "CA certificate:\tpresent (%d bytes)\n", len(secret.CaCertPem))
+	}
+	w.Flush()
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/fakeserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretsLifecycle(t *testing.T) {
+	fake := fakeserver.New()
+	tokenFile := filepath.Join(t.TempDir(), "token.txt")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("s3cr3t-token\n"), 0o600))
+
+	out, err := runAgainstFake(t, fake, NewSecretsCommand, "p4ssw0rd\n", "create", "petstore", "--username", "admin", "--password-file", "-", "--description", "Petstore staging")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Secret 'petstore' created")
+
+	_, err = runAgainstFake(t, fake, NewSecretsCommand, "", "create", "petstore", "--token-file", tokenFile)
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+
+	_, err = runAgainstFake(t, fake, NewSecretsCommand, "", "update", "petstore", "--token-file", tokenFile, "--token-header", "X-Api-Key")
+	require.NoError(t, err)
+	secrets := fake.Secrets()
+	require.Len(t, secrets, 1)
+	assert.Equal(t, "p4ssw0rd", secrets[0].Password)
+	assert.Equal(t, "s3cr3t-token", secrets[0].Token)
+	assert.Equal(t, "Petstore staging", secrets[0].Description)
+
+	out, err = runAgainstFake(t, fake, NewSecretsCommand, "", "list")
+	require.NoError(t, err)
+	assert.Regexp(t, `petstore\s+\w+\s+basic,header\s+Petstore staging`, out)
+
+	out, err = runAgainstFake(t, fake, NewSecretsCommand, "", "get", "petstore")
+	require.NoError(t, err)
+	assert.Contains(t, out, "sent in X-Api-Key")
+	assert.NotContains(t, out, "p4ssw0rd")
+	assert.NotContains(t, out, "s3cr3t-token")
+
+	_, err = runAgainstFake(t, fake, NewSecretsCommand, "", "delete", "petstore")
+	require.NoError(t, err)
+	assert.Empty(t, fake.Secrets())
+
+	_, err = runAgainstFake(t, fake, NewSecretsCommand, "", "get", "petstore")
+	assert.Equal(t, errors.KindNotFound, errors.KindOf(err))
+}
+
+func TestSecretInputsValidation(t *testing.T) {
+	fake := fakeserver.New()
+	cases := [][]string{
+		{"create", "s"},
+		{"create", "s", "--username", "admin"},
+		{"create", "s", "--token-header", "X-Api-Key"},
+		{"create", "s", "--password-file", "-", "--token-file", "-"},
+		{"create", "s", "--ca-cert-file", "-"},
+	}
+	for _, args := range cases {
+		_, err := runAgainstFake(t, fake, NewSecretsCommand, "not a certificate", args...)
+		assert.Equal(t, errors.KindUsage, errors.KindOf(err), args)
+	}
+	assert.Empty(t, fake.Secrets())
+}
@@ -0,0 +1,167 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/mockserver"
+	"github.com/spf13/cobra"
+)
+
+func NewServeCommand() *cobra.Command {
+	var (
+		port   int
+		hostIP string
+	)
+	var serveCmd = &cobra.Command{
+		Use:   "serve <file|directory>...",
+		Short: "Serve the examples of local artifacts as mocks, without containers",
+		Long: `Serve the examples of local artifacts as mocks, without containers.
+
+OpenAPI and Postman collection examples are served on the same
+/rest/<name>/<version>/<path> URLs as Microcks, dispatching on path and query
+parameters. Directories are scanned recursively and primary artifacts are
+loaded before the secondary ones completing them. Only the basic dispatching
+is done: use 'microcks start' for the full Microcks behavior.`,
+		Example: `# Serve the mocks of every artifact of a directory on port 8585
+microcks serve ./specs
+
+# Serve an OpenAPI file and its Postman examples on another port
+microcks serve api-openapi.yml api-postman.json --port 9090`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+			mocks := mockserver.New()
+			services, err := loadServeArtifacts(mocks, args, out)
+			if err != nil {
+				return err
+			}
+
+			listener, err := net.Listen("tcp", net.JoinHostPort(hostIP, fmt.Sprint(port)))
+			if err != nil {
+				return errors.Wrap(errors.KindEnvironment, fmt.Errorf("cannot listen on port %d: %w", port, err))
+			}
+			baseURL := "http://" + listener.Addr().String()
+			for _, svc := range services {
+				printServedService(out, baseURL, svc)
+			}
+
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer cancel()
+
+			srv := &http.Server{Handler: mocks}
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer shutdownCancel()
+				_ = srv.Shutdown(shutdownCtx)
+			}()
+
+			fmt.Fprintf(out, "Serving mocks on %s — press Ctrl+C to stop.\n", baseURL)
+			if err := srv.Serve(listener); err != http.ErrServerClosed {
+				return errors.Wrap(errors.KindEnvironment, err)
+			}
+			return nil
+		},
+	}
+
+	serveCmd.Flags().IntVar(&port, "port", 8585, "Port to listen on")
+	serveCmd.Flags().StringVar(&hostIP, "host-ip", connectors.LOCALHOST_IP, "Host IP to listen on, e.g. 0.0.0.0 to serve other machines")
+
+	return serveCmd
+}
+
+// loadServeArtifacts loads the artifacts of files and directories into the
+// mock server, primary ones first, and returns the services they define.
+// Artifacts that can't be loaded are reported and skipped.
+func loadServeArtifacts(mocks *mockserver.Server, paths []string, out io.Writer) ([]mockserver.Service, error) {
+	files, err := artifactFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrapf(errors.KindEnvironment, "cannot read %s: %v", file, err)
+		}
+		if _, err := mocks.Load(file, content, detectFileType(file).IsPrimary); err != nil {
+			fmt.Fprintf(out, "Skipping %v\n", err)
+		}
+	}
+
+	services := mocks.Services()
+	if len(services) == 0 {
+		return nil, errors.Wrapf(errors.KindUsage, "no artifact defining an API found in %s", strings.Join(paths, ", "))
+	}
+	return services, nil
+}
+
+// artifactFiles lists the given files and the artifacts of the given
+// directories, scanned recursively, primary artifacts first.
+func artifactFiles(paths []string) ([]string, error) {
+	fs := &RealFileSystem{}
+	var files []string
+	for _, path := range paths {
+		info, err := fs.Stat(path)
+		if err != nil {
+			return nil, errors.Wrapf(errors.KindUsage, "cannot read %s: %v", path, err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		found, err := findSpecificationFiles(fs, path, true, "")
+		if err != nil {
+			return nil, errors.Wrapf(errors.KindEnvironment, "error scanning directory %s: %v", path, err)
+		}
+		files = append(files, found...)
+	}
+	sortPrimaryFirst(files)
+	return files, nil
+}
+
+// printServedService lists the mocked operations of a service under its
+// Microcks-like base URL.
+func printServedService(out io.Writer, baseURL string, svc mockserver.Service) {
+	// Microcks encodes the spaces of service names as '+' in mock URLs.
+	name := url.PathEscape(strings.ReplaceAll(svc.Name, " ", "+"))
+	fmt.Fprintf(out, "%s %s at %s/rest/%s/%s\n", svc.Name, svc.Version, baseURL, name, url.PathEscape(svc.Version))
+	served := 0
+	for _, op := range svc.Operations {
+		if len(op.Exchanges) == 0 {
+			continue
+		}
+		served++
+		fmt.Fprintf(out, "  %-40s %d example(s)\n", op.Name, len(op.Exchanges))
+	}
+	if served == 0 {
+		fmt.Fprintln(out, "  no REST operation with examples to serve")
+	}
+}
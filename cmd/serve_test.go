@@ -0,0 +1,88 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/mockserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeDispatchesPostmanExamplesOfOpenAPIOperations(t *testing.T) {
+	// The secondary artifact sorts first and is loaded last.
+	dir := copySamples(t, map[string]string{
+		"weather-forecast-postman.json": "a/weather-postman.json",
+		"weather-forecast-openapi.yml":  "b-weather-openapi.yml",
+	})
+	mocks := mockserver.New()
+	var out bytes.Buffer
+	services, err := loadServeArtifacts(mocks, []string{dir}, &out)
+	require.NoError(t, err)
+	require.Len(t, services, 1)
+
+	printServedService(&out, "http://localhost:8585", services[0])
+	assert.Contains(t, out.String(), "WeatherForecast API 1.1.0 at http://localhost:8585/rest/WeatherForecast+API/1.1.0\n")
+	assert.Contains(t, out.String(), "GET /forecast/{region}")
+
+	server := httptest.NewServer(mocks)
+	defer server.Close()
+	get := func(path string) (int, string) {
+		resp, err := http.Get(server.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	status, body := get("/rest/WeatherForecast+API/1.1.0/forecast/east?apiKey=123")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, `"region": "east"`)
+
+	// The wildcard example answers the other regions.
+	status, body = get("/rest/WeatherForecast%20API/1.1.0/forecast/center")
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Contains(t, body, "Region is unknown")
+
+	// Only the mocks are served, not the Microcks API.
+	status, _ = get("/api/services")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestServeSkipsUnsupportedArtifacts(t *testing.T) {
+	dir := copySamples(t, map[string]string{"ecommerce-api-openapi.yml": "ecommerce-openapi.yml"})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.yaml"), []byte("hello: world"), 0o600))
+
+	var out bytes.Buffer
+	services, err := loadServeArtifacts(mockserver.New(), []string{dir}, &out)
+	require.NoError(t, err)
+	require.Len(t, services, 1)
+	assert.Contains(t, out.String(), "Skipping "+filepath.Join(dir, "notes.yaml")+" is not a supported artifact")
+
+	_, err = loadServeArtifacts(mockserver.New(), []string{filepath.Join(dir, "notes.yaml")}, io.Discard)
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+
+	_, err = loadServeArtifacts(mockserver.New(), []string{filepath.Join(dir, "missing")}, io.Discard)
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+}
@@ -0,0 +1,331 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+const servicesPageSize = 100
+
+// labelsSidecarSuffixes name the files holding the labels of the artifact
+// next to them: petstore.yaml is labelled by petstore.labels.yaml.
+var labelsSidecarSuffixes = []string{".labels.yaml", ".labels.yml"}
+
+func NewServicesCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var servicesCmd = &cobra.Command{
+		Use:     "services",
+		Aliases: []string{"service"},
+		Short:   "List and label the services (APIs) of Microcks, configure their mocks",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.HelpFunc()(cmd, args)
+		},
+	}
+
+	servicesCmd.AddCommand(NewServicesListCommand(globalClientOpts))
+	servicesCmd.AddCommand(NewServicesLabelCommand(globalClientOpts))
+	servicesCmd.AddCommand(NewServicesDispatcherCommand(globalClientOpts))
+
+	return servicesCmd
+}
+
+func NewServicesListCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var selector string
+
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List services",
+		Example: `# Services of the pets domain that are in production
+microcks services list -l domain=pets,status=prod`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			labels, err := parseSelector(selector)
+			if err != nil {
+				return err
+			}
+			mc, _, err := newMicrocksClientFromOptions(globalClientOpts)
+			if err != nil {
+				return err
+			}
+			services, err := listAllServices(mc)
+			if err != nil {
+				return err
+			}
+
+			var selected []connectors.Service
+			for _, svc := range services {
+				if matchLabels(svc.Metadata, labels) {
+					selected = append(selected, svc)
+				}
+			}
+			if len(selected) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No service found")
+				return nil
+			}
+			printServices(cmd.OutOrStdout(), selected)
+			return nil
+		},
+	}
+
+	listCmd.Flags().StringVarP(&selector, "selector", "l", "", "Label selector, e.g. 'domain=pets,status=prod'")
+	return listCmd
+}
+
+func NewServicesLabelCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var unlabel []string
+
+	var labelCmd = &cobra.Command{
+		Use:   "label <apiName:apiVersion> [key=value ...]",
+		Short: "Add, change or remove labels of a service",
+		Example: `# Label an API
+microcks services label "Petstore API:1.0" domain=pets status=prod
+
+# Remove a label
+microcks services label "Petstore API:1.0" --unlabel status`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			labels, err := parseLabels(args[1:])
+			if err != nil {
+				return err
+			}
+			if len(labels) == 0 && len(unlabel) == 0 {
+				return errors.Wrapf(errors.KindUsage, "give labels as key=value or keys to remove with --unlabel")
+			}
+			for _, key := range unlabel {
+				if _, ok := labels[key]; ok {
+					return errors.Wrapf(errors.KindUsage, "label '%s' cannot be both set and removed", key)
+				}
+			}
+
+			mc, _, err := newMicrocksClientFromOptions(globalClientOpts)
+			if err != nil {
+				return err
+			}
+			svc, err := labelService(mc, args[0], labels, unlabel)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Service '%s:%s' labels: %s\n", svc.Name, svc.Version, formatLabels(serviceLabels(svc)))
+			return nil
+		},
+	}
+
+	labelCmd.Flags().StringArrayVar(&unlabel, "unlabel", nil, "Key of a label to remove (repeatable)")
+	return labelCmd
+}
+
+func printServices(out io.Writer, services []connectors.Service) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVERSION\tTYPE\tID\tLABELS")
+	for _, svc := range services {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", svc.Name, svc.Version, svc.Type, svc.ID, formatLabels(serviceLabels(&svc)))
+	}
+	w.Flush()
+}
+
+func serviceLabels(svc *connectors.Service) map[string]string {
+	if svc.Metadata == nil {
+		return nil
+	}
+	return svc.Metadata.Labels
+}
+
+// labelService sets and removes labels of a service, keeping the others and
+// its annotations.
+func labelService(mc connectors.MicrocksClient, serviceRef string, set map[string]string, unset []string) (*connectors.Service, error) {
+	svc, err := mc.GetService(serviceRef)
+	if err != nil {
+		return nil, err
+	}
+	metadata := connectors.Metadata{Labels: map[string]string{}}
+	if svc.Metadata != nil {
+		metadata.Annotations = svc.Metadata.Annotations
+		for k, v := range svc.Metadata.Labels {
+			metadata.Labels[k] = v
+		}
+	}
+	for k, v := range set {
+		metadata.Labels[k] = v
+	}
+	for _, k := range unset {
+		delete(metadata.Labels, k)
+	}
+	if err := mc.UpdateServiceMetadata(svc.ID, &metadata); err != nil {
+		return nil, err
+	}
+	svc.Metadata = &metadata
+	return svc, nil
+}
+
+// labelsSidecar returns the labels sidecar file of an artifact, or "".
+func labelsSidecar(specPath string) string {
+	base := strings.TrimSuffix(specPath, filepath.Ext(specPath))
+	for _, suffix := range labelsSidecarSuffixes {
+		if _, err := os.Stat(base + suffix); err == nil {
+			return base + suffix
+		}
+	}
+	return ""
+}
+
+func isLabelsSidecar(path string) bool {
+	for _, suffix := range labelsSidecarSuffixes {
+		if strings.HasSuffix(strings.ToLower(path), suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyLabelsSidecar labels the service an artifact was imported into with
+// the labels of its sidecar file, if any.
+func applyLabelsSidecar(out io.Writer, mc connectors.MicrocksClient, specPath, serviceRef string) error {
+	sidecar := labelsSidecar(specPath)
+	if sidecar == "" {
+		return nil
+	}
+	data, err := os.ReadFile(sidecar)
+	if err != nil {
+		return errors.Wrapf(errors.KindUsage, "cannot read labels file %s: %v", sidecar, err)
+	}
+	var labels map[string]string
+	if err := yaml.Unmarshal(data, &labels); err != nil {
+		return errors.Wrapf(errors.KindUsage, "%s must map label keys to values: %v", sidecar, err)
+	}
+	if len(labels) == 0 {
+		return nil
+	}
+	svc, err := labelService(mc, strings.TrimSpace(serviceRef), labels, nil)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Service '%s:%s' labelled from %s: %s\n", svc.Name, svc.Version, filepath.Base(sidecar), formatLabels(labels))
+	return nil
+}
+
+func listAllServices(mc connectors.MicrocksClient) ([]connectors.Service, error) {
+	var all []connectors.Service
+	for page := 0; ; page++ {
+		services, err := mc.ListServices(page, servicesPageSize)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, services...)
+		if len(services) < servicesPageSize {
+			return all, nil
+		}
+	}
+}
+
+// serviceSelection selects services by `name:version` or `name` (all its
+// versions), by label selector, or all of them.
+type serviceSelection struct {
+	refs     []string
+	selector string
+	all      bool
+}
+
+func (sel serviceSelection) validate() error {
+	switch {
+	case sel.all && (len(sel.refs) > 0 || sel.selector != ""):
+		return errors.Wrapf(errors.KindUsage, "--all cannot be combined with --services or --selector")
+	case !sel.all && len(sel.refs) == 0 && sel.selector == "":
+		return errors.Wrapf(errors.KindUsage, "select services with --services, --selector or --all")
+	}
+	_, err := parseSelector(sel.selector)
+	return err
+}
+
+// selectServices returns the services matching any of the refs or the
+// selector, in server order. Every ref must match at least one service.
+func selectServices(mc connectors.MicrocksClient, sel serviceSelection) ([]connectors.Service, error) {
+	selector, err := parseSelector(sel.selector)
+	if err != nil {
+		return nil, err
+	}
+	services, err := listAllServices(mc)
+	if err != nil {
+		return nil, err
+	}
+
+	matchedRefs := map[string]bool{}
+	var selected []connectors.Service
+	for _, svc := range services {
+		keep := sel.all || (len(selector) > 0 && matchLabels(svc.Metadata, selector))
+		for _, ref := range sel.refs {
+			if ref == svc.Name || ref == svc.Name+":"+svc.Version {
+				matchedRefs[ref] = true
+				keep = true
+			}
+		}
+		if keep {
+			selected = append(selected, svc)
+		}
+	}
+	for _, ref := range sel.refs {
+		if !matchedRefs[ref] {
+			return nil, errors.Wrapf(errors.KindNotFound, "service '%s' is not registered in Microcks", ref)
+		}
+	}
+	return selected, nil
+}
+
+// parseSelector parses a label selector: comma-separated key=value pairs
+// that must all match.
+func parseSelector(selector string) (map[string]string, error) {
+	if selector == "" {
+		return nil, nil
+	}
+	labels := map[string]string{}
+	for _, pair := range strings.Split(selector, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, errors.Wrapf(errors.KindUsage, "--selector: %q must be key=value", pair)
+		}
+		labels[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return labels, nil
+}
+
+func matchLabels(metadata *connectors.Metadata, selector map[string]string) bool {
+	for key, value := range selector {
+		if metadata == nil || metadata.Labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func serviceRefs(services []connectors.Service) []string {
+	refs := make([]string, 0, len(services))
+	for _, svc := range services {
+		refs = append(refs, svc.Name+":"+svc.Version)
+	}
+	sort.Strings(refs)
+	return refs
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/fakeserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServicesLabelAndList(t *testing.T) {
+	fake := fakeserver.New()
+	fake.AddService(fakeserver.Service{Name: "Petstore API", Version: "1.0", Type: "REST",
+		Metadata: &fakeserver.Metadata{Labels: map[string]string{"domain": "pets"}, Annotations: map[string]string{"owner": "alice"}}})
+	fake.AddService(fakeserver.Service{Name: "Orders API", Version: "1.0", Type: "REST"})
+
+	out, err := runAgainstFake(t, fake, NewServicesCommand, "", "label", "Petstore API:1.0", "status=prod", "team=alpha")
+	require.NoError(t, err)
+	assert.Equal(t, "Service 'Petstore API:1.0' labels: domain=pets,status=prod,team=alpha\n", out)
+
+	out, err = runAgainstFake(t, fake, NewServicesCommand, "", "label", "Petstore API:1.0", "--unlabel", "team")
+	require.NoError(t, err)
+	assert.Contains(t, out, "labels: domain=pets,status=prod\n")
+	assert.Equal(t, map[string]string{"owner": "alice"}, fake.Services()[0].Metadata.Annotations)
+
+	out, err = runAgainstFake(t, fake, NewServicesCommand, "", "list", "-l", "status=prod")
+	require.NoError(t, err)
+	assert.Regexp(t, `Petstore API\s+1.0\s+REST\s+\w+\s+domain=pets,status=prod\n`, out)
+	assert.NotContains(t, out, "Orders API")
+
+	out, err = runAgainstFake(t, fake, NewServicesCommand, "", "list", "-l", "status=retired")
+	require.NoError(t, err)
+	assert.Equal(t, "No service found\n", out)
+
+	_, err = runAgainstFake(t, fake, NewServicesCommand, "", "label", "Petstore API:1.0")
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+	_, err = runAgainstFake(t, fake, NewServicesCommand, "", "label", "Petstore API:1.0", "status=prod", "--unlabel", "status")
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+	_, err = runAgainstFake(t, fake, NewServicesCommand, "", "label", "Billing API:1.0", "status=prod")
+	assert.Equal(t, errors.KindNotFound, errors.KindOf(err))
+}
+
+func TestImportDirAppliesLabelsSidecar(t *testing.T) {
+	fake := fakeserver.New()
+	configPath := writeContextsConfig(t, map[string]*fakeserver.Server{"local": fake})
+
+	dir := t.TempDir()
+	content, err := os.ReadFile("../samples/weather-forecast-openapi.yml")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "weather-openapi.yml"), content, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "weather-openapi.labels.yaml"), []byte("domain: weather\nstatus: beta\n"), 0o600))
+
+	cmd := NewImportDirCommand(&connectors.ClientOptions{ConfigPath: configPath})
+	cmd.SetArgs([]string{dir})
+	cmd.SilenceUsage = true
+	require.NoError(t, cmd.Execute())
+
+	require.Len(t, fake.Artifacts(), 1)
+	services := fake.Services()
+	require.Len(t, services, 1)
+	assert.Equal(t, map[string]string{"domain": "weather", "status": "beta"}, services[0].Metadata.Labels)
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func NewSnapshotCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var snapshotCmd = &cobra.Command{
+		Use:   "snapshot",
+		Short: "Export and import Microcks repository snapshots",
+		Long: `Export the content of a Microcks repository (services, their resources and examples) to a file,
+and import it into the same or another Microcks, e.g. before an upgrade or to clone an environment`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.HelpFunc()(cmd, args)
+		},
+	}
+
+	snapshotCmd.AddCommand(NewSnapshotExportCommand(globalClientOpts))
+	snapshotCmd.AddCommand(NewSnapshotImportCommand(globalClientOpts))
+
+	return snapshotCmd
+}
+
+func NewSnapshotExportCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var (
+		sel    serviceSelection
+		output string
+	)
+
+	var exportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Export services to a snapshot file",
+		Example: `# Back up everything
+microcks snapshot export --all -o snapshot.json
+
+# Some services, by name (all versions) or name:version
+microcks snapshot export --services "Petstore API,Orders API:1.0" -o snapshot.json
+
+# Services labelled domain=pets
+microcks snapshot export --selector domain=pets -o pets.json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := sel.validate(); err != nil {
+				return err
+			}
+
+			mc, _, err := newMicrocksClientFromOptions(globalClientOpts)
+			if err != nil {
+				return err
+			}
+			services, err := selectServices(mc, sel)
+			if err != nil {
+				return err
+			}
+			if len(services) == 0 {
+				return errors.Wrapf(errors.KindNotFound, "no service matches the selection")
+			}
+
+			ids := make([]string, 0, len(services))
+			for _, svc := range services {
+				ids = append(ids, svc.ID)
+			}
+			snapshot, err := mc.ExportSnapshot(ids)
+			if err != nil {
+				return err
+			}
+
+			if output == "" || output == "-" {
+				_, err := cmd.OutOrStdout().Write(append(snapshot, '\n'))
+				return err
+			}
+			if err := os.WriteFile(output, append(snapshot, '\n'), 0o644); err != nil {
+				return errors.Wrapf(errors.KindEnvironment, "cannot write snapshot %s: %v", output, err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Exported %d services to %s: %s\n", len(services), output, strings.Join(serviceRefs(services), ", "))
+			return nil
+		},
+	}
+
+	exportCmd.Flags().StringSliceVar(&sel.refs, "services", nil, "Services to export, as 'name' (all versions) or 'name:version', comma-separated")
+	exportCmd.Flags().StringVarP(&sel.selector, "selector", "l", "", "Label selector of the services to export, e.g. 'domain=pets,team=alpha'")
+	exportCmd.Flags().BoolVar(&sel.all, "all", false, "Export all services")
+	exportCmd.Flags().StringVarP(&output, "output", "o", "", "Snapshot file to write (default: stdout)")
+
+	return exportCmd
+}
+
+func NewSnapshotImportCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var importCmd = &cobra.Command{
+		Use:   "import <snapshot.json>",
+		Short: "Import a snapshot file",
+		Example: `# Clone staging into a local instance
+microcks snapshot export --all -o staging.json --microcks-context staging
+microcks snapshot import staging.json --microcks-context local`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Check the file upfront: Microcks answers a bare 500 on junk.
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return errors.Wrapf(errors.KindUsage, "cannot read snapshot %s: %v", args[0], err)
+			}
+			var content struct {
+				Services []connectors.Service `json:"services"`
+			}
+			if err := json.Unmarshal(data, &content); err != nil {
+				return errors.Wrapf(errors.KindUsage, "%s is not a Microcks snapshot: %v", args[0], err)
+			}
+
+			mc, _, err := newMicrocksClientFromOptions(globalClientOpts)
+			if err != nil {
+				return err
+			}
+			if err := mc.ImportSnapshot(args[0]); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Imported %d services from %s: %s\n", len(content.Services), args[0], strings.Join(serviceRefs(content.Services), ", "))
+			return nil
+		},
+	}
+	return importCmd
+}
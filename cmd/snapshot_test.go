@@ -0,0 +1,74 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/fakeserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotClonesServicesBetweenServers(t *testing.T) {
+	staging := fakeserver.New()
+	staging.AddService(fakeserver.Service{Name: "Petstore API", Version: "1.0", Metadata: &fakeserver.Metadata{Labels: map[string]string{"domain": "pets"}},
+		Operations: []fakeserver.Operation{{Name: "GET /pets", Method: "GET", Examples: []string{"all"}}}})
+	staging.AddService(fakeserver.Service{Name: "Petstore API", Version: "2.0", Metadata: &fakeserver.Metadata{Labels: map[string]string{"domain": "pets"}}})
+	staging.AddService(fakeserver.Service{Name: "Orders API", Version: "1.0"})
+
+	file := filepath.Join(t.TempDir(), "snapshot.json")
+	out, err := runAgainstFake(t, staging, NewSnapshotCommand, "", "export", "--selector", "domain=pets", "-o", file)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Exported 2 services to "+file+": Petstore API:1.0, Petstore API:2.0")
+
+	local := fakeserver.New()
+	out, err = runAgainstFake(t, local, NewSnapshotCommand, "", "import", file)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Imported 2 services from "+file)
+
+	services := local.Services()
+	require.Len(t, services, 2)
+	assert.Equal(t, "Petstore API:1.0", services[0].Ref())
+	assert.Equal(t, []string{"all"}, services[0].Operations[0].Examples)
+
+	out, err = runAgainstFake(t, staging, NewSnapshotCommand, "", "export", "--services", "Orders API:1.0")
+	require.NoError(t, err)
+	assert.Contains(t, out, `"name":"Orders API"`)
+	assert.NotContains(t, out, "Petstore API")
+}
+
+func TestSnapshotExportSelection(t *testing.T) {
+	fake := fakeserver.New()
+	fake.AddService(fakeserver.Service{Name: "Orders API", Version: "1.0"})
+
+	for _, args := range [][]string{
+		{"export"},
+		{"export", "--all", "--services", "Orders API"},
+		{"export", "--selector", "domain"},
+	} {
+		_, err := runAgainstFake(t, fake, NewSnapshotCommand, "", args...)
+		assert.Equal(t, errors.KindUsage, errors.KindOf(err), "%v", args)
+	}
+
+	_, err := runAgainstFake(t, fake, NewSnapshotCommand, "", "export", "--services", "Billing API")
+	assert.Equal(t, errors.KindNotFound, errors.KindOf(err))
+
+	_, err = runAgainstFake(t, fake, NewSnapshotCommand, "", "import", "../samples/weather-forecast-openapi.yml")
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+}
@@ -1,16 +1,37 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io/fs"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
+	"github.com/docker/go-units"
+	"github.com/fsnotify/fsnotify"
 	"github.com/microcks/microcks-cli/pkg/config"
 	"github.com/microcks/microcks-cli/pkg/connectors"
 	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/util/duration"
 	"github.com/spf13/cobra"
 )
 
+// artifactsContainerPath is where --mount makes the artifacts directory
+// visible inside the container.
+const artifactsContainerPath = "/deployments/artifacts"
+
+// autoPort is the --port value picking a free host port.
+const autoPort = "auto"
+
 func NewStartCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
 	var (
 		name         string
@@ -18,8 +39,19 @@ func NewStartCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command
 		imageName    string
 		autoRemove   bool
 		driver       string
-		readyTimeout time.Duration
+		readyTimeout = duration.Value(60 * time.Second)
 		noWait       bool
+		artifactsDir string
+		mount        bool
+		watch        bool
+		async        string
+		env          []string
+		volumes      []string
+		network      string
+		hostIP       string
+		grpcPort     string
+		cpus         float64
+		memory       string
 	)
 	var startCmd = &cobra.Command{
 		Use:   "start",
@@ -30,12 +62,71 @@ microcks start
 # Define your port (by default 8585)
 microcks start --port [Port you want]
 
+# Run another instance for a project, on a free port
+microcks start --name billing --port auto
+
 # Define your driver (by default docker)
 microcks start --driver [driver you wnat either 'docker' or 'podman']
 
 # Define name of your microcks container/instance
-microcks start --name [name of you container/instance]`,
+microcks start --name [name of you container/instance]
+
+# Seed the instance with the artifacts of a directory and re-import them on change
+microcks start --artifacts ./specs --watch
+
+# Also start a Kafka broker and the async minion to mock AsyncAPI
+microcks start --async kafka
+
+# Tune the container: environment, CA certificates, gRPC port and resources
+microcks start -e JAVA_OPTIONS=-Xmx1g -v ./certs:/deployments/certs:ro --grpc-port 9090 --cpus 2 --memory 2g`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if artifactsDir == "" && (mount || watch) {
+				return errors.Wrapf(errors.KindUsage, "--mount and --watch require --artifacts")
+			}
+			if err := validateAsyncProtocol(async); err != nil {
+				return err
+			}
+			if async != "" && noWait {
+				return errors.Wrapf(errors.KindUsage, "--async cannot be combined with --no-wait: the async minion is started once the server is ready")
+			}
+			if network != "" && async != "" {
+				return errors.Wrapf(errors.KindUsage, "--network cannot be combined with --async: the instance gets its own network for the broker")
+			}
+			if hostIP != "" && net.ParseIP(hostIP) == nil {
+				return errors.Wrapf(errors.KindUsage, "--host-ip must be an IP address, got '%s'", hostIP)
+			}
+			if cpus < 0 {
+				return errors.Wrapf(errors.KindUsage, "--cpus must be positive, got %g", cpus)
+			}
+			if memory != "" {
+				if _, err := units.RAMInBytes(memory); err != nil {
+					return errors.Wrapf(errors.KindUsage, "--memory must be a size such as 512m or 2g, got '%s'", memory)
+				}
+			}
+			for _, e := range env {
+				if key, _, ok := strings.Cut(e, "="); !ok || key == "" {
+					return errors.Wrapf(errors.KindUsage, "--env must be KEY=VALUE, got '%s'", e)
+				}
+			}
+			binds := make([]string, 0, len(volumes))
+			for _, v := range volumes {
+				bind, err := parseVolume(v)
+				if err != nil {
+					return errors.Wrap(errors.KindUsage, err)
+				}
+				binds = append(binds, bind)
+			}
+			if artifactsDir != "" {
+				if noWait {
+					return errors.Wrapf(errors.KindUsage, "--artifacts cannot be combined with --no-wait: artifacts are imported once the server is ready")
+				}
+				if err := validateDirectory(&RealFileSystem{}, artifactsDir); err != nil {
+					if _, ok := err.(*ValidationError); ok {
+						return errors.Wrap(errors.KindUsage, err)
+					}
+					return err
+				}
+			}
 
 			configFile := globalClientOpts.ConfigPath
 			localConfig, err := config.ReadLocalConfig(configFile)
@@ -60,7 +151,7 @@ microcks start --name [name of you container/instance]`,
 				if instanceDriver == "" {
 					instanceDriver = driver
 				}
-				containerClient, err := connectors.NewContainerClient(instanceDriver)
+				containerClient, err := newContainerClient(instanceDriver)
 				if err != nil {
 					return errors.Wrap(errors.KindEnvironment, err)
 				}
@@ -76,34 +167,166 @@ microcks start --name [name of you container/instance]`,
 				}
 			}
 
+			if async != "" && instance.Status != "" && instance.Async != async {
+				return errors.Wrapf(errors.KindUsage, "instance %s already exists without --async %s: "+
+					"remove it with 'microcks instances rm %s' first", name, async, name)
+			}
+
+			if instance.Status != "" {
+				for _, flag := range []string{"env", "volume", "network", "host-ip", "grpc-port", "cpus", "memory", "mount"} {
+					if cmd.Flags().Changed(flag) {
+						fmt.Printf("Instance %s already exists: container options only apply when it is created, "+
+							"remove it with 'microcks instances rm %s' to change them\n", name, name)
+						break
+					}
+				}
+			}
+
+			if instance.Status != "" {
+				if err := localConfig.CheckInstance(*instance); err != nil {
+					return errors.Wrapf(errors.KindUsage, "%s: start the instance with another --name", err)
+				}
+			}
+
+			wasRunning := instance.Status == "Running"
 			switch instance.Status {
 			case "Running":
 				fmt.Printf("Microcks instance with name %s is already running", name)
-				return nil
+				if artifactsDir == "" {
+					return nil
+				}
+				fmt.Println()
 			case "Exited":
-				containerClient, err := connectors.NewContainerClient(instance.Driver)
+				containerClient, err := newContainerClient(instance.Driver)
 				if err != nil {
 					return errors.Wrap(errors.KindEnvironment, err)
 				}
 				defer containerClient.CloseClient()
 
+				if err := recreateMissingCompanions(containerClient, instance); err != nil {
+					return errors.Wrap(errors.KindEnvironment, err)
+				}
+				if err := startCompanions(containerClient, instance, companionBroker); err != nil {
+					return errors.Wrap(errors.KindEnvironment, err)
+				}
 				if err := containerClient.StartContainer(instance.ContainerID); err != nil {
 					return errors.Wrap(errors.KindEnvironment, fmt.Errorf("failed to start container: %w", err))
 				}
 				instance.Status = "Running"
 			default:
-				containerClient, err := connectors.NewContainerClient(driver)
+				previous := *instance
+				// A container recreated after drift keeps the options it
+				// was started with, unless they are given again.
+				if instance.Name != "" {
+					flags := cmd.Flags()
+					if !flags.Changed("driver") && instance.Driver != "" {
+						driver = instance.Driver
+					}
+					if !flags.Changed("async") {
+						async = instance.Async
+						if async != "" && noWait {
+							return errors.Wrapf(errors.KindUsage, "instance %s runs with --async %s, which cannot be combined with --no-wait", name, async)
+						}
+					}
+					if !flags.Changed("port") && instance.Port != "" {
+						hostPort = instance.Port
+					}
+					if !flags.Changed("image") && instance.Image != "" {
+						imageName = instance.Image
+					}
+					if !flags.Changed("env") {
+						env = instance.Env
+					}
+					if !flags.Changed("volume") {
+						binds = instance.Volumes
+					}
+					if !flags.Changed("network") && instance.Async == "" {
+						network = instance.Network
+					}
+					if !flags.Changed("host-ip") && instance.HostIP != "" {
+						hostIP = instance.HostIP
+					}
+					if !flags.Changed("grpc-port") {
+						grpcPort = instance.GrpcPort
+					}
+					if !flags.Changed("cpus") {
+						cpus = instance.CPUs
+					}
+					if !flags.Changed("memory") {
+						memory = instance.Memory
+					}
+				}
+				// The broker is published on its protocol port, a free one
+				// with --port auto, or the one it was recreated with.
+				brokerPort := ""
+				switch {
+				case async == "":
+				case instance.Async == async && instance.BrokerPort != "":
+					brokerPort = instance.BrokerPort
+				case hostPort == autoPort:
+					if brokerPort, err = freePort(localConfig, hostIP, grpcPort); err != nil {
+						return errors.Wrap(errors.KindEnvironment, err)
+					}
+				default:
+					brokerPort = asyncBrokers[async].port
+				}
+				if hostPort == autoPort {
+					if hostPort, err = freePort(localConfig, hostIP, grpcPort, brokerPort); err != nil {
+						return errors.Wrap(errors.KindEnvironment, err)
+					}
+				}
+				instance.AutoRemove = autoRemove
+				instance.Name = name
+				instance.Image = imageName
+				if instance.Image != previous.Image {
+					instance.ImageDigest = ""
+				}
+				instance.Port = hostPort
+				instance.Driver = driver
+				instance.Async = async
+				instance.Network = network
+				instance.Companions = nil
+				instance.BrokerPort = brokerPort
+				instance.Env = env
+				instance.Volumes = binds
+				instance.HostIP = hostIP
+				instance.GrpcPort = grpcPort
+				instance.CPUs = cpus
+				instance.Memory = memory
+				if mount {
+					absDir, err := filepath.Abs(artifactsDir)
+					if err != nil {
+						return errors.Wrap(errors.KindUsage, fmt.Errorf("failed to resolve artifacts directory: %w", err))
+					}
+					if bind := absDir + ":" + artifactsContainerPath + ":ro"; !slices.Contains(instance.Volumes, bind) {
+						instance.Volumes = append(instance.Volumes, bind)
+					}
+				}
+				if err := localConfig.CheckInstance(*instance); err != nil {
+					return errors.Wrapf(errors.KindUsage, "%s: choose another --port (or --port auto) or --name", err)
+				}
+
+				containerClient, err := newContainerClient(driver)
 				if err != nil {
 					return errors.Wrap(errors.KindEnvironment, err)
 				}
 				defer containerClient.CloseClient()
 
-				containerId, err := containerClient.CreateContainer(connectors.ContainerOpts{
-					Image:      imageName,
-					Port:       hostPort,
-					Name:       name,
-					AutoRemove: autoRemove,
-				})
+				// The companions of a vanished container are recreated too.
+				if err := tearDownAsync(containerClient, &previous); err != nil {
+					return errors.Wrap(errors.KindEnvironment, err)
+				}
+				if async != "" {
+					if err := setUpAsync(containerClient, instance); err != nil {
+						return errors.Wrap(errors.KindEnvironment, err)
+					}
+				}
+
+				opts, err := instanceContainerOpts(*instance)
+				if err != nil {
+					return errors.Wrap(errors.KindUsage, err)
+				}
+				containerId, err := containerClient.CreateContainer(opts)
 				if err != nil {
 					return errors.Wrap(errors.KindEnvironment, fmt.Errorf("failed to create container: %w", err))
 				}
@@ -113,26 +336,30 @@ microcks start --name [name of you container/instance]`,
 				}
 
 				instance.ContainerID = containerId
-				instance.AutoRemove = autoRemove
-				instance.Name = name
-				instance.Image = imageName
-				instance.Port = hostPort
 				instance.Status = "Running"
-				instance.Driver = driver
+				if err := pinImage(containerClient, instance); err != nil {
+					return errors.Wrap(errors.KindEnvironment, err)
+				}
 			}
 
-			//Store config and change context
-			localConfig.UpsertInstance(config.Instance{
-				ContainerID: instance.ContainerID,
-				Name:        instance.Name,
-				Image:       instance.Image,
-				Port:        instance.Port,
-				Status:      instance.Status,
-				Driver:      instance.Driver,
-				AutoRemove:  instance.AutoRemove,
-			})
+			if artifactsDir != "" {
+				absDir, err := filepath.Abs(artifactsDir)
+				if err != nil {
+					return errors.Wrap(errors.KindUsage, fmt.Errorf("failed to resolve artifacts directory: %w", err))
+				}
+				instance.Artifacts = absDir
+			}
 
-			server := fmt.Sprintf("http://localhost:%s", instance.Port)
+			//Store config and change context. UpsertInstance matches on the
+			//container ID, which changes when the container is recreated.
+			localConfig.RemoveInstance(instance.Name)
+			localConfig.UpsertInstance(*instance)
+
+			// The context is named after the instance; the one of a former
+			// container, possibly on another port or named after its URL,
+			// is replaced.
+			server := instanceURL(*instance)
+			removeInstanceContexts(localConfig, instance.Name)
 
 			localConfig.UpsertServer(config.Server{
 				Name:           name,
@@ -153,9 +380,9 @@ microcks start --name [name of you container/instance]`,
 				RefreshToken: "",
 			})
 
-			localConfig.CurrentContext = server
+			localConfig.CurrentContext = name
 			localConfig.UpsertContext(config.ContextRef{
-				Name:     server,
+				Name:     name,
 				Server:   server,
 				User:     server,
 				Instance: instance.Name,
@@ -171,26 +398,98 @@ microcks start --name [name of you container/instance]`,
 			// so chained commands (import, test) don't race the boot.
 			if !noWait {
 				fmt.Printf("Waiting for Microcks to be ready at %s ...\n", server)
-				if err := waitForReady(server, readyTimeout); err != nil {
+				if err := waitForReady(server, readyTimeout.Duration()); err != nil {
 					return errors.Wrapf(errors.KindEnvironment, "Microcks container is started but the server is not ready: %v. "+
 						"It may still be booting — retry shortly or raise --ready-timeout", err)
 				}
 			}
 
-			fmt.Printf("Microcks started successfully at %s\n", server)
-			return nil
+			if instance.Async != "" && !wasRunning {
+				containerClient, err := newContainerClient(instanceDriver(*instance))
+				if err != nil {
+					return errors.Wrap(errors.KindEnvironment, err)
+				}
+				defer containerClient.CloseClient()
+				if err := startCompanions(containerClient, instance, companionMinion); err != nil {
+					return errors.Wrap(errors.KindEnvironment, err)
+				}
+			}
+
+			fmt.Printf("Microcks started successfully at %s, context '%s' is now the current one\n", server, name)
+			if instance.Async != "" {
+				fmt.Printf("Async API mocks are published on the %s broker at %s\n", instance.Async, brokerAddress(*instance))
+			}
+			if artifactsDir == "" {
+				return nil
+			}
+			return importStartArtifacts(globalClientOpts, name, artifactsDir, watch)
 		},
 	}
 	startCmd.Flags().StringVar(&name, "name", "microcks", "name for your Microcks instance")
-	startCmd.Flags().StringVar(&hostPort, "port", "8585", "Host port to expose Microcks")
+	startCmd.Flags().StringVar(&hostPort, "port", "8585", "Host port to expose Microcks, or 'auto' to pick a free one")
 	startCmd.Flags().StringVar(&imageName, "image", "quay.io/microcks/microcks-uber:latest-native", "image which will be used to create a container")
 	startCmd.Flags().BoolVar(&autoRemove, "rm", false, "mimic of '--rm' flag of Docker to automatically remove the container when it exits")
 	startCmd.Flags().StringVar(&driver, "driver", "docker", "use --driver to change driver from docker to podman")
-	startCmd.Flags().DurationVar(&readyTimeout, "ready-timeout", 60*time.Second, "how long to wait for the Microcks server to be ready before failing")
+	startCmd.Flags().Var(&readyTimeout, "ready-timeout", "how long to wait for the Microcks server to be ready before failing")
 	startCmd.Flags().BoolVar(&noWait, "no-wait", false, "return as soon as the container is started, without waiting for the Microcks server to be ready")
+	startCmd.Flags().StringVar(&artifactsDir, "artifacts", "", "directory of artifacts to import, primary before secondary, once the server is ready")
+	startCmd.Flags().BoolVar(&mount, "mount", false, "also mount the artifacts directory read-only at "+artifactsContainerPath+" in a newly created container")
+	startCmd.Flags().BoolVar(&watch, "watch", false, "keep watching the artifacts directory and re-import files on change")
+	startCmd.Flags().StringVar(&async, "async", "", "also start a broker (kafka, mqtt, amqp or nats) and the async minion to mock AsyncAPI")
+	startCmd.Flags().StringArrayVarP(&env, "env", "e", nil, "environment variable of the container as KEY=VALUE (repeatable)")
+	startCmd.Flags().StringArrayVarP(&volumes, "volume", "v", nil, "bind mount as host-path:container-path[:ro|rw] (repeatable)")
+	startCmd.Flags().StringVar(&network, "network", "", "existing container network to attach the instance to")
+	startCmd.Flags().StringVar(&hostIP, "host-ip", connectors.LOCALHOST_IP, "host IP the ports are bound on, 0.0.0.0 for every interface")
+	startCmd.Flags().StringVar(&grpcPort, "grpc-port", "", "host port to expose the gRPC mocks on (not exposed by default)")
+	startCmd.Flags().Float64Var(&cpus, "cpus", 0, "number of CPUs the container can use, unlimited by default")
+	startCmd.Flags().StringVar(&memory, "memory", "", "memory limit of the container such as 512m or 2g, unlimited by default")
 	return startCmd
 }
 
+// freePort asks the system for a free port on hostIP, skipping the ones
+// recorded by other instances and their companions, as a stopped instance
+// keeps its ports, and the reserved ones the new instance claims itself.
+func freePort(localConfig *config.LocalConfig, hostIP string, reserved ...string) (string, error) {
+	if hostIP == "" {
+		hostIP = connectors.LOCALHOST_IP
+	}
+	for range 10 {
+		listener, err := net.Listen("tcp", net.JoinHostPort(hostIP, "0"))
+		if err != nil {
+			return "", fmt.Errorf("failed to find a free port: %w", err)
+		}
+		port := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+		listener.Close()
+		if localConfig.InstanceOnPort(port) == nil && !slices.Contains(reserved, port) {
+			return port, nil
+		}
+	}
+	return "", fmt.Errorf("failed to find a free port not recorded by another instance")
+}
+
+// parseVolume checks a host-path:container-path[:ro|rw] bind and makes its
+// host path absolute, as Docker requires. Named volumes are kept as is.
+func parseVolume(spec string) (string, error) {
+	rest, mode := spec, ""
+	if strings.HasSuffix(rest, ":ro") || strings.HasSuffix(rest, ":rw") {
+		rest, mode = rest[:len(rest)-3], rest[len(rest)-3:]
+	}
+	// The container path is a Linux one, the host path may hold a drive letter.
+	i := strings.LastIndex(rest, ":")
+	if i <= 0 || i == len(rest)-1 || !strings.HasPrefix(rest[i+1:], "/") {
+		return "", fmt.Errorf("--volume must be host-path:container-path[:ro|rw], got '%s'", spec)
+	}
+	host, target := rest[:i], rest[i+1:]
+	if !filepath.IsAbs(host) && (strings.HasPrefix(host, ".") || strings.ContainsAny(host, `/\`)) {
+		abs, err := filepath.Abs(host)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve volume %s: %w", host, err)
+		}
+		host = abs
+	}
+	return host + ":" + target + mode, nil
+}
+
 // waitForReady polls the Microcks API until it answers with 200 or the
 // timeout elapses. HTTP being up is the signal users care about — the
 // Spring Boot app inside the container takes a while after the container
@@ -211,3 +510,131 @@ func waitForReady(serverURL string, timeout time.Duration) error {
 	}
 	return fmt.Errorf("not ready after %s", timeout)
 }
+
+// importStartArtifacts imports a directory into a freshly started instance,
+// like import-dir does but recursively. With watch, it then re-imports the
+// files that change until interrupted.
+func importStartArtifacts(globalClientOpts *connectors.ClientOptions, contextName, dir string, watch bool) error {
+	mc, _, err := newContextClient(globalClientOpts, contextName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Importing artifacts from %s ...\n", dir)
+	result, err := ImportDirectory(os.Stdout, mc, &RealFileSystem{}, dir, ImportConfig{Recursive: true})
+	if err != nil {
+		if _, ok := err.(*ValidationError); ok {
+			return errors.Wrap(errors.KindUsage, err)
+		}
+		return err
+	}
+	err = reportImportDirectory(mc, result, false)
+	if !watch {
+		return err
+	}
+	if err != nil {
+		// Broken artifacts get another chance on their next save.
+		fmt.Printf("%s, they will be re-imported on change\n", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	return watchArtifactsDir(ctx, mc, dir)
+}
+
+// watchArtifactsDir re-imports the artifacts of dir that are written or
+// created, primary before secondary, until ctx is done.
+func watchArtifactsDir(ctx context.Context, mc connectors.MicrocksClient, dir string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(errors.KindEnvironment, fmt.Errorf("failed to create file watcher: %w", err))
+	}
+	defer watcher.Close()
+
+	// fsnotify watches are not recursive: watch every directory, then the
+	// ones created while watching.
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		return watcher.Add(path)
+	})
+	if err != nil {
+		return errors.Wrap(errors.KindEnvironment, fmt.Errorf("failed to watch %s: %w", dir, err))
+	}
+
+	fmt.Printf("\nWatching %s for changes — press Ctrl+C to stop.\n", dir)
+
+	changed := map[string]bool{}
+	reimport := make(chan struct{}, 1)
+	var debounce *time.Timer
+
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println("\nStopping watch mode.")
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !event.Op.Has(fsnotify.Write) && !event.Op.Has(fsnotify.Create) {
+				continue
+			}
+			if info, err := os.Stat(event.Name); err != nil {
+				continue
+			} else if info.IsDir() {
+				if err := watcher.Add(event.Name); err != nil {
+					fmt.Printf("Watch error: %s\n", err)
+				}
+				continue
+			}
+			ext := strings.ToLower(filepath.Ext(event.Name))
+			if !supportedExtensions[ext] || isLabelsSidecar(event.Name) {
+				continue
+			}
+			changed[event.Name] = true
+			// Debounce editor save bursts, and let a primary and its
+			// secondary artifacts saved together be imported in order.
+			if debounce != nil {
+				debounce.Stop()
+			}
+			debounce = time.AfterFunc(300*time.Millisecond, func() {
+				select {
+				case reimport <- struct{}{}:
+				default:
+				}
+			})
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Printf("Watch error: %s\n", err)
+
+		case <-reimport:
+			files := make([]string, 0, len(changed))
+			for file := range changed {
+				files = append(files, file)
+			}
+			clear(changed)
+			sort.Strings(files)
+			sortPrimaryFirst(files)
+
+			for _, file := range files {
+				msg, err := mc.UploadArtifact(file, detectFileType(file).IsPrimary)
+				if err != nil {
+					// Invalid artifacts mid-edit are expected: report and
+					// keep watching, the next valid save recovers.
+					fmt.Printf("Re-import of %s failed, waiting for next change: %s\n", file, err)
+					continue
+				}
+				fmt.Printf("%s changed, Microcks has re-imported '%s'\n", file, msg)
+				if err := applyLabelsSidecar(os.Stdout, mc, file, msg); err != nil {
+					fmt.Printf("Labelling from the sidecar of %s failed: %s\n", file, err)
+				}
+			}
+		}
+	}
+}
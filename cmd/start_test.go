@@ -1,11 +1,22 @@
 package cmd
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/microcks/microcks-cli/pkg/config"
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/fakeserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWaitForReadyImmediate(t *testing.T) {
@@ -51,3 +62,320 @@ func TestWaitForReadyTimeout(t *testing.T) {
 		t.Error("expected timeout error, got nil")
 	}
 }
+
+// copySamples copies sample artifacts into a fresh directory, under new names.
+func copySamples(t *testing.T, names map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for sample, name := range names {
+		content, err := os.ReadFile(filepath.Join("..", "samples", sample))
+		require.NoError(t, err)
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), content, 0o600))
+	}
+	return dir
+}
+
+func TestStartImportsArtifactsPrimaryFirst(t *testing.T) {
+	runtime := &fakeRuntime{states: map[string]string{}}
+	useFakeRuntime(t, runtime)
+	fake := fakeserver.New()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	// The secondary artifact sorts first, in a subdirectory imported too.
+	dir := copySamples(t, map[string]string{
+		"weather-forecast-postman.json": "a/weather-postman.json",
+		"weather-forecast-openapi.yml":  "b-weather-openapi.yml",
+	})
+	configPath := filepath.Join(t.TempDir(), "config")
+	cmd := NewStartCommand(&connectors.ClientOptions{ConfigPath: configPath})
+	cmd.SetArgs([]string{"--port", serverURL.Port(), "--artifacts", dir, "--mount"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	require.NoError(t, cmd.Execute())
+
+	require.Len(t, runtime.created, 1)
+	assert.Equal(t, []string{dir + ":/deployments/artifacts:ro"}, runtime.created[0].Volumes)
+
+	artifacts := fake.Artifacts()
+	require.Len(t, artifacts, 2)
+	assert.Equal(t, "b-weather-openapi.yml", artifacts[0].Name)
+	assert.True(t, artifacts[0].MainArtifact)
+	assert.Equal(t, "weather-postman.json", artifacts[1].Name)
+	assert.False(t, artifacts[1].MainArtifact)
+	assert.Len(t, fake.Services(), 1)
+}
+
+func TestStartArtifactsFlagsValidation(t *testing.T) {
+	useFakeRuntime(t, &fakeRuntime{states: map[string]string{}})
+	configPath := filepath.Join(t.TempDir(), "config")
+
+	for _, args := range [][]string{
+		{"--watch"},
+		{"--mount"},
+		{"--artifacts", t.TempDir(), "--no-wait"},
+		{"--artifacts", filepath.Join(t.TempDir(), "missing")},
+	} {
+		cmd := NewStartCommand(&connectors.ClientOptions{ConfigPath: configPath})
+		cmd.SetArgs(args)
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		err := cmd.Execute()
+		assert.Equal(t, errors.KindUsage, errors.KindOf(err), "args %v: %v", args, err)
+	}
+}
+
+func TestStartContainerOptionsAreRecordedAndReused(t *testing.T) {
+	runtime := &fakeRuntime{states: map[string]string{}}
+	useFakeRuntime(t, runtime)
+	server := httptest.NewServer(fakeserver.New())
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	configPath := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, runLifecycleCommand(NewStartCommand, configPath, "--port", serverURL.Port(),
+		"-e", "JAVA_OPTIONS=-Xmx1g", "--env", "FEATURE=on", "-v", "./certs:/deployments/certs:ro",
+		"--network", "ci", "--grpc-port", "9191", "--cpus", "1.5", "--memory", "2g"))
+
+	certs, err := filepath.Abs("certs")
+	require.NoError(t, err)
+	expected := connectors.ContainerOpts{
+		Image:    "quay.io/microcks/microcks-uber:latest-native",
+		Port:     serverURL.Port(),
+		Name:     "microcks",
+		Env:      []string{"JAVA_OPTIONS=-Xmx1g", "FEATURE=on"},
+		Volumes:  []string{certs + ":/deployments/certs:ro"},
+		Network:  "ci",
+		HostIP:   "127.0.0.1",
+		GrpcPort: "9191",
+		CPUs:     1.5,
+		Memory:   2 << 30,
+	}
+	require.Len(t, runtime.created, 1)
+	assert.Equal(t, expected, runtime.created[0])
+
+	localCfg, err := config.ReadLocalConfig(configPath)
+	require.NoError(t, err)
+	instance := localCfg.Instances[0]
+	assert.Equal(t, []string{"JAVA_OPTIONS=-Xmx1g", "FEATURE=on"}, instance.Env)
+	assert.Equal(t, "ci", instance.Network)
+	assert.Equal(t, "9191", instance.GrpcPort)
+	assert.Equal(t, 1.5, instance.CPUs)
+	assert.Equal(t, "2g", instance.Memory)
+
+	// A removed container is recreated with the recorded options.
+	delete(runtime.states, "new-1")
+	require.NoError(t, runLifecycleCommand(NewStartCommand, configPath, "--port", serverURL.Port()))
+	require.Len(t, runtime.created, 2)
+	assert.Equal(t, expected, runtime.created[1])
+	// The network is the user's, not the instance's.
+	assert.Empty(t, runtime.networks)
+}
+
+func TestStartContainerOptionsValidation(t *testing.T) {
+	useFakeRuntime(t, &fakeRuntime{states: map[string]string{}})
+	configPath := filepath.Join(t.TempDir(), "config")
+
+	for _, args := range [][]string{
+		{"--env", "JAVA_OPTIONS"},
+		{"--volume", "./certs"},
+		{"--volume", "./certs:relative"},
+		{"--host-ip", "localhost"},
+		{"--cpus", "-1"},
+		{"--memory", "lots"},
+		{"--network", "ci", "--async", "kafka"},
+	} {
+		err := runLifecycleCommand(NewStartCommand, configPath, args...)
+		assert.Equal(t, errors.KindUsage, errors.KindOf(err), "args %v: %v", args, err)
+	}
+}
+
+func TestStartPortAutoAndContextPerInstance(t *testing.T) {
+	runtime := &fakeRuntime{states: map[string]string{}}
+	useFakeRuntime(t, runtime)
+	configPath := filepath.Join(t.TempDir(), "config")
+
+	require.NoError(t, runLifecycleCommand(NewStartCommand, configPath, "--name", "orders", "--no-wait"))
+	require.NoError(t, runLifecycleCommand(NewStartCommand, configPath, "--name", "billing", "--port", "auto", "--no-wait"))
+
+	require.Len(t, runtime.created, 2)
+	port := runtime.created[1].Port
+	assert.Regexp(t, `^[0-9]+$`, port)
+	assert.NotEqual(t, "8585", port)
+
+	localCfg, err := config.ReadLocalConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "billing", localCfg.CurrentContext)
+	ctx, err := localCfg.ResolveContext("billing")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:"+port, ctx.Server.Server)
+	assert.Equal(t, port, ctx.Instance.Port)
+	ctx, err = localCfg.ResolveContext("orders")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8585", ctx.Server.Server)
+}
+
+func TestStartRefusesPortAndContextConflicts(t *testing.T) {
+	runtime := &fakeRuntime{states: map[string]string{"c1": "running"}}
+	useFakeRuntime(t, runtime)
+	configPath := writeInstancesConfig(t, config.Instance{Name: "orders", ContainerID: "c1", Port: "8585", Status: "Running"})
+	localCfg, err := config.ReadLocalConfig(configPath)
+	require.NoError(t, err)
+	localCfg.Contexts = append(localCfg.Contexts, config.ContextRef{Name: "staging", Server: "https://staging.example.com"})
+	require.NoError(t, config.WriteLocalConfig(*localCfg, configPath))
+
+	err = runLifecycleCommand(NewStartCommand, configPath, "--name", "billing", "--no-wait")
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+	assert.Contains(t, err.Error(), "port 8585 is already recorded for instance 'orders'")
+
+	err = runLifecycleCommand(NewStartCommand, configPath, "--name", "staging", "--port", "auto", "--no-wait")
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+	assert.Contains(t, err.Error(), "context 'staging' already exists")
+	assert.Empty(t, runtime.created)
+}
+
+func TestStartRefusesCompanionPortConflicts(t *testing.T) {
+	runtime := &fakeRuntime{states: map[string]string{"c1": "running"}}
+	useFakeRuntime(t, runtime)
+	configPath := writeInstancesConfig(t, config.Instance{Name: "orders", ContainerID: "c1", Port: "8585", GrpcPort: "9090",
+		Status: "Running", Async: "kafka", BrokerPort: "9092"})
+
+	err := runLifecycleCommand(NewStartCommand, configPath, "--name", "billing", "--port", "auto", "--grpc-port", "9090", "--no-wait")
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+	assert.Contains(t, err.Error(), "port 9090 is already recorded for instance 'orders'")
+
+	err = runLifecycleCommand(NewStartCommand, configPath, "--name", "billing", "--port", "9092", "--no-wait")
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+	assert.Contains(t, err.Error(), "port 9092 is already recorded for instance 'orders'")
+	assert.Empty(t, runtime.created)
+}
+
+func TestStartRecreatesPinnedImage(t *testing.T) {
+	const pinned = "quay.io/microcks/microcks-uber@sha256:0ld"
+	runtime := &fakeRuntime{
+		states:  map[string]string{},
+		digests: map[string]string{"quay.io/microcks/microcks-uber:latest-native": "quay.io/microcks/microcks-uber@sha256:n3w"},
+	}
+	useFakeRuntime(t, runtime)
+	configPath := writeInstancesConfig(t, config.Instance{Name: "microcks", ContainerID: "c1", Port: "8585",
+		Image: "quay.io/microcks/microcks-uber:latest-native", ImageDigest: pinned, Status: "Running"})
+
+	require.NoError(t, runLifecycleCommand(NewStartCommand, configPath, "--no-wait"))
+	require.Len(t, runtime.created, 1)
+	assert.Equal(t, pinned, runtime.created[0].Image)
+
+	localCfg, err := config.ReadLocalConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, pinned, localCfg.Instances[0].ImageDigest)
+}
+
+func TestStartRecreatesOnRecordedDriver(t *testing.T) {
+	runtime := &fakeRuntime{states: map[string]string{}}
+	var drivers []string
+	previous := newContainerClient
+	newContainerClient = func(driver string) (connectors.ContainerClient, error) {
+		drivers = append(drivers, driver)
+		return runtime, nil
+	}
+	t.Cleanup(func() { newContainerClient = previous })
+	configPath := writeInstancesConfig(t, config.Instance{Name: "microcks", ContainerID: "c1", Port: "8585", Status: "Running", Driver: "podman"})
+
+	require.NoError(t, runLifecycleCommand(NewStartCommand, configPath, "--no-wait"))
+	require.Len(t, runtime.created, 1)
+	assert.Equal(t, []string{"podman", "podman"}, drivers)
+
+	localCfg, err := config.ReadLocalConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "podman", localCfg.Instances[0].Driver)
+}
+
+func TestStartRecreatesAsyncCompanions(t *testing.T) {
+	runtime := &fakeRuntime{states: map[string]string{}, networks: map[string]bool{}}
+	useFakeRuntime(t, runtime)
+	server := httptest.NewServer(fakeserver.New())
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	configPath := writeInstancesConfig(t, config.Instance{Name: "microcks", ContainerID: "c1", Port: serverURL.Port(), Status: "Running",
+		Async: "kafka", Network: "microcks-network", BrokerPort: "9092", Companions: []config.Companion{
+			{Role: companionBroker, Name: "microcks-kafka", ContainerID: "b1"},
+			{Role: companionMinion, Name: "microcks-async-minion", ContainerID: "m1"},
+		}})
+
+	err = runLifecycleCommand(NewStartCommand, configPath, "--no-wait")
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+
+	require.NoError(t, runLifecycleCommand(NewStartCommand, configPath))
+	require.Len(t, runtime.created, 3)
+	assert.Equal(t, "apache/kafka:3.9.0", runtime.created[0].Image)
+	assert.Equal(t, "microcks-network", runtime.created[2].Network)
+
+	localCfg, err := config.ReadLocalConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "kafka", localCfg.Instances[0].Async)
+	assert.Len(t, localCfg.Instances[0].Companions, 2)
+}
+
+func TestStartRenamesURLContextAfterInstance(t *testing.T) {
+	useFakeRuntime(t, &fakeRuntime{states: map[string]string{"c1": "exited"}})
+	configPath := writeInstancesConfig(t, config.Instance{Name: "microcks", ContainerID: "c1", Port: "8585", Status: "Exited"})
+
+	require.NoError(t, runLifecycleCommand(NewStartCommand, configPath, "--no-wait"))
+
+	localCfg, err := config.ReadLocalConfig(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "microcks", localCfg.CurrentContext)
+	require.Len(t, localCfg.Contexts, 1)
+	assert.Equal(t, config.ContextRef{Name: "microcks", Server: "http://localhost:8585", User: "http://localhost:8585", Instance: "microcks"}, localCfg.Contexts[0])
+	assert.Len(t, localCfg.Servers, 1)
+}
+
+func TestParseVolume(t *testing.T) {
+	certs, err := filepath.Abs("certs")
+	require.NoError(t, err)
+	cases := map[string]string{
+		"/etc/ssl/certs:/deployments/certs": "/etc/ssl/certs:/deployments/certs",
+		"./certs:/deployments/certs:ro":     certs + ":/deployments/certs:ro",
+		"certs/:/deployments/certs:rw":      certs + ":/deployments/certs:rw",
+		"microcks-data:/deployments/data":   "microcks-data:/deployments/data",
+	}
+	for spec, expected := range cases {
+		bind, err := parseVolume(spec)
+		require.NoError(t, err, spec)
+		assert.Equal(t, expected, bind)
+	}
+}
+
+func TestWatchArtifactsDirReimportsChangedFiles(t *testing.T) {
+	fake := fakeserver.New()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	mc, err := connectors.NewMicrocksClient(server.URL)
+	require.NoError(t, err)
+
+	dir := copySamples(t, map[string]string{"weather-forecast-openapi.yml": "weather-openapi.yml"})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- watchArtifactsDir(ctx, mc, dir) }()
+
+	// The watcher may not be set yet on the first writes: keep saving, less
+	// often than the debounce delay.
+	content, err := os.ReadFile(filepath.Join(dir, "weather-openapi.yml"))
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		if err := os.WriteFile(filepath.Join(dir, "weather-openapi.yml"), content, 0o600); err != nil {
+			return false
+		}
+		return len(fake.Artifacts()) > 0
+	}, 10*time.Second, 500*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+	require.NotEmpty(t, fake.Artifacts())
+	assert.Equal(t, "weather-openapi.yml", fake.Artifacts()[0].Name)
+	assert.Len(t, fake.Services(), 1)
+}
@@ -40,16 +40,24 @@ func NewStopCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
 				return nil
 			}
 
-			containerClient, err := connectors.NewContainerClient(instance.Driver)
+			containerClient, err := newContainerClient(instance.Driver)
 			if err != nil {
 				return errors.Wrap(errors.KindEnvironment, err)
 			}
 			defer containerClient.CloseClient()
 
+			// The async minion goes first, the broker last: each of them
+			// keeps reaching the next one until it is stopped.
+			if err := stopCompanions(containerClient, &instance, companionMinion); err != nil {
+				return errors.Wrap(errors.KindEnvironment, err)
+			}
 			if err := containerClient.StopContainer(instance.ContainerID); err != nil {
 				return errors.Wrap(errors.KindEnvironment, fmt.Errorf("failed to stop container: %w", err))
 			}
 			fmt.Println("")
+			if err := stopCompanions(containerClient, &instance, companionBroker); err != nil {
+				return errors.Wrap(errors.KindEnvironment, err)
+			}
 			log.Printf("Instance %s stopped successfully", instance.Name)
 
 			// update configs
@@ -63,6 +71,9 @@ func NewStopCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
 				_ = localConfig.RemoveUser(ctx.User.Name)
 				_ = localConfig.RemoveAuth(ctx.Server.Server)
 				_ = localConfig.RemoveInstance(instance.Name)
+				if err := tearDownAsync(containerClient, &instance); err != nil {
+					return errors.Wrap(errors.KindEnvironment, err)
+				}
 
 				localConfig.CurrentContext = ""
 				log.Printf("Instance %s removed successfully", instance.Name)
@@ -17,13 +17,13 @@ package cmd
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/microcks/microcks-cli/pkg/config"
 	"github.com/microcks/microcks-cli/pkg/connectors"
 	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/util/duration"
 	"github.com/spf13/cobra"
 )
 
@@ -33,7 +33,8 @@ var (
 
 func NewTestCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
 	var (
-		waitFor            string
+		waitFor            = duration.Value(5 * time.Second)
+		pollTimeout        duration.Value
 		secretName         string
 		filteredOperations string
 		operationsHeaders  string
@@ -41,9 +42,13 @@ func NewTestCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
 		dryRun             bool
 		artifact           string
 		image              string
-		readyTimeout       time.Duration
+		readyTimeout       = duration.Value(90 * time.Second)
 		watch              bool
 		driver             string
+		gateOpts           gateFlags
+		maxResponseTime    duration.Value
+		coverage           bool
+		minScore           float64
 	)
 	var testCmd = &cobra.Command{
 
@@ -71,36 +76,33 @@ func NewTestCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
 				return errors.Wrapf(errors.KindUsage, "<runner> should be one of: HTTP, SOAP_HTTP, SOAP_UI, POSTMAN, OPEN_API_SCHEMA, ASYNC_API_SCHEMA, GRPC_PROTOBUF, GRAPHQL_SCHEMA")
 			}
 
-			// Validate presence and values of flags.
-			if !strings.HasSuffix(waitFor, "milli") && !strings.HasSuffix(waitFor, "sec") && !strings.HasSuffix(waitFor, "min") {
-				return errors.Wrapf(errors.KindUsage, "--waitFor format is wrong. Accepted units are: milli, sec, min (e.g. 500milli, 30sec, 5min)")
-			}
-
 			// Collect optional HTTPS transport flags.
 			config.InsecureTLS = globalClientOpts.InsecureTLS
 			config.CaCertPaths = globalClientOpts.CaCertPaths
 			config.Verbose = globalClientOpts.Verbose
 
-			// Compute time to wait in milliseconds.
-			var waitForMilliseconds int64
-			if strings.HasSuffix(waitFor, "milli") {
-				n, err := strconv.ParseInt(waitFor[:len(waitFor)-5], 0, 64)
-				if err != nil {
-					return errors.Wrapf(errors.KindUsage, "--waitFor value %q is not a valid number", waitFor)
-				}
-				waitForMilliseconds = n
-			} else if strings.HasSuffix(waitFor, "sec") {
-				n, err := strconv.ParseInt(waitFor[:len(waitFor)-3], 0, 64)
-				if err != nil {
-					return errors.Wrapf(errors.KindUsage, "--waitFor value %q is not a valid number", waitFor)
-				}
-				waitForMilliseconds = n * 1000
-			} else if strings.HasSuffix(waitFor, "min") {
-				n, err := strconv.ParseInt(waitFor[:len(waitFor)-3], 0, 64)
-				if err != nil {
-					return errors.Wrapf(errors.KindUsage, "--waitFor value %q is not a valid number", waitFor)
-				}
-				waitForMilliseconds = n * 60 * 1000
+			// Parse structured flags upfront so a typo fails the command
+			// instead of silently running the test without them.
+			operations, err := connectors.ParseFilteredOperations(filteredOperations)
+			if err != nil {
+				return err
+			}
+			headers, err := connectors.ParseOperationsHeaders(operationsHeaders)
+			if err != nil {
+				return err
+			}
+			oContext, err := connectors.ParseOAuth2Context(oAuth2Context)
+			if err != nil {
+				return err
+			}
+			gateOpts.maxResponseTime = maxResponseTime.Duration()
+			gatePolicy, err := buildGatePolicy(cmd, gateOpts)
+			if err != nil {
+				return err
+			}
+			threshold, err := minScoreFlag(cmd, minScore)
+			if err != nil {
+				return err
 			}
 
 			params := testParams{
@@ -108,10 +110,14 @@ func NewTestCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
 				testEndpoint:       testEndpoint,
 				runnerType:         runnerType,
 				secretName:         secretName,
-				waitForMillis:      waitForMilliseconds,
-				filteredOperations: filteredOperations,
-				operationsHeaders:  operationsHeaders,
-				oAuth2Context:      oAuth2Context,
+				waitFor:            waitFor.Duration(),
+				pollTimeout:        pollTimeout.Duration(),
+				filteredOperations: operations,
+				operationsHeaders:  headers,
+				oAuth2Context:      oContext,
+				gate:               gatePolicy,
+				coverage:           coverage || threshold != nil,
+				minScore:           threshold,
 			}
 
 			if !dryRun {
@@ -131,100 +137,50 @@ func NewTestCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
 				return runDryRunTest(dryRunOptions{
 					artifact:     artifact,
 					image:        image,
-					readyTimeout: readyTimeout,
+					readyTimeout: readyTimeout.Duration(),
 					watch:        watch,
 					driver:       driver,
 					params:       params,
 				})
 			}
 
-			var mc connectors.MicrocksClient
-			var serverAddr string
-
-			if globalClientOpts.ServerAddr != "" && globalClientOpts.ClientId != "" && globalClientOpts.ClientSecret != "" {
-
-				// create client with server address
-				serverAddr = globalClientOpts.ServerAddr
-				var err error
-				mc, err = connectors.NewMicrocksClient(serverAddr)
-				if err != nil {
-					return err
-				}
-
-				keycloakURL, err := mc.GetKeycloakURL()
-				if err != nil {
-					return err
-				}
-
-				oauthToken := "unauthenticated-token"
-				if keycloakURL != "null" {
-					// If Keycloak is enabled, retrieve an OAuth token using Keycloak Client.
-					kc, err := connectors.NewKeycloakClient(keycloakURL, globalClientOpts.ClientId, globalClientOpts.ClientSecret)
-					if err != nil {
-						return err
-					}
-
-					oauthToken, err = kc.ConnectAndGetToken()
-					if err != nil {
-						return err
-					}
-				}
-
-				// Then - launch the test on Microcks Server.
-				mc.SetOAuthToken(oauthToken)
-
-			} else {
-				localConfig, err := config.ReadLocalConfig(globalClientOpts.ConfigPath)
-				if err != nil {
-					return err
-				}
-
-				if localConfig == nil {
-					return errors.Wrapf(errors.KindUsage, "please login to perform this operation")
-				}
-
-				if globalClientOpts.Context == "" {
-					globalClientOpts.Context = localConfig.CurrentContext
-				}
-
-				mc, err = connectors.NewClient(*globalClientOpts)
-				if err != nil {
-					return err
-				}
-
-				ctx, err := localConfig.ResolveContext(globalClientOpts.Context)
-				if err != nil {
-					return errors.Wrap(errors.KindNotFound, err)
-				}
-
-				serverAddr = ctx.Server.Server
+			mc, serverAddr, err := newMicrocksClientFromOptions(globalClientOpts)
+			if err != nil {
+				return err
 			}
 
-			success, testResultID, err := runTestAndWait(mc, params)
+			result, err := runTestAndWait(mc, params)
 			if err != nil {
 				return err
 			}
 
-			fmt.Printf("Full TestResult details are available here: %s/#/tests/%s \n", serverAddr, testResultID)
+			fmt.Printf("Full TestResult details are available here: %s/#/tests/%s \n", serverAddr, result.ID)
 
-			if !success {
-				return errors.ErrTestFailed
-			}
-			return nil
+			return testOutcome(mc, params, result)
 		},
 	}
 
-	testCmd.Flags().StringVar(&waitFor, "waitFor", "5sec", "Time to wait for test to finish")
+	testCmd.Flags().Var(&waitFor, "waitFor", "Time the server waits for test to finish, e.g. 30sec, 90s, PT2M")
+	testCmd.Flags().Var(&pollTimeout, "poll-timeout", "Overall deadline for polling the test result (default: --waitFor plus 10s)")
 	testCmd.Flags().StringVar(&secretName, "secretName", "", "Secret to use for connecting test endpoint")
-	testCmd.Flags().StringVar(&filteredOperations, "filteredOperations", "", "List of operations to launch a test for")
-	testCmd.Flags().StringVar(&operationsHeaders, "operationsHeaders", "", "Override of operations headers as JSON string")
-	testCmd.Flags().StringVar(&oAuth2Context, "oAuth2Context", "", "Spec of an OAuth2 client context as JSON string")
+	testCmd.Flags().StringVar(&filteredOperations, "filteredOperations", "", "List of operations to launch a test for, as JSON/YAML or @file")
+	testCmd.Flags().StringVar(&operationsHeaders, "operationsHeaders", "", "Override of operations headers, as JSON/YAML or @file")
+	testCmd.Flags().StringVar(&oAuth2Context, "oAuth2Context", "", "Spec of an OAuth2 client context, as JSON/YAML or @file")
+	testCmd.Flags().StringVar(&gateOpts.file, "gate-file", "", "YAML quality gate policy: minPassRate, maxResponseTime, responseTimes, allowedFailures")
+	testCmd.Flags().Float64Var(&gateOpts.minPassRate, "min-pass-rate", 100, "Minimum percentage of passing operations for the quality gate")
+	testCmd.Flags().StringArrayVar(&gateOpts.allowFailures, "allow-failure", nil, "Operation whose failure is tolerated, optionally until a date: 'POST /pets@2026-12-31' (repeatable)")
+	testCmd.Flags().Var(&maxResponseTime, "max-response-time", "Maximum elapsed time of each operation for the quality gate, e.g. 500ms")
+	testCmd.Flags().BoolVar(&coverage, "coverage", false, "Print operation coverage and the conformance index after the test")
+	testCmd.Flags().Float64Var(&minScore, "min-score", 0, "Minimum conformance index (0-100) after the test; implies --coverage")
 	testCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Run the test against an ephemeral local Microcks container instead of a server")
 	testCmd.Flags().StringVar(&artifact, "artifact", "", "Local spec file to import on the ephemeral server (required with --dry-run)")
 	testCmd.Flags().StringVar(&image, "image", defaultDryRunImage, "Microcks uber-native image used for --dry-run")
-	testCmd.Flags().DurationVar(&readyTimeout, "ready-timeout", 90*time.Second, "How long to wait for the ephemeral container to be ready (--dry-run only)")
+	testCmd.Flags().Var(&readyTimeout, "ready-timeout", "How long to wait for the ephemeral container to be ready (--dry-run only)")
 	testCmd.Flags().BoolVar(&watch, "watch", false, "Watch the artifact file and re-run the test on change (--dry-run only)")
 	testCmd.Flags().StringVar(&driver, "driver", "", "Container runtime for --dry-run: 'docker' or 'podman' (default: auto-detect)")
 
+	testCmd.AddCommand(NewTestHistoryCommand(globalClientOpts))
+	testCmd.AddCommand(NewTestDiffCommand(globalClientOpts))
+
 	return testCmd
 }
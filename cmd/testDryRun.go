@@ -196,18 +196,15 @@ func runDryRunTest(opts dryRunOptions) error {
 	}
 	mc.SetOAuthToken("unauthenticated-token")
 
-	success, testResultID, err := runTestAndWait(mc, opts.params)
+	result, err := runTestAndWait(mc, opts.params)
 	if err != nil {
 		return err
 	}
 
 	if !opts.watch {
-		if success {
-			return nil
-		}
-		return errors.ErrTestFailed
+		return testOutcome(mc, opts.params, result)
 	}
-	printDetailsLink(endpoint, testResultID)
+	printDetailsLink(endpoint, result.ID)
 	return watchAndRerun(ctx, mc, endpoint, opts)
 }
 
@@ -285,13 +282,13 @@ func watchAndRerun(ctx context.Context, mc connectors.MicrocksClient, serverAddr
 				fmt.Printf("Re-import failed, waiting for next change: %s\n", err)
 				continue
 			}
-			success, testResultID, err := runTestAndWait(mc, opts.params)
+			result, err := runTestAndWait(mc, opts.params)
 			if err != nil {
 				fmt.Printf("Test run failed, waiting for next change: %s\n", err)
 				continue
 			}
-			printDetailsLink(serverAddr, testResultID)
-			if success {
+			printDetailsLink(serverAddr, result.ID)
+			if testOutcome(mc, opts.params, result) == nil {
 				fmt.Println("Contract test PASSED — waiting for next change.")
 			} else {
 				fmt.Println("Contract test FAILED — waiting for next change.")
@@ -17,9 +17,11 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/gate"
 )
 
 // testParams bundles the inputs needed to launch and poll a Microcks test.
@@ -29,49 +31,91 @@ type testParams struct {
 	testEndpoint       string
 	runnerType         string
 	secretName         string
-	waitForMillis      int64
-	filteredOperations string
-	operationsHeaders  string
-	oAuth2Context      string
+	waitFor            time.Duration
+	pollTimeout        time.Duration
+	filteredOperations []string
+	operationsHeaders  map[string][]connectors.HeaderDTO
+	oAuth2Context      *connectors.OAuth2ClientContext
+	gate               *gate.Policy
+	coverage           bool
+	minScore           *float64
 }
 
 // runTestAndWait creates a test on the Microcks server and polls its result
-// until completion or timeout. Shared by the regular and --dry-run paths.
-func runTestAndWait(mc connectors.MicrocksClient, params testParams) (bool, string, error) {
+// until completion or timeout, rendering operations as they complete. Shared
+// by the regular and --dry-run paths. The last known result is returned; it
+// is still InProgress when the poll deadline was hit.
+func runTestAndWait(mc connectors.MicrocksClient, params testParams) (*connectors.TestResultSummary, error) {
+	// Operation names are only known server-side: reject typos before the
+	// test starts rather than silently testing nothing.
+	if len(params.filteredOperations) > 0 || len(params.operationsHeaders) > 0 {
+		service, err := mc.GetService(params.serviceRef)
+		if err != nil {
+			return nil, err
+		}
+		if err := connectors.CheckTestOperations(service, params.filteredOperations, params.operationsHeaders); err != nil {
+			return nil, err
+		}
+	}
+
 	testResultID, err := mc.CreateTestResult(params.serviceRef, params.testEndpoint, params.runnerType, params.secretName,
-		params.waitForMillis, params.filteredOperations, params.operationsHeaders, params.oAuth2Context)
+		params.waitFor.Milliseconds(), params.filteredOperations, params.operationsHeaders, params.oAuth2Context)
 	if err != nil {
-		return false, "", fmt.Errorf("creating test: %w", err)
+		return nil, fmt.Errorf("creating test: %w", err)
 	}
 
-	// Finally - wait before checking and loop for some time
-	time.Sleep(1 * time.Second)
-
-	// Add 10.000ms to wait time as it's now representing the server timeout.
-	now := nowInMilliseconds()
-	future := now + params.waitForMillis + 10000
+	// waitFor is the server-side timeout: unless an explicit poll deadline is
+	// given, allow 10 more seconds for the server to report the result.
+	pollTimeout := params.pollTimeout
+	if pollTimeout == 0 {
+		pollTimeout = params.waitFor + 10*time.Second
+	}
+	deadline := time.Now().Add(pollTimeout)
 
-	var success = false
-	for nowInMilliseconds() < future {
-		testResultSummary, err := mc.GetTestResult(testResultID)
+	progress := newTestProgress(os.Stdout)
+	backoff := pollBackoff{}
+	for {
+		result, err := mc.GetTestResult(testResultID)
 		if err != nil {
-			return false, "", fmt.Errorf("checking test result: %w", err)
+			return nil, fmt.Errorf("checking test result: %w", err)
 		}
-		success = testResultSummary.Success
-		inProgress := testResultSummary.InProgress
-		fmt.Printf("MicrocksClient got status for test \"%s\" - success: %s, inProgress: %s \n", testResultID, fmt.Sprint(success), fmt.Sprint(inProgress))
-
-		if !inProgress {
-			break
+		if result.ID == "" {
+			result.ID = testResultID
+		}
+		if !result.InProgress {
+			progress.Done(result, false)
+			return result, nil
 		}
+		progress.Update(result)
 
-		fmt.Println("MicrocksTester waiting for 2 seconds before checking again or exiting.")
-		time.Sleep(2 * time.Second)
+		wait := backoff.next(result)
+		if time.Now().Add(wait).After(deadline) {
+			progress.Done(result, true)
+			return result, nil
+		}
+		time.Sleep(wait)
 	}
-
-	return success, testResultID, nil
 }
 
-func nowInMilliseconds() int64 {
-	return time.Now().UnixNano() / int64(time.Millisecond)
+// testOutcome reports the quality gate and, if asked, the coverage of a
+// completed test, and returns the command outcome.
+func testOutcome(mc connectors.MicrocksClient, params testParams, result *connectors.TestResultSummary) error {
+	outcome := checkTestOutcome(result, params.gate)
+	if !params.coverage {
+		return outcome
+	}
+
+	view, err := mc.GetServiceView(params.serviceRef)
+	if err != nil {
+		return err
+	}
+	report, err := fetchCoverageReport(mc, view, result)
+	if err != nil {
+		return err
+	}
+	printCoverageReport(os.Stdout, report)
+	if err := checkMinScore(report, params.minScore); err != nil {
+		return err
+	}
+	return outcome
 }
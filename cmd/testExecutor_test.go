@@ -0,0 +1,95 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/fakeserver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunTestAndWaitAgainstFakeServer(t *testing.T) {
+	fake := fakeserver.New()
+	fake.AddService(fakeserver.Service{Name: "Orders", Version: "1.0", Operations: []fakeserver.Operation{{Name: "GET /orders", Method: "GET"}}})
+	fake.SetTestOutcome("Orders:1.0", fakeserver.TestOutcome{Success: false})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	mc, err := connectors.NewMicrocksClient(server.URL)
+	require.NoError(t, err)
+
+	result, err := runTestAndWait(mc, testParams{
+		serviceRef:   "Orders:1.0",
+		testEndpoint: "http://localhost:9000",
+		runnerType:   "HTTP",
+		waitFor:      time.Second,
+	})
+	require.NoError(t, err)
+	require.False(t, result.Success)
+	require.NotEmpty(t, result.ID)
+
+	runs := fake.TestRuns()
+	require.Len(t, runs, 1)
+	require.Equal(t, int64(1000), runs[0].Timeout)
+}
+
+func TestRunTestAndWaitRejectsUnknownOperations(t *testing.T) {
+	fake := fakeserver.New()
+	fake.AddService(fakeserver.Service{Name: "Orders", Version: "1.0", Operations: []fakeserver.Operation{{Name: "GET /orders", Method: "GET"}}})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	mc, err := connectors.NewMicrocksClient(server.URL)
+	require.NoError(t, err)
+
+	_, err = runTestAndWait(mc, testParams{
+		serviceRef:         "Orders:1.0",
+		testEndpoint:       "http://localhost:9000",
+		runnerType:         "HTTP",
+		filteredOperations: []string{"GET /order"},
+	})
+	require.Error(t, err)
+	require.Equal(t, errors.KindUsage, errors.KindOf(err))
+	require.Empty(t, fake.TestRuns())
+}
+
+func TestQualityGateToleratesAllowedFailures(t *testing.T) {
+	fake := fakeserver.New()
+	fake.AddService(fakeserver.Service{Name: "Orders", Version: "1.0", Operations: []fakeserver.Operation{
+		{Name: "GET /orders", Method: "GET"},
+		{Name: "POST /orders", Method: "POST"},
+	}})
+	fake.SetTestOutcome("Orders:1.0", fakeserver.TestOutcome{Success: false, FailedOperations: []string{"POST /orders"}})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	mc, err := connectors.NewMicrocksClient(server.URL)
+	require.NoError(t, err)
+
+	policy, err := buildGatePolicy(NewTestCommand(&connectors.ClientOptions{}), gateFlags{allowFailures: []string{"POST /orders"}})
+	require.NoError(t, err)
+
+	result, err := runTestAndWait(mc, testParams{serviceRef: "Orders:1.0", testEndpoint: "http://localhost:9000", runnerType: "HTTP", waitFor: time.Second})
+	require.NoError(t, err)
+	require.False(t, result.Success)
+	require.ErrorIs(t, checkTestOutcome(result, nil), errors.ErrTestFailed)
+	require.NoError(t, checkTestOutcome(result, policy))
+}
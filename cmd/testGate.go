@@ -0,0 +1,117 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/gate"
+	"github.com/spf13/cobra"
+)
+
+// gateFlags holds the quality gate flags of the test command.
+type gateFlags struct {
+	file            string
+	minPassRate     float64
+	allowFailures   []string
+	maxResponseTime time.Duration
+}
+
+// buildGatePolicy merges the gate file with the gate flags, flags winning.
+// It returns nil when no gate is configured, keeping the all-or-nothing
+// behavior.
+func buildGatePolicy(cmd *cobra.Command, flags gateFlags) (*gate.Policy, error) {
+	var policy *gate.Policy
+	if flags.file != "" {
+		var err error
+		if policy, err = gate.LoadFile(flags.file); err != nil {
+			return nil, err
+		}
+	}
+
+	minPassRateSet := cmd.Flags().Changed("min-pass-rate")
+	if policy == nil && !minPassRateSet && len(flags.allowFailures) == 0 && flags.maxResponseTime == 0 {
+		return nil, nil
+	}
+	if policy == nil {
+		policy = &gate.Policy{}
+	}
+
+	if minPassRateSet {
+		minPassRate := flags.minPassRate
+		policy.MinPassRate = &minPassRate
+	}
+	if flags.maxResponseTime != 0 {
+		policy.MaxResponseTime = flags.maxResponseTime
+	}
+	for _, value := range flags.allowFailures {
+		allowed, err := gate.ParseAllowedFailure(value)
+		if err != nil {
+			return nil, err
+		}
+		policy.AllowedFailures = append(policy.AllowedFailures, allowed)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// checkTestOutcome turns a test result into the command outcome: the raw
+// result when there is no gate, the gate outcome otherwise.
+func checkTestOutcome(result *connectors.TestResultSummary, policy *gate.Policy) error {
+	if policy == nil {
+		if result.Success {
+			return nil
+		}
+		return errors.ErrTestFailed
+	}
+
+	report := policy.Evaluate(result, time.Now())
+	printGateReport(os.Stdout, report)
+	if !report.Passed {
+		return errors.ErrTestFailed
+	}
+	return nil
+}
+
+func printGateReport(out io.Writer, report *gate.Report) {
+	switch {
+	case report.Incomplete:
+		fmt.Fprintf(out, "Quality gate FAILED — the test did not complete\n")
+	case report.Passed:
+		fmt.Fprintf(out, "Quality gate PASSED — %s\n", formatPassRate(report))
+	default:
+		fmt.Fprintf(out, "Quality gate FAILED — %s\n", formatPassRate(report))
+	}
+	for _, f := range report.Tolerated {
+		fmt.Fprintf(out, "  ~ %s %s\n", f.Operation, f.Message)
+	}
+	for _, f := range report.Violations {
+		fmt.Fprintf(out, "  ! %s %s\n", f.Operation, f.Message)
+	}
+}
+
+func formatPassRate(report *gate.Report) string {
+	counted := report.Operations - len(report.Tolerated)
+	return fmt.Sprintf("%d/%d operations passed (%.1f%%, minimum %.1f%%), %d tolerated",
+		report.PassedCount, counted, report.PassRate, report.MinPassRate, len(report.Tolerated))
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/gate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildGatePolicyWithoutGate(t *testing.T) {
+	policy, err := buildGatePolicy(NewTestCommand(&connectors.ClientOptions{}), gateFlags{minPassRate: 100})
+	require.NoError(t, err)
+	assert.Nil(t, policy)
+}
+
+func TestBuildGatePolicyFlagsOverrideFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gate.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("minPassRate: 90\nmaxResponseTime: 1s\nallowedFailures:\n  - operation: GET /a\n"), 0o600))
+
+	cmd := NewTestCommand(&connectors.ClientOptions{})
+	require.NoError(t, cmd.Flags().Parse([]string{"--min-pass-rate", "75"}))
+	policy, err := buildGatePolicy(cmd, gateFlags{file: path, minPassRate: 75, allowFailures: []string{"GET /b@2026-12-31"}, maxResponseTime: 200 * time.Millisecond})
+	require.NoError(t, err)
+	assert.Equal(t, 75.0, *policy.MinPassRate)
+	assert.Equal(t, 200*time.Millisecond, policy.MaxResponseTime)
+	require.Len(t, policy.AllowedFailures, 2)
+	assert.Equal(t, "GET /b", policy.AllowedFailures[1].Operation)
+
+	require.NoError(t, cmd.Flags().Parse([]string{"--min-pass-rate", "101"}))
+	_, err = buildGatePolicy(cmd, gateFlags{minPassRate: 101})
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+}
+
+func TestPrintGateReport(t *testing.T) {
+	var out bytes.Buffer
+	printGateReport(&out, &gate.Report{
+		Passed: true, Operations: 3, PassedCount: 2, PassRate: 100, MinPassRate: 80,
+		Tolerated: []gate.Finding{{Operation: "POST /pets", Message: "failure tolerated until 2026-12-31"}},
+	})
+	assert.Equal(t, "Quality gate PASSED — 2/2 operations passed (100.0%, minimum 80.0%), 1 tolerated\n"+
+		"  ~ POST /pets failure tolerated until 2026-12-31\n", out.String())
+}
@@ -0,0 +1,245 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// Timing changes smaller than this are noise, whatever their percentage.
+const minTimingChange = 10
+
+func NewTestHistoryCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var limit int
+
+	var historyCmd = &cobra.Command{
+		Use:   "history <apiName:apiVersion>",
+		Short: "List previous test runs of an API",
+		Long:  `List previous test runs of an API, most recent first`,
+		Example: `# List the last 20 test runs of the petstore API
+microcks test history petstore:2.0.0
+
+# Only the last 5 runs
+microcks test history petstore:2.0.0 --limit 5`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if limit <= 0 {
+				return errors.Wrapf(errors.KindUsage, "--limit must be greater than 0")
+			}
+
+			mc, _, err := newMicrocksClientFromOptions(globalClientOpts)
+			if err != nil {
+				return err
+			}
+
+			service, err := mc.GetService(args[0])
+			if err != nil {
+				return err
+			}
+			results, err := mc.ListTestResults(service.ID, 0, limit)
+			if err != nil {
+				return err
+			}
+
+			if len(results) == 0 {
+				fmt.Printf("No test found for %s:%s\n", service.Name, service.Version)
+				return nil
+			}
+			printTestHistory(os.Stdout, results)
+			return nil
+		},
+	}
+
+	historyCmd.Flags().IntVar(&limit, "limit", 20, "Maximum number of test runs to list")
+
+	return historyCmd
+}
+
+func NewTestDiffCommand(globalClientOpts *connectors.ClientOptions) *cobra.Command {
+	var (
+		threshold int
+		all       bool
+	)
+
+	var diffCmd = &cobra.Command{
+		Use:   "diff <testId1> <testId2>",
+		Short: "Compare the operations of two test runs",
+		Long:  `Compare two test runs and show the operations whose status or timing changed between them`,
+		Example: `# What changed since the previous run?
+microcks test diff 6617d5c3e4b0a1 6618a2b7f0c9e4
+
+# Also list unchanged operations, and flag timing changes from 50%
+microcks test diff 6617d5c3e4b0a1 6618a2b7f0c9e4 --all --timing-threshold 50`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if threshold < 0 {
+				return errors.Wrapf(errors.KindUsage, "--timing-threshold must not be negative")
+			}
+
+			mc, _, err := newMicrocksClientFromOptions(globalClientOpts)
+			if err != nil {
+				return err
+			}
+
+			before, err := mc.GetTestResult(args[0])
+			if err != nil {
+				return err
+			}
+			after, err := mc.GetTestResult(args[1])
+			if err != nil {
+				return err
+			}
+
+			if before.ServiceID != after.ServiceID {
+				fmt.Fprintf(os.Stderr, "Warning: tests %s and %s are for different services\n", before.ID, after.ID)
+			}
+			printTestDiff(os.Stdout, before, after, diffTestResults(before, after, threshold), all)
+			return nil
+		},
+	}
+
+	diffCmd.Flags().IntVar(&threshold, "timing-threshold", 20, "Minimum elapsed time change, in percent, reported as slower or faster")
+	diffCmd.Flags().BoolVar(&all, "all", false, "Also list operations that did not change")
+
+	return diffCmd
+}
+
+// operationChange compares one operation across two test runs. Before or
+// After is nil when the operation was only tested in one of them.
+type operationChange struct {
+	Operation string
+	Before    *connectors.TestCaseResult
+	After     *connectors.TestCaseResult
+	Change    string
+}
+
+// diffTestResults pairs the operations of two runs, in the order they were
+// tested. Change is empty for operations with the same status and a timing
+// change under threshold percent.
+func diffTestResults(before, after *connectors.TestResultSummary, threshold int) []operationChange {
+	var changes []operationChange
+	index := map[string]int{}
+	for i := range before.TestCaseResults {
+		tc := &before.TestCaseResults[i]
+		index[tc.OperationName] = len(changes)
+		changes = append(changes, operationChange{Operation: tc.OperationName, Before: tc, Change: "removed"})
+	}
+	for i := range after.TestCaseResults {
+		tc := &after.TestCaseResults[i]
+		j, ok := index[tc.OperationName]
+		if !ok {
+			changes = append(changes, operationChange{Operation: tc.OperationName, After: tc, Change: "added"})
+			continue
+		}
+		changes[j].After = tc
+		changes[j].Change = compareTestCases(changes[j].Before, tc, threshold)
+	}
+	return changes
+}
+
+func compareTestCases(before, after *connectors.TestCaseResult, threshold int) string {
+	switch {
+	case before.Success && !after.Success:
+		return "regressed"
+	case !before.Success && after.Success:
+		return "fixed"
+	}
+
+	delta := int64(after.ElapsedTime) - int64(before.ElapsedTime)
+	if before.ElapsedTime <= 0 || max(delta, -delta) < minTimingChange {
+		return ""
+	}
+	percent := delta * 100 / int64(before.ElapsedTime)
+	switch {
+	case percent >= int64(threshold):
+		return fmt.Sprintf("slower (+%d%%)", percent)
+	case -percent >= int64(threshold):
+		return fmt.Sprintf("faster (%d%%)", percent)
+	}
+	return ""
+}
+
+func printTestHistory(out io.Writer, results []connectors.TestResultSummary) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tID\tDATE\tENDPOINT\tRUNNER\tRESULT\tDURATION")
+	for _, r := range results {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%d ms\n",
+			r.TestNumber, r.ID, formatTestDate(r.TestDate), r.TestedEndpoint, r.RunnerType, formatTestStatus(&r), r.ElapsedTime)
+	}
+	w.Flush()
+}
+
+func printTestDiff(out io.Writer, before, after *connectors.TestResultSummary, changes []operationChange, all bool) {
+	fmt.Fprintf(out, "Comparing test %s (%s, %s) with test %s (%s, %s)\n",
+		before.ID, formatTestDate(before.TestDate), formatTestStatus(before),
+		after.ID, formatTestDate(after.TestDate), formatTestStatus(after))
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "OPERATION\tBEFORE\tAFTER\tCHANGE")
+	changed := 0
+	for _, c := range changes {
+		if c.Change != "" {
+			changed++
+		} else if !all {
+			continue
+		}
+		change := c.Change
+		if change == "" {
+			change = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Operation, formatTestCaseStatus(c.Before), formatTestCaseStatus(c.After), change)
+	}
+
+	if changed == 0 && !all {
+		fmt.Fprintln(out, "No operation changed status or timing")
+		return
+	}
+	w.Flush()
+}
+
+func formatTestDate(millis int64) string {
+	return time.UnixMilli(millis).Format("2006-01-02 15:04:05")
+}
+
+func formatTestStatus(result *connectors.TestResultSummary) string {
+	switch {
+	case result.InProgress:
+		return "IN PROGRESS"
+	case result.Success:
+		return "PASSED"
+	default:
+		return "FAILED"
+	}
+}
+
+func formatTestCaseStatus(tc *connectors.TestCaseResult) string {
+	switch {
+	case tc == nil:
+		return "-"
+	case tc.Success:
+		return fmt.Sprintf("✓ %d ms", tc.ElapsedTime)
+	default:
+		return fmt.Sprintf("✗ %d ms", tc.ElapsedTime)
+	}
+}
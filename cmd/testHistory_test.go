@@ -0,0 +1,81 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffTestResults(t *testing.T) {
+	before := &connectors.TestResultSummary{ID: "t1", TestCaseResults: []connectors.TestCaseResult{
+		{OperationName: "GET /orders", Success: true, ElapsedTime: 100},
+		{OperationName: "POST /orders", Success: true, ElapsedTime: 50},
+		{OperationName: "DELETE /orders", Success: false, ElapsedTime: 10},
+		{OperationName: "GET /orders/{id}", Success: true, ElapsedTime: 5},
+		{OperationName: "PUT /orders/{id}", Success: true, ElapsedTime: 20},
+	}}
+	after := &connectors.TestResultSummary{ID: "t2", TestCaseResults: []connectors.TestCaseResult{
+		{OperationName: "GET /orders", Success: true, ElapsedTime: 300},
+		{OperationName: "POST /orders", Success: false, ElapsedTime: 50},
+		{OperationName: "DELETE /orders", Success: true, ElapsedTime: 10},
+		{OperationName: "GET /orders/{id}", Success: true, ElapsedTime: 12},
+		{OperationName: "PATCH /orders/{id}", Success: true, ElapsedTime: 20},
+	}}
+
+	changes := diffTestResults(before, after, 20)
+	got := map[string]string{}
+	for _, c := range changes {
+		got[c.Operation] = c.Change
+	}
+	assert.Equal(t, map[string]string{
+		"GET /orders":        "slower (+200%)",
+		"POST /orders":       "regressed",
+		"DELETE /orders":     "fixed",
+		"GET /orders/{id}":   "", // +140% but only 7ms: noise.
+		"PUT /orders/{id}":   "removed",
+		"PATCH /orders/{id}": "added",
+	}, got)
+	require.Len(t, changes, 6)
+	assert.Equal(t, "PATCH /orders/{id}", changes[5].Operation)
+}
+
+func TestPrintTestDiffOnlyListsChanges(t *testing.T) {
+	before := &connectors.TestResultSummary{ID: "t1", Success: true, TestCaseResults: []connectors.TestCaseResult{
+		{OperationName: "GET /orders", Success: true, ElapsedTime: 100},
+		{OperationName: "POST /orders", Success: true, ElapsedTime: 50},
+	}}
+	after := &connectors.TestResultSummary{ID: "t2", TestCaseResults: []connectors.TestCaseResult{
+		{OperationName: "GET /orders", Success: true, ElapsedTime: 100},
+		{OperationName: "POST /orders", Success: false, ElapsedTime: 40},
+	}}
+
+	var out bytes.Buffer
+	printTestDiff(&out, before, after, diffTestResults(before, after, 20), false)
+	assert.Contains(t, out.String(), "PASSED) with test t2")
+	assert.Contains(t, out.String(), "FAILED)")
+	assert.Regexp(t, `POST /orders\s+✓ 50 ms\s+✗ 40 ms\s+regressed`, out.String())
+	assert.NotContains(t, out.String(), "GET /orders")
+
+	out.Reset()
+	printTestDiff(&out, before, before, diffTestResults(before, before, 20), false)
+	assert.True(t, strings.HasSuffix(out.String(), "No operation changed status or timing\n"))
+}
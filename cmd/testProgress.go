@@ -0,0 +1,174 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"golang.org/x/term"
+)
+
+const (
+	minPollInterval = 500 * time.Millisecond
+	maxPollInterval = 5 * time.Second
+)
+
+// testProgress renders a running test as its operations complete. Update is
+// called on every poll, Done once with the final (or last known) result.
+type testProgress interface {
+	Update(result *connectors.TestResultSummary)
+	Done(result *connectors.TestResultSummary, timedOut bool)
+}
+
+// newTestProgress picks a compact redrawing view for interactive terminals
+// and append-only lines everywhere else (CI logs, pipes, files).
+func newTestProgress(out *os.File) testProgress {
+	if term.IsTerminal(int(out.Fd())) && os.Getenv("CI") == "" {
+		return &ttyProgress{out: out}
+	}
+	return &plainProgress{out: out, reported: map[string]bool{}}
+}
+
+// pollBackoff grows the delay between polls while nothing changes and
+// resets it as soon as an operation completes.
+type pollBackoff struct {
+	interval  time.Duration
+	completed int
+}
+
+func (b *pollBackoff) next(result *connectors.TestResultSummary) time.Duration {
+	switch {
+	case b.interval == 0 || len(result.TestCaseResults) > b.completed:
+		b.interval = minPollInterval
+	default:
+		b.interval = min(b.interval*3/2, maxPollInterval)
+	}
+	b.completed = len(result.TestCaseResults)
+	return b.interval
+}
+
+// plainProgress prints one line per completed operation, never repeating
+// itself, so logs stay readable.
+type plainProgress struct {
+	out      io.Writer
+	started  bool
+	reported map[string]bool
+}
+
+func (p *plainProgress) Update(result *connectors.TestResultSummary) {
+	if !p.started {
+		fmt.Fprintf(p.out, "Test %s started on %s\n", result.ID, result.TestedEndpoint)
+		p.started = true
+	}
+	for _, tc := range result.TestCaseResults {
+		if !p.reported[tc.OperationName] {
+			fmt.Fprintln(p.out, formatTestCase(tc))
+			p.reported[tc.OperationName] = true
+		}
+	}
+}
+
+func (p *plainProgress) Done(result *connectors.TestResultSummary, timedOut bool) {
+	p.Update(result)
+	fmt.Fprintln(p.out, formatTestSummary(result, timedOut))
+}
+
+// ttyProgress redraws a status line and the completed operations in place.
+type ttyProgress struct {
+	out   io.Writer
+	lines int
+	frame int
+}
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+func (p *ttyProgress) Update(result *connectors.TestResultSummary) {
+	passed, failed := countTestCases(result)
+	status := fmt.Sprintf("%s Testing %s — %d passed, %d failed", spinnerFrames[p.frame%len(spinnerFrames)], result.TestedEndpoint, passed, failed)
+	p.frame++
+	p.render(status, result)
+}
+
+func (p *ttyProgress) Done(result *connectors.TestResultSummary, timedOut bool) {
+	p.render(formatTestSummary(result, timedOut), result)
+}
+
+func (p *ttyProgress) render(status string, result *connectors.TestResultSummary) {
+	var b strings.Builder
+	if p.lines > 0 {
+		// Move the cursor back to the top of the previous frame and clear it.
+		fmt.Fprintf(&b, "\033[%dA\033[J", p.lines)
+	}
+	b.WriteString(status + "\n")
+	for _, tc := range result.TestCaseResults {
+		b.WriteString(formatTestCase(tc) + "\n")
+	}
+	p.lines = 1 + len(result.TestCaseResults)
+	fmt.Fprint(p.out, b.String())
+}
+
+func formatTestCase(tc connectors.TestCaseResult) string {
+	mark := "✓"
+	if !tc.Success {
+		mark = "✗"
+	}
+	line := fmt.Sprintf("  %s %s (%d ms)", mark, tc.OperationName, tc.ElapsedTime)
+	if !tc.Success {
+		for _, step := range tc.TestStepResults {
+			if step.Message != "" {
+				line += " - " + firstLine(step.Message)
+				break
+			}
+		}
+	}
+	return line
+}
+
+func formatTestSummary(result *connectors.TestResultSummary, timedOut bool) string {
+	passed, failed := countTestCases(result)
+	switch {
+	case timedOut:
+		return fmt.Sprintf("Test %s still in progress when polling timed out (%d passed, %d failed so far)", result.ID, passed, failed)
+	case result.Success:
+		return fmt.Sprintf("Test %s PASSED — %d/%d operations passed", result.ID, passed, passed+failed)
+	default:
+		return fmt.Sprintf("Test %s FAILED — %d/%d operations passed", result.ID, passed, passed+failed)
+	}
+}
+
+func countTestCases(result *connectors.TestResultSummary) (int, int) {
+	passed, failed := 0, 0
+	for _, tc := range result.TestCaseResults {
+		if tc.Success {
+			passed++
+		} else {
+			failed++
+		}
+	}
+	return passed, failed
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
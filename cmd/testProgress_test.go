@@ -0,0 +1,74 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlainProgressPrintsEachOperationOnce(t *testing.T) {
+	var out bytes.Buffer
+	progress := &plainProgress{out: &out, reported: map[string]bool{}}
+
+	result := &connectors.TestResultSummary{ID: "t1", TestedEndpoint: "http://orders", InProgress: true}
+	progress.Update(result)
+	result.TestCaseResults = []connectors.TestCaseResult{{OperationName: "GET /orders", Success: true, ElapsedTime: 12}}
+	progress.Update(result)
+	progress.Update(result)
+	result.InProgress = false
+	result.TestCaseResults = append(result.TestCaseResults, connectors.TestCaseResult{
+		OperationName:   "POST /orders",
+		ElapsedTime:     40,
+		TestStepResults: []connectors.TestStepResult{{Message: "Invalid body\nat line 3"}},
+	})
+	progress.Done(result, false)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, []string{
+		"Test t1 started on http://orders",
+		"  ✓ GET /orders (12 ms)",
+		"  ✗ POST /orders (40 ms) - Invalid body",
+		"Test t1 FAILED — 1/2 operations passed",
+	}, lines)
+}
+
+func TestPlainProgressReportsTimeout(t *testing.T) {
+	var out bytes.Buffer
+	progress := &plainProgress{out: &out, reported: map[string]bool{}}
+	progress.Done(&connectors.TestResultSummary{ID: "t1", InProgress: true}, true)
+	assert.Contains(t, out.String(), "still in progress when polling timed out")
+}
+
+func TestPollBackoffGrowsAndResets(t *testing.T) {
+	backoff := pollBackoff{}
+	result := &connectors.TestResultSummary{}
+
+	assert.Equal(t, minPollInterval, backoff.next(result))
+	previous := backoff.next(result)
+	assert.Greater(t, previous, minPollInterval)
+	for i := 0; i < 20; i++ {
+		previous = backoff.next(result)
+	}
+	assert.Equal(t, maxPollInterval, previous)
+
+	result.TestCaseResults = []connectors.TestCaseResult{{OperationName: "GET /orders"}}
+	assert.Equal(t, minPollInterval, backoff.next(result))
+}
@@ -396,6 +396,29 @@ func TestLocalConfigCRUDAndValidation(t *testing.T) {
 	assert.True(t, os.IsNotExist(err))
 }
 
+func TestCheckInstance(t *testing.T) {
+	cfg := LocalConfig{
+		Contexts: []ContextRef{
+			{Name: "orders", Server: "http://localhost:8585", User: "http://localhost:8585", Instance: "orders"},
+			{Name: "staging", Server: "https://staging.example.com", User: "https://staging.example.com"},
+		},
+		Instances: []Instance{{Name: "orders", Port: "8585", GrpcPort: "9595", Async: "kafka", BrokerPort: "9092"}},
+	}
+
+	assert.Equal(t, "orders", cfg.InstanceOnPort("8585").Name)
+	assert.Equal(t, "orders", cfg.InstanceOnPort("9595").Name)
+	assert.Equal(t, "orders", cfg.InstanceOnPort("9092").Name)
+	assert.Nil(t, cfg.InstanceOnPort("8686"))
+
+	assert.NoError(t, cfg.CheckInstance(Instance{Name: "orders", Port: "8585"}))
+	assert.NoError(t, cfg.CheckInstance(Instance{Name: "billing", Port: "8686"}))
+	assert.EqualError(t, cfg.CheckInstance(Instance{Name: "billing", Port: "8585"}), "port 8585 is already recorded for instance 'orders'")
+	assert.EqualError(t, cfg.CheckInstance(Instance{Name: "billing", Port: "8686", GrpcPort: "9595"}), "port 9595 is already recorded for instance 'orders'")
+	assert.EqualError(t, cfg.CheckInstance(Instance{Name: "billing", Port: "8686", Async: "kafka", BrokerPort: "9092"}), "port 9092 is already recorded for instance 'orders'")
+	assert.EqualError(t, cfg.CheckInstance(Instance{Name: "billing", Port: "4222", Async: "nats", BrokerPort: "4222"}), "port 4222 is claimed twice by instance 'billing'")
+	assert.EqualError(t, cfg.CheckInstance(Instance{Name: "staging", Port: "8686"}), "context 'staging' already exists for server https://staging.example.com")
+}
+
 func TestReadLocalConfigPermission(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("Skipping OS file permission failure test on Windows since files always have 0666 or 0444 permissions")
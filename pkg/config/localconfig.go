@@ -46,13 +46,41 @@ type Server struct {
 }
 
 type Instance struct {
-	Name        string `yaml:"name"`
-	Image       string `yaml:"image"`
+	Name  string `yaml:"name"`
+	Image string `yaml:"image"`
+	// ImageDigest pins Image to the repository digest the container was
+	// created from (repository@sha256:...), empty for a local image.
+	ImageDigest string `yaml:"imageDigest,omitempty"`
 	Status      string `yaml:"status"`
 	Port        string `yaml:"port"`
 	ContainerID string `yaml:"containerID"`
 	AutoRemove  bool   `yaml:"autoRemove"`
 	Driver      string `yaml:"driver"`
+	// Async is the broker protocol of `start --async`; its broker and
+	// async minion containers are Companions attached to Network. The
+	// broker is published on the host at BrokerPort.
+	Async      string      `yaml:"async,omitempty"`
+	Network    string      `yaml:"network,omitempty"`
+	Companions []Companion `yaml:"companions,omitempty"`
+	BrokerPort string      `yaml:"brokerPort,omitempty"`
+	// Container options of `microcks start`, reused when the container is
+	// recreated. Memory is kept as given (512m, 2g).
+	Env      []string `yaml:"env,omitempty"`
+	Volumes  []string `yaml:"volumes,omitempty"`
+	HostIP   string   `yaml:"hostIP,omitempty"`
+	GrpcPort string   `yaml:"grpcPort,omitempty"`
+	CPUs     float64  `yaml:"cpus,omitempty"`
+	Memory   string   `yaml:"memory,omitempty"`
+	// Artifacts is the directory last imported with `start --artifacts`.
+	Artifacts string `yaml:"artifacts,omitempty"`
+}
+
+// Companion is a container managed along with an instance.
+type Companion struct {
+	Role        string `yaml:"role"`
+	Name        string `yaml:"name"`
+	Image       string `yaml:"image"`
+	ContainerID string `yaml:"containerID"`
 }
 
 type Auth struct {
@@ -320,6 +348,49 @@ func (l *LocalConfig) UpsertInstance(instance Instance) {
 	l.Instances = append(l.Instances, instance)
 }
 
+// HostPorts returns the host ports an instance and its companions claim.
+func (i Instance) HostPorts() []string {
+	var ports []string
+	for _, port := range []string{i.Port, i.GrpcPort, i.BrokerPort} {
+		if port != "" {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// InstanceOnPort returns the instance recorded with a host port, for itself
+// or one of its companions, or nil.
+func (l *LocalConfig) InstanceOnPort(port string) *Instance {
+	for _, i := range l.Instances {
+		if slices.Contains(i.HostPorts(), port) {
+			return &i
+		}
+	}
+	return nil
+}
+
+// CheckInstance returns an error when an instance claims a host port twice
+// or one of another recorded instance, or the name of a context that isn't
+// its own.
+func (l *LocalConfig) CheckInstance(instance Instance) error {
+	ports := instance.HostPorts()
+	for n, port := range ports {
+		if slices.Contains(ports[:n], port) {
+			return fmt.Errorf("port %s is claimed twice by instance '%s'", port, instance.Name)
+		}
+		if other := l.InstanceOnPort(port); other != nil && other.Name != instance.Name {
+			return fmt.Errorf("port %s is already recorded for instance '%s'", port, other.Name)
+		}
+	}
+	for _, c := range l.Contexts {
+		if c.Name == instance.Name && c.Instance != instance.Name {
+			return fmt.Errorf("context '%s' already exists for server %s", c.Name, c.Server)
+		}
+	}
+	return nil
+}
+
 // Returns true if server was removed successfully
 func (l *LocalConfig) RemoveInstance(instanceName string) bool {
 	if instanceName == "" {
@@ -2,7 +2,10 @@ package connectors
 
 import (
 	"context"
+	errs "errors"
 	"fmt"
+	"io"
+	"net"
 	"os"
 	"os/exec"
 	"runtime"
@@ -10,9 +13,13 @@ import (
 	"time"
 
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
 	"github.com/moby/term"
 )
@@ -22,6 +29,15 @@ type ContainerClient interface {
 	StartContainer(containerId string) error
 	StopContainer(continerId string) error
 	ContainerExists(containerId string) (bool, error)
+	InspectContainer(containerId string) (*ContainerDetails, error)
+	ContainerLogs(containerId string, opts LogOpts, out io.Writer) error
+	RemoveContainer(containerId string, removeVolumes bool) error
+	RemoveVolume(name string) error
+	WaitForHealthy(containerId string, timeout time.Duration) error
+	ListImages(reference string) ([]ImageSummary, error)
+	PullImage(reference string) error
+	CreateNetwork(name string) (string, error)
+	RemoveNetwork(name string) error
 	CloseClient() error
 }
 
@@ -34,10 +50,78 @@ type ContainerOpts struct {
 	Port       string
 	AutoRemove bool
 	Name       string
+	// Volumes are bind mounts in the Docker `host-path:container-path[:ro]`
+	// form.
+	Volumes []string
+	Env     []string
+	Cmd     []string
+	// Network is a user-defined network to attach the container to, where
+	// other containers reach it by its NetworkAliases.
+	Network        string
+	NetworkAliases []string
+	// Ports binds container ports (9092/tcp) to host ports, in addition to
+	// the Microcks 8080 port bound to Port and the gRPC 9090 port bound to
+	// GrpcPort.
+	Ports    map[string]string
+	GrpcPort string
+	// HostIP is the host interface ports are bound on, LOCALHOST_IP when
+	// empty.
+	HostIP string
+	// CPUs and Memory (in bytes) limit the container resources, unlimited
+	// when zero.
+	CPUs   float64
+	Memory int64
 }
 
+// LogOpts selects the container logs to stream: Tail is the number of
+// lines to start from ("all" or empty for everything), Follow keeps the
+// stream open until the container stops.
+type LogOpts struct {
+	Follow bool
+	Tail   string
+}
+
+// ContainerDetails is the runtime view of a container.
+type ContainerDetails struct {
+	ID   string
+	Name string
+	// Image is the reference the container was created from, ImageID the
+	// local image it resolved to and ImageDigest its repository digest
+	// (empty for an image that was never pulled nor pushed).
+	Image       string
+	ImageID     string
+	ImageDigest string
+	// State is one of created, running, paused, restarting, removing,
+	// exited or dead. Health is starting, healthy or unhealthy, empty when
+	// the image declares no healthcheck.
+	State     string
+	Health    string
+	ExitCode  int
+	StartedAt string
+	// Ports maps exposed container ports (8080/tcp) to their host
+	// bindings (127.0.0.1:8585).
+	Ports map[string]string
+	// Volumes are the names of the volumes mounted in the container, bind
+	// mounts excluded.
+	Volumes []string
+}
+
+// ImageSummary describes an image available to the runtime.
+type ImageSummary struct {
+	ID          string
+	RepoTags    []string
+	RepoDigests []string
+	Size        int64
+	Created     time.Time
+}
+
+// ErrContainerNotFound is returned when the runtime doesn't know the
+// container (anymore).
+var ErrContainerNotFound = errs.New("no such container")
+
 const (
 	MICROCKS_DEFAULT_PORT = "8080"
+	MICROCKS_GRPC_PORT    = "9090"
 	LOCALHOST_IP          = "127.0.0.1"
 )
 
@@ -124,51 +208,87 @@ func NewPodmanClient() (*containerClient, error) {
 func (cli *containerClient) CreateContainer(opts ContainerOpts) (string, error) {
 	ctx := context.Background()
 
-	// Define exposed port and bindings
-	exposedPort, _ := nat.NewPort("tcp", "8080")
-	portBindings := nat.PortMap{
-		exposedPort: []nat.PortBinding{
-			{
-				HostIP:   LOCALHOST_IP,
-				HostPort: opts.Port,
-			},
-		},
+	containerConfig, hostConfig, networking, err := containerConfigs(opts)
+	if err != nil {
+		return "", err
 	}
 
-	out, err := cli.cli.ImagePull(ctx, opts.Image, image.PullOptions{})
+	if err := cli.PullImage(opts.Image); err != nil {
+		return "", err
+	}
+	resp, err := cli.cli.ContainerCreate(ctx, containerConfig, hostConfig, networking, nil, opts.Name)
+
 	if err != nil {
 		return "", err
 	}
+
+	return resp.ID, nil
+}
+
+// PullImage pulls an image, displaying the progress on stdout.
+func (cli *containerClient) PullImage(reference string) error {
+	ctx := context.Background()
+	out, err := cli.cli.ImagePull(ctx, reference, image.PullOptions{})
+	if err != nil {
+		return err
+	}
 	defer out.Close()
 
 	fd, isTerminal := term.GetFdInfo(os.Stdout)
+	return jsonmessage.DisplayJSONMessagesStream(out, os.Stdout, fd, isTerminal, nil)
+}
 
-	err = jsonmessage.DisplayJSONMessagesStream(
-		out,
-		os.Stdout,
-		fd,
-		isTerminal,
-		nil,
-	)
-	if err != nil {
-		return "", err
+// containerConfigs translates opts into the container, host and networking
+// configurations of the Docker API.
+func containerConfigs(opts ContainerOpts) (*container.Config, *container.HostConfig, *network.NetworkingConfig, error) {
+	bindings := map[string]string{}
+	if opts.Port != "" {
+		bindings[MICROCKS_DEFAULT_PORT+"/tcp"] = opts.Port
+	}
+	if opts.GrpcPort != "" {
+		bindings[MICROCKS_GRPC_PORT+"/tcp"] = opts.GrpcPort
+	}
+	for containerPort, hostPort := range opts.Ports {
+		bindings[containerPort] = hostPort
+	}
+	hostIP := opts.HostIP
+	if hostIP == "" {
+		hostIP = LOCALHOST_IP
+	}
+	exposedPorts := nat.PortSet{}
+	portBindings := nat.PortMap{}
+	for containerPort, hostPort := range bindings {
+		port, err := nat.NewPort(nat.SplitProtoPort(containerPort))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		exposedPorts[port] = struct{}{}
+		portBindings[port] = []nat.PortBinding{{HostIP: hostIP, HostPort: hostPort}}
 	}
-	resp, err := cli.cli.ContainerCreate(
-		ctx,
-		&container.Config{
-			Image:        opts.Image,
-			ExposedPorts: nat.PortSet{exposedPort: struct{}{}},
-		},
-		&container.HostConfig{
-			PortBindings: portBindings,
-			AutoRemove:   opts.AutoRemove,
-		}, nil, nil, opts.Name)
 
-	if err != nil {
-		return "", err
+	var networking *network.NetworkingConfig
+	if opts.Network != "" {
+		networking = &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{
+			opts.Network: {Aliases: opts.NetworkAliases},
+		}}
 	}
 
-	return resp.ID, nil
+	containerConfig := &container.Config{
+		Image:        opts.Image,
+		ExposedPorts: exposedPorts,
+		Env:          opts.Env,
+		Cmd:          opts.Cmd,
+	}
+	hostConfig := &container.HostConfig{
+		PortBindings: portBindings,
+		AutoRemove:   opts.AutoRemove,
+		Binds:        opts.Volumes,
+		Resources: container.Resources{
+			NanoCPUs: int64(opts.CPUs * 1e9),
+			Memory:   opts.Memory,
+		},
+	}
+	return containerConfig, hostConfig, networking, nil
 }
 
 func (cli *containerClient) StartContainer(containerId string) error {
@@ -199,6 +319,217 @@ func (cli *containerClient) ContainerExists(containerId string) (bool, error) {
 	return true, nil
 }
 
+func (cli *containerClient) InspectContainer(containerId string) (*ContainerDetails, error) {
+	ctx := context.Background()
+	info, err := cli.cli.ContainerInspect(ctx, containerId)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return nil, ErrContainerNotFound
+		}
+		return nil, err
+	}
+	details := containerDetails(info)
+
+	// The digest is an image property, not a container one. A missing
+	// image (removed after the container was created) leaves it empty.
+	if details.ImageID != "" {
+		img, err := cli.cli.ImageInspect(ctx, details.ImageID)
+		if err != nil && !client.IsErrNotFound(err) {
+			return nil, err
+		}
+		details.ImageDigest = repoDigest(details.Image, img.RepoDigests)
+	}
+	return details, nil
+}
+
+func (cli *containerClient) ContainerLogs(containerId string, opts LogOpts, out io.Writer) error {
+	ctx := context.Background()
+	logs, err := cli.cli.ContainerLogs(ctx, containerId, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     opts.Follow,
+		Tail:       opts.Tail,
+	})
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+
+	// Containers are created without a TTY, so stdout and stderr come
+	// multiplexed in a single stream.
+	_, err = stdcopy.StdCopy(out, out, logs)
+	return err
+}
+
+// RemoveContainer force-removes the container, stopping it if needed, and
+// its anonymous volumes with removeVolumes.
+func (cli *containerClient) RemoveContainer(containerId string, removeVolumes bool) error {
+	ctx := context.Background()
+	err := cli.cli.ContainerRemove(ctx, containerId, container.RemoveOptions{Force: true, RemoveVolumes: removeVolumes})
+	if client.IsErrNotFound(err) {
+		return ErrContainerNotFound
+	}
+	return err
+}
+
+// RemoveVolume removes a volume; one already gone is not an error.
+func (cli *containerClient) RemoveVolume(name string) error {
+	ctx := context.Background()
+	err := cli.cli.VolumeRemove(ctx, name, false)
+	if client.IsErrNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// WaitForHealthy polls the container until its healthcheck passes, or until
+// it runs when the image has no healthcheck. It fails fast when the
+// container exits or disappears instead of waiting for the timeout.
+func (cli *containerClient) WaitForHealthy(containerId string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		details, err := cli.InspectContainer(containerId)
+		if err != nil {
+			return err
+		}
+		healthy, err := healthOutcome(details)
+		if healthy || err != nil {
+			return err
+		}
+		if time.Now().After(deadline) {
+			if details.Health != "" {
+				return fmt.Errorf("container is %s after %s", details.Health, timeout)
+			}
+			return fmt.Errorf("container is %s after %s", details.State, timeout)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// ListImages lists the images matching reference, e.g.
+// quay.io/microcks/microcks-uber or quay.io/microcks/microcks-uber:1.12.0.
+// An empty reference lists them all.
+func (cli *containerClient) ListImages(reference string) ([]ImageSummary, error) {
+	ctx := context.Background()
+	opts := image.ListOptions{}
+	if reference != "" {
+		opts.Filters = filters.NewArgs(filters.Arg("reference", reference))
+	}
+	images, err := cli.cli.ImageList(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]ImageSummary, 0, len(images))
+	for _, img := range images {
+		summaries = append(summaries, ImageSummary{
+			ID:          img.ID,
+			RepoTags:    img.RepoTags,
+			RepoDigests: img.RepoDigests,
+			Size:        img.Size,
+			Created:     time.Unix(img.Created, 0),
+		})
+	}
+	return summaries, nil
+}
+
+// containerDetails converts what Docker and Podman (through its Docker
+// compatible API) report on inspect.
+func containerDetails(info container.InspectResponse) *ContainerDetails {
+	details := &ContainerDetails{Ports: map[string]string{}}
+	if info.ContainerJSONBase != nil {
+		details.ID = info.ID
+		details.Name = strings.TrimPrefix(info.Name, "/")
+		details.ImageID = info.Image
+		if info.State != nil {
+			details.State = info.State.Status
+			details.ExitCode = info.State.ExitCode
+			details.StartedAt = info.State.StartedAt
+			if info.State.Health != nil {
+				details.Health = info.State.Health.Status
+			}
+		}
+	}
+	if info.Config != nil {
+		details.Image = info.Config.Image
+	}
+	for _, m := range info.Mounts {
+		if m.Type == mount.TypeVolume {
+			details.Volumes = append(details.Volumes, m.Name)
+		}
+	}
+	if info.NetworkSettings != nil {
+		for port, bindings := range info.NetworkSettings.Ports {
+			for _, binding := range bindings {
+				details.Ports[string(port)] = net.JoinHostPort(binding.HostIP, binding.HostPort)
+			}
+		}
+	}
+	return details
+}
+
+// repoDigest picks the digest of the repository the image was referenced
+// from, as an image pushed to several registries has one digest each.
+func repoDigest(reference string, digests []string) string {
+	repository := reference
+	if i := strings.LastIndex(repository, "@"); i >= 0 {
+		repository = repository[:i]
+	} else if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository = repository[:i]
+	}
+	for _, digest := range digests {
+		if strings.HasPrefix(digest, repository+"@") {
+			return digest
+		}
+	}
+	if len(digests) > 0 {
+		return digests[0]
+	}
+	return ""
+}
+
+// healthOutcome tells whether a container is healthy, and fails when it
+// can no longer become so.
+func healthOutcome(details *ContainerDetails) (bool, error) {
+	switch details.State {
+	case "exited", "dead":
+		return false, fmt.Errorf("container exited with code %d", details.ExitCode)
+	case "removing":
+		return false, fmt.Errorf("container is being removed")
+	case "running":
+		return details.Health == "" || details.Health == "healthy", nil
+	}
+	return false, nil
+}
+
+// CreateNetwork creates a bridge network, or returns the ID of the existing
+// network with that name.
+func (cli *containerClient) CreateNetwork(name string) (string, error) {
+	ctx := context.Background()
+	existing, err := cli.cli.NetworkInspect(ctx, name, network.InspectOptions{})
+	if err == nil {
+		return existing.ID, nil
+	}
+	if !client.IsErrNotFound(err) {
+		return "", err
+	}
+	created, err := cli.cli.NetworkCreate(ctx, name, network.CreateOptions{Driver: "bridge"})
+	if err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// RemoveNetwork removes a network; one that no longer exists is not an
+// error.
+func (cli *containerClient) RemoveNetwork(name string) error {
+	ctx := context.Background()
+	err := cli.cli.NetworkRemove(ctx, name)
+	if client.IsErrNotFound(err) {
+		return nil
+	}
+	return err
+}
+
 func (cli *containerClient) CloseClient() error {
 	return cli.cli.Close()
 }
@@ -0,0 +1,142 @@
+package connectors
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
+)
+
+func TestContainerDetailsFromInspect(t *testing.T) {
+	info := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			ID:    "c0ffee",
+			Name:  "/microcks",
+			Image: "sha256:1234",
+			State: &container.State{
+				Status:    "exited",
+				ExitCode:  137,
+				StartedAt: "2026-10-19T08:00:00Z",
+				Health:    &container.Health{Status: "unhealthy"},
+			},
+		},
+		Config: &container.Config{Image: "quay.io/microcks/microcks-uber:latest-native"},
+		Mounts: []container.MountPoint{
+			{Type: mount.TypeBind, Source: "/etc/ssl/certs", Destination: "/deployments/certs"},
+			{Type: mount.TypeVolume, Name: "4f1e", Destination: "/deployments/data"},
+		},
+		NetworkSettings: &container.NetworkSettings{
+			NetworkSettingsBase: container.NetworkSettingsBase{
+				Ports: nat.PortMap{"8080/tcp": {{HostIP: "127.0.0.1", HostPort: "8585"}}},
+			},
+		},
+	}
+
+	details := containerDetails(info)
+	if details.ID != "c0ffee" || details.Name != "microcks" || details.ImageID != "sha256:1234" {
+		t.Errorf("unexpected identity: %+v", details)
+	}
+	if details.Image != "quay.io/microcks/microcks-uber:latest-native" {
+		t.Errorf("unexpected image: %s", details.Image)
+	}
+	if details.State != "exited" || details.ExitCode != 137 || details.Health != "unhealthy" {
+		t.Errorf("unexpected state: %+v", details)
+	}
+	if got := details.Ports["8080/tcp"]; got != "127.0.0.1:8585" {
+		t.Errorf("unexpected port binding: %q", got)
+	}
+	if len(details.Volumes) != 1 || details.Volumes[0] != "4f1e" {
+		t.Errorf("unexpected volumes: %v", details.Volumes)
+	}
+}
+
+func TestRepoDigestMatchesReferencedRepository(t *testing.T) {
+	digests := []string{
+		"docker.io/microcks/microcks-uber@sha256:aaa",
+		"quay.io/microcks/microcks-uber@sha256:bbb",
+	}
+	cases := map[string]string{
+		"quay.io/microcks/microcks-uber:latest-native":   "quay.io/microcks/microcks-uber@sha256:bbb",
+		"quay.io/microcks/microcks-uber@sha256:bbb":      "quay.io/microcks/microcks-uber@sha256:bbb",
+		"quay.io/microcks/microcks-uber":                 "quay.io/microcks/microcks-uber@sha256:bbb",
+		"localhost:5000/microcks/microcks-uber:nightly":  "docker.io/microcks/microcks-uber@sha256:aaa",
+		"docker.io/microcks/microcks-uber:1.12.0-native": "docker.io/microcks/microcks-uber@sha256:aaa",
+	}
+	for reference, expected := range cases {
+		if got := repoDigest(reference, digests); got != expected {
+			t.Errorf("repoDigest(%q) = %q, expected %q", reference, got, expected)
+		}
+	}
+	if got := repoDigest("microcks-uber:local", nil); got != "" {
+		t.Errorf("expected no digest for a local image, got %q", got)
+	}
+}
+
+func TestHealthOutcome(t *testing.T) {
+	cases := []struct {
+		details ContainerDetails
+		healthy bool
+		failed  bool
+	}{
+		{ContainerDetails{State: "created"}, false, false},
+		{ContainerDetails{State: "running"}, true, false},
+		{ContainerDetails{State: "running", Health: "starting"}, false, false},
+		{ContainerDetails{State: "running", Health: "unhealthy"}, false, false},
+		{ContainerDetails{State: "running", Health: "healthy"}, true, false},
+		{ContainerDetails{State: "exited", ExitCode: 1}, false, true},
+		{ContainerDetails{State: "dead"}, false, true},
+	}
+	for _, c := range cases {
+		healthy, err := healthOutcome(&c.details)
+		if healthy != c.healthy || (err != nil) != c.failed {
+			t.Errorf("healthOutcome(%+v) = %t, %v", c.details, healthy, err)
+		}
+	}
+}
+
+func TestContainerConfigs(t *testing.T) {
+	containerConfig, hostConfig, networking, err := containerConfigs(ContainerOpts{
+		Image:    "quay.io/microcks/microcks-uber:latest",
+		Port:     "8585",
+		GrpcPort: "9191",
+		HostIP:   "0.0.0.0",
+		Env:      []string{"FEATURE=on"},
+		Volumes:  []string{"/etc/ssl/certs:/deployments/certs:ro"},
+		Network:  "ci",
+		CPUs:     1.5,
+		Memory:   512 << 20,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(containerConfig.ExposedPorts) != 2 {
+		t.Errorf("expected 8080 and 9090 to be exposed, got %v", containerConfig.ExposedPorts)
+	}
+	if got := hostConfig.PortBindings["9090/tcp"]; len(got) != 1 || got[0] != (nat.PortBinding{HostIP: "0.0.0.0", HostPort: "9191"}) {
+		t.Errorf("unexpected gRPC binding: %v", got)
+	}
+	if got := hostConfig.PortBindings["8080/tcp"]; len(got) != 1 || got[0].HostIP != "0.0.0.0" {
+		t.Errorf("unexpected HTTP binding: %v", got)
+	}
+	if hostConfig.NanoCPUs != 1_500_000_000 || hostConfig.Memory != 512<<20 {
+		t.Errorf("unexpected resources: %+v", hostConfig.Resources)
+	}
+	if len(hostConfig.Binds) != 1 || containerConfig.Env[0] != "FEATURE=on" {
+		t.Errorf("unexpected binds or env: %v %v", hostConfig.Binds, containerConfig.Env)
+	}
+	if _, ok := networking.EndpointsConfig["ci"]; !ok {
+		t.Errorf("expected the container to join the ci network")
+	}
+
+	_, hostConfig, networking, err = containerConfigs(ContainerOpts{Image: "nats:2.10", Ports: map[string]string{"4222/tcp": "4222"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hostConfig.PortBindings["4222/tcp"]; len(got) != 1 || got[0].HostIP != LOCALHOST_IP {
+		t.Errorf("expected ports to be bound on localhost by default, got %v", got)
+	}
+	if networking != nil {
+		t.Errorf("expected no networking config without network")
+	}
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package connectors
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// ImportJob represents a Microcks importer job: a repository URL that is
+// re-imported on a schedule while the job is active.
+type ImportJob struct {
+	ID                             string       `json:"id,omitempty"`
+	Name                           string       `json:"name"`
+	RepositoryURL                  string       `json:"repositoryUrl"`
+	MainArtifact                   bool         `json:"mainArtifact"`
+	RepositoryDisableSSLValidation bool         `json:"repositoryDisableSSLValidation"`
+	Frequency                      string       `json:"frequency,omitempty"`
+	CreatedDate                    int64        `json:"createdDate,omitempty"`
+	LastImportDate                 int64        `json:"lastImportDate,omitempty"`
+	LastImportError                string       `json:"lastImportError,omitempty"`
+	Active                         bool         `json:"active"`
+	Metadata                       *Metadata    `json:"metadata,omitempty"`
+	SecretRef                      *SecretRef   `json:"secretRef,omitempty"`
+	ServiceRefs                    []ServiceRef `json:"serviceRefs,omitempty"`
+}
+
+// SecretRef represents a reference to a Secret
+type SecretRef struct {
+	SecretID string `json:"secretId"`
+	Name     string `json:"name"`
+}
+
+// ServiceRef represents a reference to a Service imported by a job
+type ServiceRef struct {
+	ServiceID string `json:"serviceId"`
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+}
+
+func (c *microcksClient) ListImportJobs(page int, size int) ([]ImportJob, error) {
+	jobs := []ImportJob{}
+	rel := &url.URL{Path: "jobs", RawQuery: fmt.Sprintf("page=%d&size=%d", page, size)}
+	if err := c.sendJSON("GET", rel, nil, &jobs, "listing importer jobs"); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (c *microcksClient) CreateImportJob(job *ImportJob) (*ImportJob, error) {
+	created := ImportJob{}
+	if err := c.sendJSON("POST", &url.URL{Path: "jobs"}, job, &created, "creating importer job"); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (c *microcksClient) ActivateImportJob(jobID string) (*ImportJob, error) {
+	return c.changeImportJob(jobID, "activate", "activating importer job")
+}
+
+func (c *microcksClient) StartImportJob(jobID string) (*ImportJob, error) {
+	return c.changeImportJob(jobID, "start", "starting importer job")
+}
+
+func (c *microcksClient) StopImportJob(jobID string) (*ImportJob, error) {
+	return c.changeImportJob(jobID, "stop", "stopping importer job")
+}
+
+func (c *microcksClient) DeleteImportJob(jobID string) error {
+	return c.sendJSON("DELETE", &url.URL{Path: "jobs/" + jobID}, nil, nil, "deleting importer job")
+}
+
+// changeImportJob calls one of the activate, start and stop job actions.
+func (c *microcksClient) changeImportJob(jobID, action, what string) (*ImportJob, error) {
+	job := ImportJob{}
+	if err := c.sendJSON("PUT", &url.URL{Path: "jobs/" + jobID + "/" + action}, nil, &job, what); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package connectors
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/microcks/microcks-cli/pkg/errors"
+)
+
+// ConformanceMetric represents the test conformance index Microcks computes
+// for a Service. MaxPossibleScore is bounded by the operations having examples.
+type ConformanceMetric struct {
+	ID               string             `json:"id"`
+	ServiceID        string             `json:"serviceId"`
+	MaxPossibleScore float64            `json:"maxPossibleScore"`
+	CurrentScore     float64            `json:"currentScore"`
+	LastUpdateDay    string             `json:"lastUpdateDay"`
+	LatestTrend      string             `json:"latestTrend"`
+	LatestScores     map[string]float64 `json:"latestScores"`
+}
+
+func (c *microcksClient) GetConformanceMetric(serviceID string) (*ConformanceMetric, error) {
+	metric := ConformanceMetric{}
+	err := c.sendJSON("GET", &url.URL{Path: "metrics/conformance/service/" + serviceID}, nil, &metric, "getting conformance metric")
+	if errors.KindOf(err) == errors.KindNotFound {
+		return nil, errors.Wrapf(errors.KindNotFound, "no conformance metric computed yet for service '%s', run a test first", serviceID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &metric, nil
+}
+
+// DailyInvocationStatistic represents the mock invocation counts of a day,
+// for a Service or for all of them (no service name). Hours are keyed "0" to
+// "23" and minutes "0" to "1439".
+type DailyInvocationStatistic struct {
+	ID             string           `json:"id,omitempty"`
+	Day            string           `json:"day"`
+	ServiceName    string           `json:"serviceName,omitempty"`
+	ServiceVersion string           `json:"serviceVersion,omitempty"`
+	DailyCount     int64            `json:"dailyCount"`
+	HourlyCount    map[string]int64 `json:"hourlyCount,omitempty"`
+	MinuteCount    map[string]int64 `json:"minuteCount,omitempty"`
+}
+
+// GetInvocationStats returns the invocations of a service mocks on a day,
+// formatted as YYYYMMDD. Days without invocation have a zero count.
+func (c *microcksClient) GetInvocationStats(serviceName, serviceVersion, day string) (*DailyInvocationStatistic, error) {
+	stats := DailyInvocationStatistic{Day: day, ServiceName: serviceName, ServiceVersion: serviceVersion}
+	rel := &url.URL{Path: "metrics/invocations/" + serviceName + "/" + serviceVersion, RawQuery: "day=" + url.QueryEscape(day)}
+	if err := c.sendJSON("GET", rel, nil, &stats, "getting invocation metrics"); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// GetGlobalInvocationStats returns the invocations of all mocks on a day.
+func (c *microcksClient) GetGlobalInvocationStats(day string) (*DailyInvocationStatistic, error) {
+	stats := DailyInvocationStatistic{Day: day}
+	rel := &url.URL{Path: "metrics/invocations/global", RawQuery: "day=" + url.QueryEscape(day)}
+	if err := c.sendJSON("GET", rel, nil, &stats, "getting global invocation metrics"); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// GetTopInvocationStats returns the most invoked services on a day, most
+// invoked first.
+func (c *microcksClient) GetTopInvocationStats(day string, limit int) ([]DailyInvocationStatistic, error) {
+	stats := []DailyInvocationStatistic{}
+	rel := &url.URL{Path: "metrics/invocations/top", RawQuery: fmt.Sprintf("day=%s&limit=%d", url.QueryEscape(day), limit)}
+	if err := c.sendJSON("GET", rel, nil, &stats, "getting top invocation metrics"); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -48,23 +48,173 @@ type MicrocksClient interface {
 	HttpClient() *http.Client
 	GetKeycloakURL() (string, error)
 	SetOAuthToken(oauthToken string)
-	CreateTestResult(serviceID string, testEndpoint string, runnerType string, secretName string, timeout int64, filteredOperations string, operationsHeaders string, oAuth2Context string) (string, error)
+	ListServices(page int, size int) ([]Service, error)
+	GetService(serviceRef string) (*Service, error)
+	UpdateServiceMetadata(serviceID string, metadata *Metadata) error
+	UpdateOperation(serviceID string, operationName string, override *OperationOverride) error
+	GetServiceView(serviceRef string) (*ServiceView, error)
+	CreateTestResult(serviceID string, testEndpoint string, runnerType string, secretName string, timeout int64, filteredOperations []string, operationsHeaders map[string][]HeaderDTO, oAuth2Context *OAuth2ClientContext) (string, error)
 	GetTestResult(testResultID string) (*TestResultSummary, error)
+	ListTestResults(serviceID string, page int, size int) ([]TestResultSummary, error)
 	UploadArtifact(specificationFilePath string, mainArtifact bool) (string, error)
 	DownloadArtifact(artifactURL string, mainArtifact bool, secret string) (string, error)
+	GetConformanceMetric(serviceID string) (*ConformanceMetric, error)
+	GetInvocationStats(serviceName string, serviceVersion string, day string) (*DailyInvocationStatistic, error)
+	GetGlobalInvocationStats(day string) (*DailyInvocationStatistic, error)
+	GetTopInvocationStats(day string, limit int) ([]DailyInvocationStatistic, error)
+	ListSecrets(page int, size int) ([]Secret, error)
+	GetSecret(secretID string) (*Secret, error)
+	CreateSecret(secret *Secret) (*Secret, error)
+	UpdateSecret(secret *Secret) error
+	DeleteSecret(secretID string) error
+	ListImportJobs(page int, size int) ([]ImportJob, error)
+	CreateImportJob(job *ImportJob) (*ImportJob, error)
+	ActivateImportJob(jobID string) (*ImportJob, error)
+	StartImportJob(jobID string) (*ImportJob, error)
+	StopImportJob(jobID string) (*ImportJob, error)
+	DeleteImportJob(jobID string) error
+	ExportSnapshot(serviceIDs []string) (json.RawMessage, error)
+	ImportSnapshot(snapshotFilePath string) error
+	GetServiceResources(serviceID string) ([]Resource, error)
 }
 
 // TestResultSummary represents a simple view on Microcks TestResult
 type TestResultSummary struct {
-	ID             string `json:"id"`
-	Version        int32  `json:"version"`
-	TestNumber     int32  `json:"testNumber"`
-	TestDate       int64  `json:"testDate"`
-	TestedEndpoint string `json:"testedEndpoint"`
-	ServiceID      string `json:"serviceId"`
-	ElapsedTime    int32  `json:"elapsedTime"`
-	Success        bool   `json:"success"`
-	InProgress     bool   `json:"inProgress"`
+	ID              string           `json:"id"`
+	Version         int32            `json:"version"`
+	TestNumber      int32            `json:"testNumber"`
+	TestDate        int64            `json:"testDate"`
+	TestedEndpoint  string           `json:"testedEndpoint"`
+	ServiceID       string           `json:"serviceId"`
+	RunnerType      string           `json:"runnerType"`
+	ElapsedTime     int32            `json:"elapsedTime"`
+	Success         bool             `json:"success"`
+	InProgress      bool             `json:"inProgress"`
+	TestCaseResults []TestCaseResult `json:"testCaseResults"`
+}
+
+// TestCaseResult represents the result of testing one operation
+type TestCaseResult struct {
+	OperationName   string           `json:"operationName"`
+	Success         bool             `json:"success"`
+	ElapsedTime     int32            `json:"elapsedTime"`
+	TestStepResults []TestStepResult `json:"testStepResults"`
+}
+
+// TestStepResult represents the result of one request or message of a test case
+type TestStepResult struct {
+	RequestName      string `json:"requestName"`
+	EventMessageName string `json:"eventMessageName"`
+	Success          bool   `json:"success"`
+	ElapsedTime      int32  `json:"elapsedTime"`
+	Message          string `json:"message"`
+}
+
+// Service represents a Microcks Service, an API name and version
+type Service struct {
+	ID         string      `json:"id"`
+	Name       string      `json:"name"`
+	Version    string      `json:"version"`
+	Type       string      `json:"type"`
+	Metadata   *Metadata   `json:"metadata,omitempty"`
+	Operations []Operation `json:"operations"`
+}
+
+// Metadata represents the labels and annotations of a Microcks resource
+type Metadata struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Operation represents an operation of a Microcks Service
+type Operation struct {
+	Name            string `json:"name"`
+	Method          string `json:"method"`
+	Action          string `json:"action,omitempty"`
+	Dispatcher      string `json:"dispatcher,omitempty"`
+	DispatcherRules string `json:"dispatcherRules,omitempty"`
+	DefaultDelay    int64  `json:"defaultDelay,omitempty"`
+}
+
+// OperationOverride represents the mock behavior of an operation: how the
+// response is dispatched among examples, and the response delay in ms
+type OperationOverride struct {
+	Dispatcher      string `json:"dispatcher"`
+	DispatcherRules string `json:"dispatcherRules"`
+	DefaultDelay    int64  `json:"defaultDelay"`
+}
+
+// DispatcherTypes lists the dispatchers of Microcks, and whether they need
+// rules.
+var DispatcherTypes = map[string]bool{
+	"SEQUENCE":       false,
+	"RANDOM":         false,
+	"URI_PARAMS":     true,
+	"URI_PARTS":      true,
+	"URI_ELEMENTS":   true,
+	"QUERY_ARGS":     true,
+	"QUERY_MATCH":    true,
+	"QUERY_HEADER":   true,
+	"JSON_BODY":      true,
+	"SCRIPT":         true,
+	"GROOVY":         true,
+	"JS":             true,
+	"FALLBACK":       true,
+	"PROXY":          true,
+	"PROXY_FALLBACK": true,
+}
+
+// ServiceView represents a Service with the request/response examples of
+// each of its operations, keyed by operation name
+type ServiceView struct {
+	Service     Service                      `json:"service"`
+	MessagesMap map[string][]json.RawMessage `json:"messagesMap"`
+}
+
+// RequestResponsePair is a named example of an operation: a request and the
+// response the mock answers with
+type RequestResponsePair struct {
+	Request  ExampleMessage `json:"request"`
+	Response ExampleMessage `json:"response"`
+}
+
+// ExampleMessage is the request or response of an example. Microcks stores
+// the path and query parameters of requests as queryParameters.
+type ExampleMessage struct {
+	Name            string           `json:"name"`
+	Content         string           `json:"content,omitempty"`
+	MediaType       string           `json:"mediaType,omitempty"`
+	Status          string           `json:"status,omitempty"`
+	Headers         []MessageHeader  `json:"headers,omitempty"`
+	QueryParameters []QueryParameter `json:"queryParameters,omitempty"`
+}
+
+// MessageHeader is a header of an example message
+type MessageHeader struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// QueryParameter is a parameter of an example request
+type QueryParameter struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Exchanges decodes the request/response examples of an operation. Unnamed
+// kinds of exchanges (e.g. unidirectional events) are skipped.
+func (v *ServiceView) Exchanges(operation string) ([]RequestResponsePair, error) {
+	var pairs []RequestResponsePair
+	for _, raw := range v.MessagesMap[operation] {
+		var pair RequestResponsePair
+		if err := json.Unmarshal(raw, &pair); err != nil {
+			return nil, errors.Wrap(errors.KindAPI, fmt.Errorf("parsing examples of %s: %w", operation, err))
+		}
+		if pair.Request.Name != "" || pair.Response.Name != "" {
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs, nil
 }
 
 // HeaderDTO represents an operation header passed for Test
@@ -111,14 +261,14 @@ type microcksClient struct {
 }
 
 type testRequest struct {
-	ServiceID          string          `json:"serviceId"`
-	TestEndpoint       string          `json:"testEndpoint"`
-	RunnerType         string          `json:"runnerType"`
-	Timeout            int64           `json:"timeout"`
-	SecretName         string          `json:"secretName,omitempty"`
-	FilteredOperations json.RawMessage `json:"filteredOperations,omitempty"`
-	OperationsHeaders  json.RawMessage `json:"operationsHeaders,omitempty"`
-	OAuth2Context      json.RawMessage `json:"oAuth2Context,omitempty"`
+	ServiceID          string                 `json:"serviceId"`
+	TestEndpoint       string                 `json:"testEndpoint"`
+	RunnerType         string                 `json:"runnerType"`
+	Timeout            int64                  `json:"timeout"`
+	SecretName         string                 `json:"secretName,omitempty"`
+	FilteredOperations []string               `json:"filteredOperations,omitempty"`
+	OperationsHeaders  map[string][]HeaderDTO `json:"operationsHeaders,omitempty"`
+	OAuth2Context      *OAuth2ClientContext   `json:"oAuth2Context,omitempty"`
 }
 
 func NewClient(opts ClientOptions) (MicrocksClient, error) {
@@ -345,28 +495,67 @@ func (c *microcksClient) SetOAuthToken(oauthToken string) {
 	c.AuthToken = oauthToken
 }
 
-func (c *microcksClient) CreateTestResult(serviceID string, testEndpoint string, runnerType string, secretName string, timeout int64, filteredOperations string, operationsHeaders string, oAuth2Context string) (string, error) {
+func (c *microcksClient) GetService(serviceRef string) (*Service, error) {
+	// Microcks resolves either a service ID or its `name:version`.
+	rel := &url.URL{Path: "services/" + serviceRef, RawQuery: "messages=false"}
+	service := Service{}
+	err := c.sendJSON("GET", rel, nil, &service, "getting service '"+serviceRef+"'")
+	if errors.KindOf(err) == errors.KindNotFound {
+		return nil, errors.Wrapf(errors.KindNotFound, "service '%s' is not registered in Microcks", serviceRef)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &service, nil
+}
+
+func (c *microcksClient) ListServices(page int, size int) ([]Service, error) {
+	services := []Service{}
+	rel := &url.URL{Path: "services", RawQuery: fmt.Sprintf("page=%d&size=%d", page, size)}
+	if err := c.sendJSON("GET", rel, nil, &services, "listing services"); err != nil {
+		return nil, err
+	}
+	return services, nil
+}
+
+// UpdateServiceMetadata replaces the labels and annotations of a service.
+func (c *microcksClient) UpdateServiceMetadata(serviceID string, metadata *Metadata) error {
+	return c.sendJSON("PUT", &url.URL{Path: "services/" + serviceID + "/metadata"}, metadata, nil, "updating service metadata")
+}
+
+// UpdateOperation overrides the dispatcher and default delay of an operation.
+func (c *microcksClient) UpdateOperation(serviceID, operationName string, override *OperationOverride) error {
+	rel := &url.URL{Path: "services/" + serviceID + "/operation", RawQuery: "operationName=" + url.QueryEscape(operationName)}
+	return c.sendJSON("PUT", rel, override, nil, "updating operation")
+}
+
+func (c *microcksClient) GetServiceView(serviceRef string) (*ServiceView, error) {
+	view := ServiceView{}
+	err := c.sendJSON("GET", &url.URL{Path: "services/" + serviceRef, RawQuery: "messages=true"}, nil, &view, "getting service view")
+	if errors.KindOf(err) == errors.KindNotFound {
+		return nil, errors.Wrapf(errors.KindNotFound, "service '%s' is not registered in Microcks", serviceRef)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+func (c *microcksClient) CreateTestResult(serviceID string, testEndpoint string, runnerType string, secretName string, timeout int64, filteredOperations []string, operationsHeaders map[string][]HeaderDTO, oAuth2Context *OAuth2ClientContext) (string, error) {
 	// Ensure we have a correct URL.
 	rel := &url.URL{Path: "tests"}
 	u := c.APIURL.ResolveReference(rel)
 
 	// Prepare an input struct as body.
 	testReq := testRequest{
-		ServiceID:    serviceID,
-		TestEndpoint: testEndpoint,
-		RunnerType:   runnerType,
-		Timeout:      timeout,
-		SecretName:   secretName,
-	}
-
-	if len(filteredOperations) > 0 && ensureValidOperationsList(filteredOperations) {
-		testReq.FilteredOperations = json.RawMessage(filteredOperations)
-	}
-	if len(operationsHeaders) > 0 && ensureValidOperationsHeaders(operationsHeaders) {
-		testReq.OperationsHeaders = json.RawMessage(operationsHeaders)
-	}
-	if len(oAuth2Context) > 0 && ensureValidOAuth2Context(oAuth2Context) {
-		testReq.OAuth2Context = json.RawMessage(oAuth2Context)
+		ServiceID:          serviceID,
+		TestEndpoint:       testEndpoint,
+		RunnerType:         runnerType,
+		Timeout:            timeout,
+		SecretName:         secretName,
+		FilteredOperations: filteredOperations,
+		OperationsHeaders:  operationsHeaders,
+		OAuth2Context:      oAuth2Context,
 	}
 
 	input, err := json.Marshal(testReq)
@@ -456,6 +645,15 @@ func (c *microcksClient) GetTestResult(testResultID string) (*TestResultSummary,
 	return &result, nil
 }
 
+func (c *microcksClient) ListTestResults(serviceID string, page int, size int) ([]TestResultSummary, error) {
+	rel := &url.URL{Path: "tests/service/" + serviceID, RawQuery: fmt.Sprintf("page=%d&size=%d", page, size)}
+	results := []TestResultSummary{}
+	if err := c.sendJSON("GET", rel, nil, &results, "listing tests"); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
 func (c *microcksClient) UploadArtifact(specificationFilePath string, mainArtifact bool) (string, error) {
 	// Ensure file exists on fs.
 	file, err := os.Open(specificationFilePath)
@@ -588,39 +786,3 @@ func (c *microcksClient) DownloadArtifact(artifactURL string, mainArtifact bool,
 
 	return string(respBody), nil
 }
-
-func ensureValidOperationsList(filteredOperations string) bool {
-	// Unmarshal using a generic interface
-	var list = []string{}
-	err := json.Unmarshal([]byte(filteredOperations), &list)
-	if err != nil {
-		fmt.Println("Error parsing JSON in filteredOperations: ", err)
-		return false
-	}
-	return true
-}
-
-func ensureValidOperationsHeaders(operationsHeaders string) bool {
-	// Unmarshal using a generic interface
-	var headers = map[string][]HeaderDTO{}
-	err := json.Unmarshal([]byte(operationsHeaders), &headers)
-	if err != nil {
-		fmt.Println("Error parsing JSON in operationsHeaders: ", err)
-		return false
-	}
-	return true
-}
-
-func ensureValidOAuth2Context(oAuth2Context string) bool {
-	var oContext = OAuth2ClientContext{}
-	err := json.Unmarshal([]byte(oAuth2Context), &oContext)
-	if err != nil {
-		fmt.Println("Error parsing JSON in oAuth2Context: ", err)
-		return false
-	}
-	if !grantTypeChoices[oContext.GrantType] {
-		fmt.Println("grantType in oAuth2Context is not supported. OAuth2 is turned off.")
-		return false
-	}
-	return true
-}
@@ -8,6 +8,10 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/microcks/microcks-cli/pkg/config"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/fakeserver"
 )
 
 func TestUploadArtifactStreamsWithoutBuffering(t *testing.T) {
@@ -108,3 +112,209 @@ func TestDownloadArtifactReturnsResponseBody(t *testing.T) {
 		t.Fatalf("expected response body %q, got %q", expectedBody, msg)
 	}
 }
+
+func TestCreateAndGetTestResultAgainstFakeServer(t *testing.T) {
+	fake := fakeserver.New()
+	fake.AddService(fakeserver.Service{Name: "Orders", Version: "1.0"})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client, err := NewMicrocksClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewMicrocksClient returned error: %v", err)
+	}
+
+	testID, err := client.CreateTestResult("Orders:1.0", "http://orders", "HTTP", "", 2000, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("CreateTestResult returned error: %v", err)
+	}
+	result, err := client.GetTestResult(testID)
+	if err != nil {
+		t.Fatalf("GetTestResult returned error: %v", err)
+	}
+	if result.ID != testID || !result.Success || result.InProgress {
+		t.Fatalf("unexpected test result: %+v", result)
+	}
+
+	if _, err := client.CreateTestResult("Unknown:1.0", "http://orders", "HTTP", "", 2000, nil, nil, nil); errors.KindOf(err) != errors.KindNotFound {
+		t.Fatalf("expected a KindNotFound error for an unknown service, got %v", err)
+	}
+}
+
+func TestListTestResultsAgainstFakeServer(t *testing.T) {
+	fake := fakeserver.New()
+	svc := fake.AddService(fakeserver.Service{Name: "Orders", Version: "1.0"})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client, err := NewMicrocksClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewMicrocksClient returned error: %v", err)
+	}
+
+	var ids []string
+	for _, endpoint := range []string{"http://orders-v1", "http://orders-v2", "http://orders-v3"} {
+		id, err := client.CreateTestResult("Orders:1.0", endpoint, "HTTP", "", 2000, nil, nil, nil)
+		if err != nil {
+			t.Fatalf("CreateTestResult returned error: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	results, err := client.ListTestResults(svc.ID, 0, 2)
+	if err != nil {
+		t.Fatalf("ListTestResults returned error: %v", err)
+	}
+	if len(results) != 2 || results[0].ID != ids[2] || results[1].ID != ids[1] {
+		t.Fatalf("expected the 2 most recent tests first, got %+v", results)
+	}
+	if results[0].TestedEndpoint != "http://orders-v3" || results[0].RunnerType != "HTTP" {
+		t.Fatalf("unexpected test result: %+v", results[0])
+	}
+}
+
+func TestGetServiceAgainstFakeServer(t *testing.T) {
+	fake := fakeserver.New()
+	svc := fake.AddService(fakeserver.Service{Name: "Orders", Version: "1.0"})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client, err := NewMicrocksClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewMicrocksClient returned error: %v", err)
+	}
+
+	service, err := client.GetService("Orders:1.0")
+	if err != nil {
+		t.Fatalf("GetService returned error: %v", err)
+	}
+	if service.ID != svc.ID {
+		t.Fatalf("unexpected service: %+v", service)
+	}
+
+	_, err = client.GetService("Payments:1.0")
+	if errors.KindOf(err) != errors.KindNotFound || err.Error() != "service 'Payments:1.0' is not registered in Microcks" {
+		t.Fatalf("expected a KindNotFound error for an unknown service, got %v", err)
+	}
+}
+
+func TestGetConformanceMetricAgainstFakeServer(t *testing.T) {
+	fake := fakeserver.New()
+	svc := fake.AddService(fakeserver.Service{Name: "Orders", Version: "1.0", Operations: []fakeserver.Operation{
+		{Name: "GET /orders", Method: "GET", Examples: []string{"all", "empty"}},
+	}})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client, err := NewMicrocksClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewMicrocksClient returned error: %v", err)
+	}
+
+	if _, err := client.GetConformanceMetric(svc.ID); errors.KindOf(err) != errors.KindNotFound {
+		t.Fatalf("expected a KindNotFound error before any test, got %v", err)
+	}
+
+	view, err := client.GetServiceView("Orders:1.0")
+	if err != nil {
+		t.Fatalf("GetServiceView returned error: %v", err)
+	}
+	if view.Service.ID != svc.ID || len(view.MessagesMap["GET /orders"]) != 2 {
+		t.Fatalf("unexpected service view: %+v", view)
+	}
+
+	fake.SetConformanceMetric("Orders:1.0", fakeserver.ConformanceMetric{CurrentScore: 42, MaxPossibleScore: 100, LatestTrend: "UP"})
+	metric, err := client.GetConformanceMetric(svc.ID)
+	if err != nil {
+		t.Fatalf("GetConformanceMetric returned error: %v", err)
+	}
+	if metric.CurrentScore != 42 || metric.LatestTrend != "UP" || metric.ServiceID != svc.ID {
+		t.Fatalf("unexpected conformance metric: %+v", metric)
+	}
+}
+
+func TestExportAndImportSnapshotAgainstFakeServer(t *testing.T) {
+	source := fakeserver.New()
+	orders := source.AddService(fakeserver.Service{Name: "Orders", Version: "1.0", Operations: []fakeserver.Operation{
+		{Name: "GET /orders", Method: "GET", Examples: []string{"all"}},
+	}})
+	source.AddService(fakeserver.Service{Name: "Billing", Version: "1.0"})
+	sourceServer := httptest.NewServer(source)
+	defer sourceServer.Close()
+
+	client, err := NewMicrocksClient(sourceServer.URL)
+	if err != nil {
+		t.Fatalf("NewMicrocksClient returned error: %v", err)
+	}
+	services, err := client.ListServices(0, 20)
+	if err != nil {
+		t.Fatalf("ListServices returned error: %v", err)
+	}
+	if len(services) != 2 {
+		t.Fatalf("expected 2 services, got %+v", services)
+	}
+	snapshot, err := client.ExportSnapshot([]string{orders.ID})
+	if err != nil {
+		t.Fatalf("ExportSnapshot returned error: %v", err)
+	}
+
+	snapshotPath := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := os.WriteFile(snapshotPath, snapshot, 0o600); err != nil {
+		t.Fatalf("failed to write snapshot: %v", err)
+	}
+	target := fakeserver.New()
+	targetServer := httptest.NewServer(target)
+	defer targetServer.Close()
+
+	client, err = NewMicrocksClient(targetServer.URL)
+	if err != nil {
+		t.Fatalf("NewMicrocksClient returned error: %v", err)
+	}
+	if err := client.ImportSnapshot(snapshotPath); err != nil {
+		t.Fatalf("ImportSnapshot returned error: %v", err)
+	}
+	imported := target.Services()
+	if len(imported) != 1 || imported[0].ID != orders.ID || len(imported[0].Operations[0].Examples) != 1 {
+		t.Fatalf("unexpected imported services: %+v", imported)
+	}
+}
+
+func TestVerboseSecretExchangesHideBodies(t *testing.T) {
+	server := httptest.NewServer(fakeserver.New())
+	defer server.Close()
+	client, err := NewMicrocksClient(server.URL)
+	if err != nil {
+		t.Fatalf("NewMicrocksClient returned error: %v", err)
+	}
+
+	oldVerbose, oldStdout := config.Verbose, os.Stdout
+	defer func() { config.Verbose, os.Stdout = oldVerbose, oldStdout }()
+	config.Verbose = true
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	created, err := client.CreateSecret(&Secret{Name: "petstore", Password: "s3cr3t-password", Token: "s3cr3t-token", CaCertPem: "s3cr3t-cert"})
+	if err == nil {
+		created.Password = "s3cr3t-update"
+		err = client.UpdateSecret(created)
+	}
+	if err == nil {
+		_, err = client.GetSecret(created.ID)
+	}
+	if err == nil {
+		_, err = client.ListSecrets(0, 20)
+	}
+	_ = w.Close()
+	os.Stdout = oldStdout
+	dump, _ := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("secret exchange returned error: %v", err)
+	}
+
+	if !strings.Contains(string(dump), "Dumping request 'Microcks for creating secret'") {
+		t.Fatalf("expected the secret exchanges to be dumped, got %q", dump)
+	}
+	if strings.Contains(string(dump), "s3cr3t") {
+		t.Fatalf("expected no secret value in the verbose dump, got %q", dump)
+	}
+}
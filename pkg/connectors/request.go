@@ -0,0 +1,109 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package connectors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/microcks/microcks-cli/pkg/config"
+	"github.com/microcks/microcks-cli/pkg/errors"
+)
+
+// sendJSON sends a request to the Microcks API with an optional JSON body and
+// decodes the JSON response into target, when not nil. what describes the
+// exchange in verbose dumps and errors, e.g. "getting conformance metric".
+// A 404 is reported as KindNotFound, any other non-2xx status as KindAPI.
+func (c *microcksClient) sendJSON(method string, rel *url.URL, body interface{}, target interface{}, what string) error {
+	return c.exchangeJSON(method, rel, body, target, what, true)
+}
+
+// sendSensitiveJSON is sendJSON for exchanges holding credentials, like
+// secrets: verbose dumps show their headers but never their bodies.
+func (c *microcksClient) sendSensitiveJSON(method string, rel *url.URL, body interface{}, target interface{}, what string) error {
+	return c.exchangeJSON(method, rel, body, target, what, false)
+}
+
+func (c *microcksClient) exchangeJSON(method string, rel *url.URL, body interface{}, target interface{}, what string, dumpBodies bool) error {
+	u := c.APIURL.ResolveReference(rel)
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, u.String(), reader)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.AuthToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	respBody, err := c.send(req, what, dumpBodies && body != nil, dumpBodies)
+	if err != nil {
+		return err
+	}
+	if target == nil || len(bytes.TrimSpace(respBody)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, target); err != nil {
+		return errors.Wrap(errors.KindAPI, fmt.Errorf("parsing response for %s: %w", what, err))
+	}
+	return nil
+}
+
+// send sends a prepared request to the Microcks API, with the verbose dumps,
+// and returns the body of a 2xx response. A 404 is reported as KindNotFound,
+// any other non-2xx status as KindAPI.
+func (c *microcksClient) send(req *http.Request, what string, dumpRequestBody, dumpResponseBody bool) ([]byte, error) {
+	// Dump request if verbose required.
+	config.DumpRequestIfRequired("Microcks for "+what, req, dumpRequestBody)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(errors.KindConnection, err)
+	}
+	defer resp.Body.Close()
+
+	// Dump response if verbose required.
+	config.DumpResponseIfRequired("Microcks for "+what, resp, dumpResponseBody)
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(errors.KindConnection, fmt.Errorf("reading response for %s: %w", what, err))
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errors.Wrapf(errors.KindNotFound, "Microcks returned HTTP 404 %s", what)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, errors.Wrapf(errors.KindAPI, "Microcks returned HTTP %d %s: %s", resp.StatusCode, what, strings.TrimSpace(string(respBody)))
+	}
+	return respBody, nil
+}
@@ -0,0 +1,41 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package connectors
+
+import (
+	"net/url"
+)
+
+// Resource is an artifact, or a part of it, stored by Microcks for a service:
+// the OpenAPI/AsyncAPI/... contract, a Postman collection, examples or the
+// schemas the contract references.
+type Resource struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Path           string `json:"path,omitempty"`
+	Content        string `json:"content"`
+	Type           string `json:"type"`
+	ServiceID      string `json:"serviceId"`
+	SourceArtifact string `json:"sourceArtifact,omitempty"`
+}
+
+func (c *microcksClient) GetServiceResources(serviceID string) ([]Resource, error) {
+	resources := []Resource{}
+	if err := c.sendJSON("GET", &url.URL{Path: "resources/service/" + serviceID}, nil, &resources, "getting service resources"); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package connectors
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// Secret represents a Microcks Secret used to reach secured test endpoints
+// and remote artifacts: basic auth, a (bearer or custom header) token and/or
+// a CA certificate.
+type Secret struct {
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Username    string `json:"username,omitempty"`
+	Password    string `json:"password,omitempty"`
+	Token       string `json:"token,omitempty"`
+	TokenHeader string `json:"tokenHeader,omitempty"`
+	CaCertPem   string `json:"caCertPem,omitempty"`
+}
+
+func (c *microcksClient) ListSecrets(page int, size int) ([]Secret, error) {
+	secrets := []Secret{}
+	rel := &url.URL{Path: "secrets", RawQuery: fmt.Sprintf("page=%d&size=%d", page, size)}
+	if err := c.sendSensitiveJSON("GET", rel, nil, &secrets, "listing secrets"); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+func (c *microcksClient) GetSecret(secretID string) (*Secret, error) {
+	secret := Secret{}
+	if err := c.sendSensitiveJSON("GET", &url.URL{Path: "secrets/" + secretID}, nil, &secret, "getting secret"); err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+func (c *microcksClient) CreateSecret(secret *Secret) (*Secret, error) {
+	created := Secret{}
+	if err := c.sendSensitiveJSON("POST", &url.URL{Path: "secrets"}, secret, &created, "creating secret"); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (c *microcksClient) UpdateSecret(secret *Secret) error {
+	return c.sendSensitiveJSON("PUT", &url.URL{Path: "secrets/" + secret.ID}, secret, nil, "updating secret")
+}
+
+func (c *microcksClient) DeleteSecret(secretID string) error {
+	return c.sendSensitiveJSON("DELETE", &url.URL{Path: "secrets/" + secretID}, nil, nil, "deleting secret")
+}
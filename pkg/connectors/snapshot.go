@@ -0,0 +1,91 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package connectors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/microcks/microcks-cli/pkg/errors"
+)
+
+// ExportSnapshot exports the repository content of the given services:
+// definitions, resources and examples, as a JSON document ImportSnapshot
+// can load into another Microcks.
+func (c *microcksClient) ExportSnapshot(serviceIDs []string) (json.RawMessage, error) {
+	query := url.Values{}
+	for _, id := range serviceIDs {
+		query.Add("serviceIds", id)
+	}
+	var snapshot json.RawMessage
+	if err := c.sendJSON("GET", &url.URL{Path: "export", RawQuery: query.Encode()}, nil, &snapshot, "exporting snapshot"); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func (c *microcksClient) ImportSnapshot(snapshotFilePath string) error {
+	file, err := os.Open(snapshotFilePath)
+	if err != nil {
+		return errors.Wrap(errors.KindUsage, fmt.Errorf("cannot read snapshot %q: %w", snapshotFilePath, err))
+	}
+	defer file.Close()
+
+	// Stream the multipart form like UploadArtifact: snapshots can be large.
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	errCh := make(chan error, 1)
+	go func() {
+		defer pw.Close()
+
+		part, err := writer.CreateFormFile("file", filepath.Base(snapshotFilePath))
+		if err != nil {
+			errCh <- err
+			return
+		}
+		if _, err = io.Copy(part, file); err != nil {
+			errCh <- err
+			return
+		}
+		errCh <- writer.Close()
+	}()
+
+	u := c.APIURL.ResolveReference(&url.URL{Path: "import"})
+	req, err := http.NewRequest("POST", u.String(), pr)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+c.AuthToken)
+
+	_, err = c.send(req, "importing snapshot", false, true)
+	if errors.KindOf(err) == errors.KindConnection {
+		return err
+	}
+
+	// Check for errors from the multipart writer goroutine.
+	if pipeErr := <-errCh; pipeErr != nil {
+		return fmt.Errorf("failed to write multipart form: %w", pipeErr)
+	}
+	return err
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package connectors
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// GlobalsHeadersKey is the operationsHeaders entry applying to every operation.
+const GlobalsHeadersKey = "globals"
+
+// ParseFilteredOperations parses the --filteredOperations value: a JSON or
+// YAML list of operation names, inline or from a file with `@path`.
+func ParseFilteredOperations(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var operations []string
+	if err := decodeTestOption("filteredOperations", value, &operations); err != nil {
+		return nil, err
+	}
+	if len(operations) == 0 {
+		return nil, errors.Wrapf(errors.KindUsage, "--filteredOperations must list at least one operation")
+	}
+	for i, op := range operations {
+		if strings.TrimSpace(op) == "" {
+			return nil, errors.Wrapf(errors.KindUsage, "--filteredOperations: operation #%d is empty", i+1)
+		}
+	}
+	return operations, nil
+}
+
+// ParseOperationsHeaders parses the --operationsHeaders value: a JSON or YAML
+// map of operation name (or "globals") to headers, inline or from `@path`.
+func ParseOperationsHeaders(value string) (map[string][]HeaderDTO, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var headers map[string][]HeaderDTO
+	if err := decodeTestOption("operationsHeaders", value, &headers); err != nil {
+		return nil, err
+	}
+	for _, operation := range sortedHeaderKeys(headers) {
+		for i, header := range headers[operation] {
+			if strings.TrimSpace(header.Name) == "" {
+				return nil, errors.Wrapf(errors.KindUsage, "--operationsHeaders: header #%d of %q has no name", i+1, operation)
+			}
+			if header.Values == "" {
+				return nil, errors.Wrapf(errors.KindUsage, "--operationsHeaders: header %q of %q has no values", header.Name, operation)
+			}
+		}
+	}
+	return headers, nil
+}
+
+// ParseOAuth2Context parses the --oAuth2Context value, inline or from `@path`,
+// and checks the fields required by its grant type are present.
+func ParseOAuth2Context(value string) (*OAuth2ClientContext, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var oContext OAuth2ClientContext
+	if err := decodeTestOption("oAuth2Context", value, &oContext); err != nil {
+		return nil, err
+	}
+	if !grantTypeChoices[oContext.GrantType] {
+		return nil, errors.Wrapf(errors.KindUsage, "--oAuth2Context: grantType %q is not supported, use one of CLIENT_CREDENTIALS, PASSWORD, REFRESH_TOKEN", oContext.GrantType)
+	}
+
+	required := map[string]string{"clientId": oContext.ClientId, "clientSecret": oContext.ClientSecret, "tokenUri": oContext.TokenURI}
+	switch oContext.GrantType {
+	case "PASSWORD":
+		required["username"] = oContext.Username
+		required["password"] = oContext.Password
+	case "REFRESH_TOKEN":
+		required["refreshToken"] = oContext.RefreshToken
+	}
+	var missing []string
+	for field, v := range required {
+		if v == "" {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, errors.Wrapf(errors.KindUsage, "--oAuth2Context: %s grant type requires %s", oContext.GrantType, strings.Join(missing, ", "))
+	}
+	return &oContext, nil
+}
+
+// CheckTestOperations rejects filtered operations and operations headers that
+// do not name an operation of the service, before a test is launched.
+func CheckTestOperations(service *Service, filteredOperations []string, operationsHeaders map[string][]HeaderDTO) error {
+	known := make(map[string]bool, len(service.Operations))
+	names := make([]string, 0, len(service.Operations))
+	for _, op := range service.Operations {
+		known[op.Name] = true
+		names = append(names, op.Name)
+	}
+
+	for _, op := range filteredOperations {
+		if !known[op] {
+			return errors.Wrapf(errors.KindUsage, "--filteredOperations: unknown operation %q for %s:%s (known operations: %s)",
+				op, service.Name, service.Version, strings.Join(names, ", "))
+		}
+	}
+	for _, op := range sortedHeaderKeys(operationsHeaders) {
+		if op != GlobalsHeadersKey && !known[op] {
+			return errors.Wrapf(errors.KindUsage, "--operationsHeaders: unknown operation %q for %s:%s (known operations: %s)",
+				op, service.Name, service.Version, strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
+
+// decodeTestOption decodes a JSON or YAML value (or `@file` content) into
+// target, rejecting unknown fields so that typos don't go unnoticed.
+func decodeTestOption(flag, value string, target interface{}) error {
+	data := []byte(value)
+	source := "value"
+	if strings.HasPrefix(value, "@") {
+		source = value[1:]
+		var err error
+		if data, err = os.ReadFile(source); err != nil {
+			return errors.Wrapf(errors.KindUsage, "--%s: cannot read %s: %v", flag, source, err)
+		}
+	}
+
+	// YAML is a superset of JSON: decode generically, then re-encode as JSON
+	// so the json tags of the target types apply.
+	var generic interface{}
+	if err := yaml.Unmarshal(data, &generic); err != nil {
+		return errors.Wrapf(errors.KindUsage, "--%s: %s is neither valid JSON nor YAML: %v", flag, source, err)
+	}
+	normalized, err := json.Marshal(generic)
+	if err != nil {
+		return errors.Wrapf(errors.KindUsage, "--%s: %s cannot be converted to JSON: %v", flag, source, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(normalized))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		return errors.Wrapf(errors.KindUsage, "--%s: invalid %s: %v", flag, source, err)
+	}
+	return nil
+}
+
+func sortedHeaderKeys(headers map[string][]HeaderDTO) []string {
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
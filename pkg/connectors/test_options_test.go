@@ -0,0 +1,93 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package connectors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilteredOperations(t *testing.T) {
+	ops, err := ParseFilteredOperations(`["GET /orders", "POST /orders"]`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GET /orders", "POST /orders"}, ops)
+
+	ops, err = ParseFilteredOperations("- GET /orders\n")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GET /orders"}, ops)
+
+	for _, invalid := range []string{`["GET /orders"`, `[]`, `[""]`, `{"op": "GET /orders"}`} {
+		_, err := ParseFilteredOperations(invalid)
+		assert.Equal(t, errors.KindUsage, errors.KindOf(err), "value %q should be a usage error", invalid)
+	}
+}
+
+func TestParseOperationsHeadersFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "headers.yaml")
+	content := "globals:\n  - name: x-api-key\n    values: secret\nGET /orders:\n  - name: Accept\n    values: application/json\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	headers, err := ParseOperationsHeaders("@" + path)
+	require.NoError(t, err)
+	assert.Equal(t, []HeaderDTO{{Name: "x-api-key", Values: "secret"}}, headers["globals"])
+	assert.Equal(t, []HeaderDTO{{Name: "Accept", Values: "application/json"}}, headers["GET /orders"])
+}
+
+func TestParseOperationsHeadersRejectsTypos(t *testing.T) {
+	_, err := ParseOperationsHeaders(`{"globals": [{"nmae": "x-api-key", "values": "secret"}]}`)
+	require.Error(t, err)
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+	assert.Contains(t, err.Error(), `unknown field "nmae"`)
+
+	_, err = ParseOperationsHeaders("@does-not-exist.json")
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+}
+
+func TestParseOAuth2Context(t *testing.T) {
+	oContext, err := ParseOAuth2Context(`{"clientId":"id","clientSecret":"secret","tokenUri":"https://idp/token","grantType":"CLIENT_CREDENTIALS"}`)
+	require.NoError(t, err)
+	assert.Equal(t, "CLIENT_CREDENTIALS", oContext.GrantType)
+
+	_, err = ParseOAuth2Context(`{"clientId":"id","clientSecret":"secret","tokenUri":"https://idp/token","grantType":"IMPLICIT"}`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `grantType "IMPLICIT" is not supported`)
+
+	_, err = ParseOAuth2Context(`{"clientId":"id","clientSecret":"secret","tokenUri":"https://idp/token","grantType":"PASSWORD","username":"admin"}`)
+	require.Error(t, err)
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+	assert.Contains(t, err.Error(), "PASSWORD grant type requires password")
+}
+
+func TestCheckTestOperations(t *testing.T) {
+	service := &Service{Name: "Orders", Version: "1.0", Operations: []Operation{{Name: "GET /orders"}, {Name: "POST /orders"}}}
+
+	require.NoError(t, CheckTestOperations(service, []string{"GET /orders"},
+		map[string][]HeaderDTO{GlobalsHeadersKey: {{Name: "a", Values: "b"}}, "POST /orders": {{Name: "a", Values: "b"}}}))
+
+	err := CheckTestOperations(service, []string{"GET /order"}, nil)
+	require.Error(t, err)
+	assert.Equal(t, errors.KindUsage, errors.KindOf(err))
+	assert.Contains(t, err.Error(), `unknown operation "GET /order" for Orders:1.0`)
+
+	err = CheckTestOperations(service, nil, map[string][]HeaderDTO{"DELETE /orders": {{Name: "a", Values: "b"}}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--operationsHeaders")
+}
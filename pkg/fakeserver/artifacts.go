@@ -0,0 +1,163 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package fakeserver
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/microcks/microcks-cli/pkg/mockserver"
+)
+
+func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeText(w, http.StatusBadRequest, "Missing artifact file: %v", err)
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		writeText(w, http.StatusBadRequest, "Cannot read artifact file: %v", err)
+		return
+	}
+	mainArtifact := r.FormValue("mainArtifact") != "false"
+
+	s.importArtifact(w, Artifact{
+		Name:         header.Filename,
+		MainArtifact: mainArtifact,
+		Content:      content,
+	})
+}
+
+func (s *Server) handleDownload(w http.ResponseWriter, r *http.Request) {
+	artifactURL := r.FormValue("url")
+	if artifactURL == "" {
+		writeText(w, http.StatusBadRequest, "Missing url parameter")
+		return
+	}
+
+	content, err := s.fetchRemote(artifactURL)
+	if err != nil {
+		writeText(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	s.importArtifact(w, Artifact{
+		Name:         remoteName(artifactURL),
+		URL:          artifactURL,
+		MainArtifact: r.FormValue("mainArtifact") != "false",
+		Secret:       r.FormValue("secret"),
+		Content:      content,
+	})
+}
+
+// importArtifact parses an artifact, registers or completes the service it
+// defines and answers like Microcks does: 201 with `name:version`.
+func (s *Server) importArtifact(w http.ResponseWriter, artifact Artifact) {
+	stored, err := s.storeArtifact(artifact)
+	if err != nil {
+		writeText(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	writeText(w, http.StatusCreated, "%s", stored.Ref())
+}
+
+// storeArtifact parses an artifact and registers or completes the service it
+// defines.
+func (s *Server) storeArtifact(artifact Artifact) (Service, error) {
+	svc, err := mockserver.Parse(artifact.Content)
+	if err != nil {
+		return Service{}, fmt.Errorf("%s is not a supported artifact: %v", artifact.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stored *Service
+	if artifact.MainArtifact {
+		stored = s.upsertService(svc)
+	} else {
+		stored = s.findService(svc.Ref())
+		if stored == nil {
+			return Service{}, fmt.Errorf("No main artifact has been imported for %s", svc.Ref())
+		}
+		mockserver.MergeExamples(stored, svc)
+	}
+	artifact.ServiceID = stored.ID
+	s.artifacts = append(s.artifacts, artifact)
+	return *stored, nil
+}
+
+// fetchRemote returns the content registered with AddRemoteArtifact for url,
+// or fetches it over HTTP.
+func (s *Server) fetchRemote(artifactURL string) ([]byte, error) {
+	s.mu.Lock()
+	content, known := s.remoteArtifacts[artifactURL]
+	s.mu.Unlock()
+	if known {
+		return content, nil
+	}
+
+	resp, err := http.Get(artifactURL)
+	if err != nil {
+		return nil, fmt.Errorf("Exception while retrieving remote item %s: %v", artifactURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Exception while retrieving remote item %s: HTTP %d", artifactURL, resp.StatusCode)
+	}
+	if content, err = io.ReadAll(resp.Body); err != nil {
+		return nil, fmt.Errorf("Exception while retrieving remote item %s: %v", artifactURL, err)
+	}
+	return content, nil
+}
+
+func remoteName(artifactURL string) string {
+	if i := strings.LastIndex(artifactURL, "/"); i >= 0 {
+		return artifactURL[i+1:]
+	}
+	return artifactURL
+}
+
+func stringField(section map[string]interface{}, name string) string {
+	switch v := section[name].(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,181 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package fakeserver
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ImportJob is a Microcks importer job. The fake never schedules imports:
+// they only run on activate and start.
+type ImportJob struct {
+	ID                             string       `json:"id"`
+	Name                           string       `json:"name"`
+	RepositoryURL                  string       `json:"repositoryUrl"`
+	MainArtifact                   bool         `json:"mainArtifact"`
+	RepositoryDisableSSLValidation bool         `json:"repositoryDisableSSLValidation"`
+	Frequency                      string       `json:"frequency,omitempty"`
+	CreatedDate                    int64        `json:"createdDate,omitempty"`
+	LastImportDate                 int64        `json:"lastImportDate,omitempty"`
+	LastImportError                string       `json:"lastImportError,omitempty"`
+	Active                         bool         `json:"active"`
+	Metadata                       *Metadata    `json:"metadata,omitempty"`
+	SecretRef                      *SecretRef   `json:"secretRef,omitempty"`
+	ServiceRefs                    []ServiceRef `json:"serviceRefs,omitempty"`
+}
+
+// SecretRef references a Secret.
+type SecretRef struct {
+	SecretID string `json:"secretId"`
+	Name     string `json:"name"`
+}
+
+// ServiceRef references a Service imported by a job.
+type ServiceRef struct {
+	ServiceID string `json:"serviceId"`
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+}
+
+// ImportJobs returns a snapshot of the known importer jobs.
+func (s *Server) ImportJobs() []ImportJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]ImportJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, *job)
+	}
+	return jobs
+}
+
+func (s *Server) handleListJobs(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	page, size := pageParams(r)
+	jobs := make([]ImportJob, 0, size)
+	for i := page * size; i < len(s.jobs) && i < (page+1)*size; i++ {
+		jobs = append(jobs, *s.jobs[i])
+	}
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
+	var job ImportJob
+	if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
+		writeText(w, http.StatusBadRequest, "Malformed importer job: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job.ID = s.nextID()
+	job.CreatedDate = s.now().UnixMilli()
+	s.jobs = append(s.jobs, &job)
+	writeJSON(w, http.StatusCreated, job)
+}
+
+func (s *Server) handleDeleteJob(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.findJob(r.PathValue("id"))
+	if i < 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	s.jobs = append(s.jobs[:i], s.jobs[i+1:]...)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleActivateJob(w http.ResponseWriter, r *http.Request) {
+	s.changeJob(w, r.PathValue("id"), true, true)
+}
+
+// handleStartJob imports right away; like Microcks, it also reactivates the job.
+func (s *Server) handleStartJob(w http.ResponseWriter, r *http.Request) {
+	s.changeJob(w, r.PathValue("id"), true, true)
+}
+
+func (s *Server) handleStopJob(w http.ResponseWriter, r *http.Request) {
+	s.changeJob(w, r.PathValue("id"), false, false)
+}
+
+// changeJob sets a job active state and, if asked, runs its import now,
+// recording the outcome like Microcks does.
+func (s *Server) changeJob(w http.ResponseWriter, id string, active, runImport bool) {
+	s.mu.Lock()
+	i := s.findJob(id)
+	if i < 0 {
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	job := s.jobs[i]
+	job.Active = active
+	s.mu.Unlock()
+
+	if runImport {
+		s.runJob(job)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, job)
+}
+
+func (s *Server) runJob(job *ImportJob) {
+	s.mu.Lock()
+	artifactURL, mainArtifact := job.RepositoryURL, job.MainArtifact
+	var secret string
+	if job.SecretRef != nil {
+		secret = job.SecretRef.Name
+	}
+	s.mu.Unlock()
+
+	content, err := s.fetchRemote(artifactURL)
+	var svc Service
+	if err == nil {
+		svc, err = s.storeArtifact(Artifact{
+			Name:         remoteName(artifactURL),
+			URL:          artifactURL,
+			MainArtifact: mainArtifact,
+			Secret:       secret,
+			Content:      content,
+		})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job.LastImportDate = s.now().UnixMilli()
+	if err != nil {
+		job.LastImportError = err.Error()
+		return
+	}
+	job.LastImportError = ""
+	job.ServiceRefs = []ServiceRef{{ServiceID: svc.ID, Name: svc.Name, Version: svc.Version}}
+}
+
+// findJob returns the index of a job by ID, -1 if unknown. Callers hold mu.
+func (s *Server) findJob(id string) int {
+	for i, job := range s.jobs {
+		if job.ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,188 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package fakeserver
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ConformanceMetric is the test conformance index of a service.
+type ConformanceMetric struct {
+	ServiceID        string             `json:"serviceId"`
+	MaxPossibleScore float64            `json:"maxPossibleScore"`
+	CurrentScore     float64            `json:"currentScore"`
+	LastUpdateDay    string             `json:"lastUpdateDay"`
+	LatestTrend      string             `json:"latestTrend"`
+	LatestScores     map[string]float64 `json:"latestScores"`
+}
+
+// SetConformanceMetric overrides the conformance metric of a service,
+// referenced by ID or `name:version`.
+func (s *Server) SetConformanceMetric(serviceRef string, metric ConformanceMetric) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conformance[serviceRef] = metric
+}
+
+// handleGetConformance serves the configured metric, or one derived from the
+// latest completed test: like Microcks, only operations with examples score.
+func (s *Server) handleGetConformance(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	svc := s.findService(r.PathValue("serviceId"))
+	if svc == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	for _, ref := range []string{svc.Ref(), svc.ID} {
+		if metric, ok := s.conformance[ref]; ok {
+			metric.ServiceID = svc.ID
+			writeJSON(w, http.StatusOK, metric)
+			return
+		}
+	}
+
+	var latest *testResult
+	for i := len(s.tests) - 1; i >= 0 && latest == nil; i-- {
+		if s.tests[i].ServiceID == svc.ID {
+			if result := s.renderTest(s.tests[i]); !result.InProgress {
+				latest = &result
+			}
+		}
+	}
+	if latest == nil || len(svc.Operations) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var withExamples, passed int
+	for _, op := range svc.Operations {
+		if len(op.Examples) == 0 {
+			continue
+		}
+		withExamples++
+		if slices.ContainsFunc(latest.TestCaseResults, func(tc testCaseResult) bool { return tc.OperationName == op.Name && tc.Success }) {
+			passed++
+		}
+	}
+	day := s.now().Format("20060102")
+	score := float64(passed) * 100 / float64(len(svc.Operations))
+	writeJSON(w, http.StatusOK, ConformanceMetric{
+		ServiceID:        svc.ID,
+		MaxPossibleScore: float64(withExamples) * 100 / float64(len(svc.Operations)),
+		CurrentScore:     score,
+		LastUpdateDay:    day,
+		LatestTrend:      "STABLE",
+		LatestScores:     map[string]float64{day: score},
+	})
+}
+
+// DailyInvocationStatistic is the mock invocation counts of a day, for a
+// service or for all of them.
+type DailyInvocationStatistic struct {
+	Day            string           `json:"day"`
+	ServiceName    string           `json:"serviceName,omitempty"`
+	ServiceVersion string           `json:"serviceVersion,omitempty"`
+	DailyCount     int64            `json:"dailyCount"`
+	HourlyCount    map[string]int64 `json:"hourlyCount"`
+	MinuteCount    map[string]int64 `json:"minuteCount"`
+}
+
+type invocation struct {
+	serviceName    string
+	serviceVersion string
+	at             time.Time
+}
+
+// AddInvocations records count invocations of the mocks of a service at a
+// given time, on top of the ones served by the fake itself.
+func (s *Server) AddInvocations(serviceName, serviceVersion string, at time.Time, count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < count; i++ {
+		s.invocations = append(s.invocations, invocation{serviceName, serviceVersion, at})
+	}
+}
+
+// recordInvocation records an invocation of a mock now. Callers hold mu.
+func (s *Server) recordInvocation(svc *Service) {
+	s.invocations = append(s.invocations, invocation{svc.Name, svc.Version, s.now()})
+}
+
+// invocationStats aggregates the invocations of a day, of all services when
+// name is empty. Callers hold mu.
+func (s *Server) invocationStats(day, name, version string) DailyInvocationStatistic {
+	stats := DailyInvocationStatistic{Day: day, ServiceName: name, ServiceVersion: version, HourlyCount: map[string]int64{}, MinuteCount: map[string]int64{}}
+	for _, inv := range s.invocations {
+		if inv.at.Format("20060102") != day || (name != "" && (inv.serviceName != name || inv.serviceVersion != version)) {
+			continue
+		}
+		stats.DailyCount++
+		stats.HourlyCount[strconv.Itoa(inv.at.Hour())]++
+		stats.MinuteCount[strconv.Itoa(inv.at.Hour()*60+inv.at.Minute())]++
+	}
+	return stats
+}
+
+func (s *Server) handleGetInvocations(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, s.invocationStats(s.statsDay(r), r.PathValue("serviceName"), r.PathValue("serviceVersion")))
+}
+
+func (s *Server) handleGetGlobalInvocations(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, s.invocationStats(s.statsDay(r), "", ""))
+}
+
+func (s *Server) handleGetTopInvocations(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	day := s.statsDay(r)
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	seen := map[[2]string]bool{}
+	top := []DailyInvocationStatistic{}
+	for _, inv := range s.invocations {
+		key := [2]string{inv.serviceName, inv.serviceVersion}
+		if inv.at.Format("20060102") == day && !seen[key] {
+			seen[key] = true
+			top = append(top, s.invocationStats(day, inv.serviceName, inv.serviceVersion))
+		}
+	}
+	sort.SliceStable(top, func(i, j int) bool { return top[i].DailyCount > top[j].DailyCount })
+	if len(top) > limit {
+		top = top[:limit]
+	}
+	writeJSON(w, http.StatusOK, top)
+}
+
+// statsDay reads the day query parameter, defaulting to today.
+func (s *Server) statsDay(r *http.Request) string {
+	if day := r.URL.Query().Get("day"); day != "" {
+		return day
+	}
+	return s.now().Format("20060102")
+}
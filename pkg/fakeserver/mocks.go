@@ -0,0 +1,40 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package fakeserver
+
+import (
+	"net/http"
+
+	"github.com/microcks/microcks-cli/pkg/mockserver"
+)
+
+// handleRestMock answers REST mocks from the examples of the operations,
+// and counts the invocation.
+func (s *Server) handleRestMock(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	svc := s.findService(r.PathValue("service") + ":" + r.PathValue("version"))
+	if svc == nil {
+		svc = s.findService(mockserver.ServiceName(r.PathValue("service")) + ":" + r.PathValue("version"))
+	}
+	if svc == nil {
+		writeText(w, http.StatusNotFound, "No service %s:%s", r.PathValue("service"), r.PathValue("version"))
+		return
+	}
+	s.recordInvocation(svc)
+	mockserver.Dispatch(w, r, *svc, "/"+r.PathValue("path"))
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package fakeserver
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type resource struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Content        string `json:"content"`
+	Type           string `json:"type"`
+	ServiceID      string `json:"serviceId"`
+	SourceArtifact string `json:"sourceArtifact"`
+}
+
+// handleListServiceResources lists the artifacts imported for a service as
+// Microcks resources, in import order. Re-imports replace older content.
+func (s *Server) handleListServiceResources(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resources := []resource{}
+	svc := s.findService(r.PathValue("serviceId"))
+	if svc == nil {
+		writeJSON(w, http.StatusOK, resources)
+		return
+	}
+	seen := map[string]int{}
+	for i, artifact := range s.artifacts {
+		if artifact.ServiceID != svc.ID {
+			continue
+		}
+		res := resource{
+			ID:             fmt.Sprintf("%s-%d", svc.ID, i),
+			Name:           artifact.Name,
+			Content:        string(artifact.Content),
+			Type:           resourceType(artifact.Content),
+			ServiceID:      svc.ID,
+			SourceArtifact: artifact.Name,
+		}
+		if j, ok := seen[artifact.Name]; ok {
+			resources[j] = res
+			continue
+		}
+		seen[artifact.Name] = len(resources)
+		resources = append(resources, res)
+	}
+	writeJSON(w, http.StatusOK, resources)
+}
+
+// resourceType returns the Microcks ResourceType of an artifact.
+func resourceType(content []byte) string {
+	var doc map[string]interface{}
+	_ = yaml.Unmarshal(content, &doc)
+	info, _ := doc["info"].(map[string]interface{})
+	switch {
+	case doc["openapi"] != nil || doc["swagger"] != nil:
+		return "OPEN_API_SPEC"
+	case doc["asyncapi"] != nil:
+		return "ASYNC_API_SPEC"
+	case doc["kind"] == "APIMetadata":
+		return "API_METADATA"
+	case doc["kind"] == "APIExamples":
+		return "API_EXAMPLES"
+	case info != nil && (info["_postman_id"] != nil || strings.Contains(stringField(info, "schema"), "postman")):
+		return "POSTMAN_COLLECTION"
+	}
+	return "JSON_SCHEMA"
+}
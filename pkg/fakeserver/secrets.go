@@ -0,0 +1,135 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package fakeserver
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Secret is a Microcks Secret.
+type Secret struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Username    string `json:"username,omitempty"`
+	Password    string `json:"password,omitempty"`
+	Token       string `json:"token,omitempty"`
+	TokenHeader string `json:"tokenHeader,omitempty"`
+	CaCertPem   string `json:"caCertPem,omitempty"`
+}
+
+// AddSecret registers a secret. An empty ID is generated; the stored copy is
+// returned.
+func (s *Server) AddSecret(secret Secret) Secret {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if secret.ID == "" {
+		secret.ID = s.nextID()
+	}
+	s.secrets = append(s.secrets, &secret)
+	return secret
+}
+
+// Secrets returns a snapshot of the known secrets.
+func (s *Server) Secrets() []Secret {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secrets := make([]Secret, 0, len(s.secrets))
+	for _, secret := range s.secrets {
+		secrets = append(secrets, *secret)
+	}
+	return secrets
+}
+
+func (s *Server) handleListSecrets(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	page, size := pageParams(r)
+	secrets := make([]Secret, 0, size)
+	for i := page * size; i < len(s.secrets) && i < (page+1)*size; i++ {
+		secrets = append(secrets, *s.secrets[i])
+	}
+	writeJSON(w, http.StatusOK, secrets)
+}
+
+func (s *Server) handleCreateSecret(w http.ResponseWriter, r *http.Request) {
+	var secret Secret
+	if err := json.NewDecoder(r.Body).Decode(&secret); err != nil {
+		writeText(w, http.StatusBadRequest, "Malformed secret: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	secret.ID = s.nextID()
+	s.secrets = append(s.secrets, &secret)
+	writeJSON(w, http.StatusCreated, secret)
+}
+
+func (s *Server) handleGetSecret(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := s.findSecret(r.PathValue("id")); i >= 0 {
+		writeJSON(w, http.StatusOK, s.secrets[i])
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+func (s *Server) handleUpdateSecret(w http.ResponseWriter, r *http.Request) {
+	var secret Secret
+	if err := json.NewDecoder(r.Body).Decode(&secret); err != nil {
+		writeText(w, http.StatusBadRequest, "Malformed secret: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.findSecret(r.PathValue("id"))
+	if i < 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	secret.ID = s.secrets[i].ID
+	s.secrets[i] = &secret
+	writeJSON(w, http.StatusOK, secret)
+}
+
+func (s *Server) handleDeleteSecret(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.findSecret(r.PathValue("id"))
+	if i < 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	s.secrets = append(s.secrets[:i], s.secrets[i+1:]...)
+	w.WriteHeader(http.StatusOK)
+}
+
+// findSecret returns the index of a secret by ID, -1 if unknown. Callers hold mu.
+func (s *Server) findSecret(id string) int {
+	for i, secret := range s.secrets {
+		if secret.ID == id {
+			return i
+		}
+	}
+	return -1
+}
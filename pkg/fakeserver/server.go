@@ -0,0 +1,381 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package fakeserver provides an in-process, in-memory stand-in for the
+// Microcks REST API. It answers the endpoints the CLI talks to with realistic
+// payloads so that tests and offline demos don't need a real Microcks.
+//
+// A Server is a plain http.Handler: wrap it with httptest.NewServer in Go
+// tests, or serve it with net/http (see `microcks fake-server`).
+package fakeserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/microcks/microcks-cli/pkg/mockserver"
+)
+
+// Service, Metadata, Operation and Exchange are the ones of mockserver, so
+// that the fake answers mocks like `microcks serve` does.
+type (
+	Service   = mockserver.Service
+	Metadata  = mockserver.Metadata
+	Operation = mockserver.Operation
+	Exchange  = mockserver.Exchange
+)
+
+// Artifact records an artifact received through upload or download.
+type Artifact struct {
+	Name         string
+	URL          string
+	MainArtifact bool
+	Secret       string
+	ServiceID    string
+	Content      []byte
+}
+
+// Server is an in-memory fake of the Microcks REST API. The zero value is not
+// usable; build one with New. All methods are safe for concurrent use.
+type Server struct {
+	mu sync.Mutex
+
+	keycloakEnabled bool
+	keycloakURL     string
+	keycloakRealm   string
+
+	services        []*Service
+	artifacts       []Artifact
+	remoteArtifacts map[string][]byte
+
+	tests          []*testRun
+	outcomes       map[string]TestOutcome
+	defaultOutcome TestOutcome
+	conformance    map[string]ConformanceMetric
+	invocations    []invocation
+
+	secrets []*Secret
+	jobs    []*ImportJob
+
+	seq int
+	now func() time.Time
+	mux *http.ServeMux
+}
+
+// New builds a fake server with Keycloak disabled, no services and tests
+// that succeed immediately.
+func New() *Server {
+	s := &Server{
+		remoteArtifacts: map[string][]byte{},
+		outcomes:        map[string]TestOutcome{},
+		conformance:     map[string]ConformanceMetric{},
+		defaultOutcome:  TestOutcome{Success: true},
+		now:             time.Now,
+		mux:             http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("GET /api/keycloak/config", s.handleKeycloakConfig)
+	s.mux.HandleFunc("POST /api/artifact/upload", s.handleUpload)
+	s.mux.HandleFunc("POST /api/artifact/download", s.handleDownload)
+	s.mux.HandleFunc("GET /api/services", s.handleListServices)
+	s.mux.HandleFunc("GET /api/services/{id}", s.handleGetService)
+	s.mux.HandleFunc("PUT /api/services/{id}/metadata", s.handleUpdateServiceMetadata)
+	s.mux.HandleFunc("PUT /api/services/{id}/operation", s.handleUpdateOperation)
+	s.mux.HandleFunc("POST /api/tests", s.handleCreateTest)
+	s.mux.HandleFunc("GET /api/tests/{id}", s.handleGetTest)
+	s.mux.HandleFunc("GET /api/tests/service/{serviceId}", s.handleListServiceTests)
+	s.mux.HandleFunc("GET /api/metrics/conformance/service/{serviceId}", s.handleGetConformance)
+	s.mux.HandleFunc("GET /api/metrics/invocations/global", s.handleGetGlobalInvocations)
+	s.mux.HandleFunc("GET /api/metrics/invocations/top", s.handleGetTopInvocations)
+	s.mux.HandleFunc("GET /api/metrics/invocations/{serviceName}/{serviceVersion}", s.handleGetInvocations)
+	s.mux.HandleFunc("GET /api/secrets", s.handleListSecrets)
+	s.mux.HandleFunc("POST /api/secrets", s.handleCreateSecret)
+	s.mux.HandleFunc("GET /api/secrets/{id}", s.handleGetSecret)
+	s.mux.HandleFunc("PUT /api/secrets/{id}", s.handleUpdateSecret)
+	s.mux.HandleFunc("DELETE /api/secrets/{id}", s.handleDeleteSecret)
+	s.mux.HandleFunc("GET /api/resources/service/{serviceId}", s.handleListServiceResources)
+	s.mux.HandleFunc("GET /api/export", s.handleExport)
+	s.mux.HandleFunc("POST /api/import", s.handleImport)
+	s.mux.HandleFunc("GET /api/jobs", s.handleListJobs)
+	s.mux.HandleFunc("POST /api/jobs", s.handleCreateJob)
+	s.mux.HandleFunc("DELETE /api/jobs/{id}", s.handleDeleteJob)
+	s.mux.HandleFunc("PUT /api/jobs/{id}/activate", s.handleActivateJob)
+	s.mux.HandleFunc("PUT /api/jobs/{id}/start", s.handleStartJob)
+	s.mux.HandleFunc("PUT /api/jobs/{id}/stop", s.handleStopJob)
+	s.mux.HandleFunc("/rest/{service}/{version}/{path...}", s.handleRestMock)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// EnableKeycloak makes the Keycloak config endpoint report an enabled realm.
+func (s *Server) EnableKeycloak(authServerURL, realm string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keycloakEnabled = true
+	s.keycloakURL = authServerURL
+	s.keycloakRealm = realm
+}
+
+// AddService registers a service as if an artifact defining it had been
+// imported. An empty ID is generated; the stored copy is returned.
+func (s *Server) AddService(svc Service) Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.upsertService(svc)
+}
+
+// ImportArtifact registers or completes the service an artifact defines,
+// like an upload does, and returns the stored copy.
+func (s *Server) ImportArtifact(artifact Artifact) (Service, error) {
+	return s.storeArtifact(artifact)
+}
+
+// Services returns a snapshot of the known services.
+func (s *Server) Services() []Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	services := make([]Service, 0, len(s.services))
+	for _, svc := range s.services {
+		services = append(services, *svc)
+	}
+	return services
+}
+
+// Artifacts returns the artifacts received so far, in import order.
+func (s *Server) Artifacts() []Artifact {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Artifact(nil), s.artifacts...)
+}
+
+// SetClock makes the fake read the current time from now, e.g. to record
+// invocations on a fixed day in tests.
+func (s *Server) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+// AddRemoteArtifact serves content for url on artifact/download, so that
+// import-url works offline. Unknown URLs are fetched over HTTP.
+func (s *Server) AddRemoteArtifact(url string, content []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remoteArtifacts[url] = content
+}
+
+func (s *Server) nextID() string {
+	s.seq++
+	// Mimic the 24 hex digits of the MongoDB ObjectIds Microcks hands out.
+	return fmt.Sprintf("%024x", s.seq)
+}
+
+// findService looks a service up by ID or by `name:version`. Callers hold mu.
+func (s *Server) findService(idOrRef string) *Service {
+	for _, svc := range s.services {
+		if svc.ID == idOrRef || svc.Ref() == idOrRef {
+			return svc
+		}
+	}
+	return nil
+}
+
+// upsertService merges svc into the known services. Callers hold mu.
+func (s *Server) upsertService(svc Service) *Service {
+	if existing := s.findService(svc.Ref()); existing != nil {
+		if len(svc.Operations) > 0 {
+			existing.Operations = svc.Operations
+		}
+		if svc.Type != "" {
+			existing.Type = svc.Type
+		}
+		return existing
+	}
+	if svc.ID == "" {
+		svc.ID = s.nextID()
+	}
+	if svc.Operations == nil {
+		svc.Operations = []Operation{}
+	}
+	stored := svc
+	s.services = append(s.services, &stored)
+	return &stored
+}
+
+func (s *Server) handleKeycloakConfig(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"enabled":         s.keycloakEnabled,
+		"realm":           s.keycloakRealm,
+		"resource":        "microcks-app-js",
+		"auth-server-url": s.keycloakURL,
+	})
+}
+
+func (s *Server) handleListServices(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	page, size := pageParams(r)
+	services := make([]Service, 0, size)
+	for i := page * size; i < len(s.services) && i < (page+1)*size; i++ {
+		services = append(services, *s.services[i])
+	}
+	writeJSON(w, http.StatusOK, services)
+}
+
+func (s *Server) handleGetService(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	svc := s.findService(r.PathValue("id"))
+	if svc == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.URL.Query().Get("messages") != "true" {
+		writeJSON(w, http.StatusOK, svc)
+		return
+	}
+
+	// The service view pairs the request and response of each example.
+	messages := map[string][]map[string]interface{}{}
+	for _, op := range svc.Operations {
+		for _, example := range op.Examples {
+			messages[op.Name] = append(messages[op.Name], renderExchange(op, example))
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"service": svc, "messagesMap": messages})
+}
+
+func (s *Server) handleUpdateServiceMetadata(w http.ResponseWriter, r *http.Request) {
+	var metadata Metadata
+	if err := json.NewDecoder(r.Body).Decode(&metadata); err != nil {
+		writeText(w, http.StatusBadRequest, "Malformed metadata: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	svc := s.findService(r.PathValue("id"))
+	if svc == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	svc.Metadata = &metadata
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleUpdateOperation(w http.ResponseWriter, r *http.Request) {
+	var override struct {
+		Dispatcher      string `json:"dispatcher"`
+		DispatcherRules string `json:"dispatcherRules"`
+		DefaultDelay    int64  `json:"defaultDelay"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&override); err != nil {
+		writeText(w, http.StatusBadRequest, "Malformed operation override: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	svc := s.findService(r.PathValue("id"))
+	if svc == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	for i, op := range svc.Operations {
+		if op.Name == r.URL.Query().Get("operationName") {
+			svc.Operations[i].Dispatcher = override.Dispatcher
+			svc.Operations[i].DispatcherRules = override.DispatcherRules
+			svc.Operations[i].DefaultDelay = override.DefaultDelay
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+func renderExchange(op Operation, example string) map[string]interface{} {
+	request := map[string]interface{}{"name": example, "operationId": op.Name}
+	response := map[string]interface{}{"name": example, "operationId": op.Name}
+	for _, e := range op.Exchanges {
+		if e.Name != example {
+			continue
+		}
+		params := []map[string]string{}
+		for _, name := range sortedKeys(stringMap(e.Parameters)) {
+			params = append(params, map[string]string{"name": name, "value": e.Parameters[name]})
+		}
+		request["content"] = e.RequestBody
+		request["queryParameters"] = params
+		response["content"] = e.ResponseBody
+		response["mediaType"] = e.MediaType
+		response["status"] = strconv.Itoa(e.Status)
+	}
+	return map[string]interface{}{"request": request, "response": response}
+}
+
+func stringMap(m map[string]string) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// maxPageSize bounds the size query parameter of the list endpoints.
+const maxPageSize = 1000
+
+// pageParams reads Microcks' page/size query parameters (defaults 0/20).
+// pageParams reads the page and size query parameters. Both are clamped so
+// that callers can allocate size items and compute (page+1)*size safely.
+func pageParams(r *http.Request) (int, int) {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 0 {
+		page = 0
+	}
+	size, err := strconv.Atoi(r.URL.Query().Get("size"))
+	if err != nil || size <= 0 {
+		size = 20
+	}
+	size = min(size, maxPageSize)
+	page = min(page, math.MaxInt32)
+	return page, size
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeText(w http.ResponseWriter, status int, format string, a ...interface{}) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(strings.TrimSpace(fmt.Sprintf(format, a...))))
+}
@@ -0,0 +1,236 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package fakeserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func upload(t *testing.T, url, fileName string, content []byte, mainArtifact bool) (int, string) {
+	t.Helper()
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", fileName)
+	require.NoError(t, err)
+	_, _ = part.Write(content)
+	if !mainArtifact {
+		require.NoError(t, writer.WriteField("mainArtifact", "false"))
+	}
+	require.NoError(t, writer.Close())
+
+	resp, err := http.Post(url+"/api/artifact/upload", writer.FormDataContentType(), body)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(msg)
+}
+
+func TestUploadRegistersOpenAPIService(t *testing.T) {
+	fake := New()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	content, err := os.ReadFile("../../samples/weather-forecast-openapi.yml")
+	require.NoError(t, err)
+
+	status, msg := upload(t, server.URL, "weather-forecast-openapi.yml", content, true)
+	require.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, "WeatherForecast API:1.1.0", msg)
+
+	services := fake.Services()
+	require.Len(t, services, 1)
+	assert.Equal(t, "REST", services[0].Type)
+	assert.Contains(t, services[0].Operations, Operation{Name: "GET /forecast/{region}", Method: "GET"})
+
+	resp, err := http.Get(server.URL + "/api/services/WeatherForecast%20API:1.1.0")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestUploadCollectsOpenAPIExamples(t *testing.T) {
+	fake := New()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	content, err := os.ReadFile("../../samples/ecommerce-api-openapi.yml")
+	require.NoError(t, err)
+	status, _ := upload(t, server.URL, "ecommerce-api-openapi.yml", content, true)
+	require.Equal(t, http.StatusCreated, status)
+
+	withExamples := 0
+	for _, op := range fake.Services()[0].Operations {
+		if len(op.Examples) > 0 {
+			withExamples++
+		}
+	}
+	assert.Positive(t, withExamples)
+}
+
+func TestUploadSecondaryArtifactRequiresMainOne(t *testing.T) {
+	server := httptest.NewServer(New())
+	defer server.Close()
+
+	content, err := os.ReadFile("../../samples/weather-forecast-postman.json")
+	require.NoError(t, err)
+
+	status, msg := upload(t, server.URL, "weather-forecast-postman.json", content, false)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, msg, "No main artifact")
+}
+
+func TestUploadRejectsUnknownArtifact(t *testing.T) {
+	server := httptest.NewServer(New())
+	defer server.Close()
+
+	status, msg := upload(t, server.URL, "notes.yaml", []byte("hello: world"), true)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, msg, "unknown artifact type")
+}
+
+func TestTestLifecycle(t *testing.T) {
+	fake := New()
+	fake.AddService(Service{
+		Name:    "Orders",
+		Version: "1.0",
+		Operations: []Operation{
+			{Name: "GET /orders", Method: "GET"},
+			{Name: "POST /orders", Method: "POST"},
+		},
+	})
+	fake.SetTestOutcome("Orders:1.0", TestOutcome{
+		Success:          false,
+		InProgressFor:    200 * time.Millisecond,
+		FailedOperations: []string{"POST /orders"},
+	})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/api/tests", "application/json",
+		strings.NewReader(`{"serviceId":"Orders:1.0","testEndpoint":"http://orders","runnerType":"HTTP","timeout":1000}`))
+	require.NoError(t, err)
+	var created testResult
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.True(t, created.InProgress)
+	assert.Equal(t, int32(1), created.TestNumber)
+
+	time.Sleep(250 * time.Millisecond)
+
+	resp, err = http.Get(server.URL + "/api/tests/" + created.ID)
+	require.NoError(t, err)
+	var completed testResult
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&completed))
+	resp.Body.Close()
+	assert.False(t, completed.InProgress)
+	assert.False(t, completed.Success)
+	require.Len(t, completed.TestCaseResults, 2)
+	assert.True(t, completed.TestCaseResults[0].Success)
+	assert.False(t, completed.TestCaseResults[1].Success)
+
+	runs := fake.TestRuns()
+	require.Len(t, runs, 1)
+	assert.Equal(t, "http://orders", runs[0].TestedEndpoint)
+}
+
+func TestCreateTestOnUnknownServiceIsNotFound(t *testing.T) {
+	server := httptest.NewServer(New())
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/api/tests", "application/json", strings.NewReader(`{"serviceId":"Nope:1.0"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestKeycloakConfig(t *testing.T) {
+	fake := New()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	var config map[string]interface{}
+	resp, err := http.Get(server.URL + "/api/keycloak/config")
+	require.NoError(t, err)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&config))
+	resp.Body.Close()
+	assert.Equal(t, false, config["enabled"])
+
+	fake.EnableKeycloak("http://keycloak:8180", "microcks")
+	resp, err = http.Get(server.URL + "/api/keycloak/config")
+	require.NoError(t, err)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&config))
+	resp.Body.Close()
+	assert.Equal(t, true, config["enabled"])
+	assert.Equal(t, "microcks", config["realm"])
+}
+
+func TestListServicesClampsPaging(t *testing.T) {
+	fake := New()
+	fake.AddService(Service{Name: "Orders", Version: "1.0"})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	for _, query := range []string{"?size=99999999999", "?size=99999999&page=99999999999999"} {
+		var services []Service
+		resp, err := http.Get(server.URL + "/api/services" + query)
+		require.NoError(t, err, query)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&services), query)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, query)
+	}
+}
+
+func TestTestBeforeItsCreationHasNoResult(t *testing.T) {
+	fake := New()
+	fake.AddService(Service{
+		Name:       "Orders",
+		Version:    "1.0",
+		Operations: []Operation{{Name: "GET /orders", Method: "GET"}},
+	})
+	fake.SetTestOutcome("Orders:1.0", TestOutcome{Success: true, InProgressFor: time.Minute})
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	fake.SetClock(func() time.Time { return created })
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/api/tests", "application/json",
+		strings.NewReader(`{"serviceId":"Orders:1.0","testEndpoint":"http://orders","runnerType":"HTTP","timeout":1000}`))
+	require.NoError(t, err)
+	var test testResult
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&test))
+	resp.Body.Close()
+
+	fake.SetClock(func() time.Time { return created.Add(-time.Hour) })
+	resp, err = http.Get(server.URL + "/api/tests/" + test.ID)
+	require.NoError(t, err)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&test))
+	resp.Body.Close()
+	assert.True(t, test.InProgress)
+	assert.Empty(t, test.TestCaseResults)
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package fakeserver
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+)
+
+// snapshot mimics the repository export of Microcks: services, the
+// resources (artifacts) defining them and their examples.
+type snapshot struct {
+	Services  []Service          `json:"services"`
+	Resources []snapshotResource `json:"resources"`
+	Requests  []snapshotExample  `json:"requests"`
+}
+
+type snapshotResource struct {
+	Name         string `json:"name"`
+	ServiceID    string `json:"serviceId"`
+	MainArtifact bool   `json:"mainArtifact"`
+	Content      string `json:"content"`
+}
+
+type snapshotExample struct {
+	Name        string `json:"name"`
+	OperationID string `json:"operationId"`
+	ServiceID   string `json:"serviceId"`
+}
+
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := r.URL.Query()["serviceIds"]
+	export := snapshot{Services: []Service{}, Resources: []snapshotResource{}, Requests: []snapshotExample{}}
+	for _, svc := range s.services {
+		if !slices.Contains(ids, svc.ID) {
+			continue
+		}
+		export.Services = append(export.Services, *svc)
+		for _, op := range svc.Operations {
+			for _, example := range op.Examples {
+				export.Requests = append(export.Requests, snapshotExample{Name: example, OperationID: op.Name, ServiceID: svc.ID})
+			}
+		}
+	}
+	for _, artifact := range s.artifacts {
+		if slices.Contains(ids, artifact.ServiceID) {
+			export.Resources = append(export.Resources, snapshotResource{
+				Name: artifact.Name, ServiceID: artifact.ServiceID, MainArtifact: artifact.MainArtifact, Content: string(artifact.Content),
+			})
+		}
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="microcks-repository.json"`)
+	writeJSON(w, http.StatusOK, export)
+}
+
+func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeText(w, http.StatusBadRequest, "Missing snapshot file: %v", err)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeText(w, http.StatusBadRequest, "Cannot read snapshot file: %v", err)
+		return
+	}
+	var imported snapshot
+	if err := json.Unmarshal(data, &imported); err != nil {
+		writeText(w, http.StatusBadRequest, "Malformed snapshot: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Service IDs are kept, like Microcks does, so resources still match.
+	for _, svc := range imported.Services {
+		for i, op := range svc.Operations {
+			for _, example := range imported.Requests {
+				if example.ServiceID == svc.ID && example.OperationID == op.Name {
+					svc.Operations[i].Examples = append(svc.Operations[i].Examples, example.Name)
+				}
+			}
+		}
+		if existing := s.findService(svc.Ref()); existing != nil {
+			*existing = svc
+		} else {
+			stored := svc
+			s.services = append(s.services, &stored)
+		}
+	}
+	for _, resource := range imported.Resources {
+		s.artifacts = append(s.artifacts, Artifact{
+			Name: resource.Name, ServiceID: resource.ServiceID, MainArtifact: resource.MainArtifact, Content: []byte(resource.Content),
+		})
+	}
+	w.WriteHeader(http.StatusCreated)
+}
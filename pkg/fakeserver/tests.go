@@ -0,0 +1,273 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package fakeserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// TestOutcome configures how a test launched on the fake server behaves.
+type TestOutcome struct {
+	// Success is the overall result reported once the test completes.
+	Success bool
+	// InProgressFor is how long the test reports inProgress before completing.
+	// Operations complete progressively over that period.
+	InProgressFor time.Duration
+	// FailedOperations names the operations reported as failed when Success
+	// is false. Empty means every operation fails.
+	FailedOperations []string
+	// ElapsedTimes overrides the per-operation elapsed time (default 10ms).
+	ElapsedTimes map[string]time.Duration
+}
+
+// TestRun is the server-side record of a launched test.
+type TestRun struct {
+	ID                 string
+	ServiceID          string
+	TestedEndpoint     string
+	RunnerType         string
+	SecretName         string
+	Timeout            int64
+	FilteredOperations []string
+	OperationsHeaders  json.RawMessage
+	OAuth2Context      json.RawMessage
+	CreatedAt          time.Time
+}
+
+type testRun struct {
+	TestRun
+	testNumber int32
+	operations []string
+	outcome    TestOutcome
+}
+
+type testCreationRequest struct {
+	ServiceID          string          `json:"serviceId"`
+	TestEndpoint       string          `json:"testEndpoint"`
+	RunnerType         string          `json:"runnerType"`
+	Timeout            int64           `json:"timeout"`
+	SecretName         string          `json:"secretName"`
+	FilteredOperations []string        `json:"filteredOperations"`
+	OperationsHeaders  json.RawMessage `json:"operationsHeaders"`
+	OAuth2Context      json.RawMessage `json:"oAuth2Context"`
+}
+
+type testStepResult struct {
+	Success     bool   `json:"success"`
+	ElapsedTime int32  `json:"elapsedTime"`
+	RequestName string `json:"requestName"`
+	Message     string `json:"message,omitempty"`
+}
+
+type testCaseResult struct {
+	Success         bool             `json:"success"`
+	ElapsedTime     int32            `json:"elapsedTime"`
+	OperationName   string           `json:"operationName"`
+	TestStepResults []testStepResult `json:"testStepResults"`
+}
+
+type testResult struct {
+	ID              string           `json:"id"`
+	Version         int32            `json:"version"`
+	TestNumber      int32            `json:"testNumber"`
+	TestDate        int64            `json:"testDate"`
+	TestedEndpoint  string           `json:"testedEndpoint"`
+	ServiceID       string           `json:"serviceId"`
+	RunnerType      string           `json:"runnerType"`
+	Timeout         int64            `json:"timeout"`
+	ElapsedTime     int32            `json:"elapsedTime"`
+	Success         bool             `json:"success"`
+	InProgress      bool             `json:"inProgress"`
+	TestCaseResults []testCaseResult `json:"testCaseResults"`
+}
+
+// SetTestOutcome configures the outcome of tests launched on a service,
+// referenced by ID or `name:version`.
+func (s *Server) SetTestOutcome(serviceRef string, outcome TestOutcome) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outcomes[serviceRef] = outcome
+}
+
+// SetDefaultTestOutcome configures the outcome of tests on services without
+// a specific outcome.
+func (s *Server) SetDefaultTestOutcome(outcome TestOutcome) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defaultOutcome = outcome
+}
+
+// TestRuns returns the tests launched so far, in launch order.
+func (s *Server) TestRuns() []TestRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runs := make([]TestRun, 0, len(s.tests))
+	for _, t := range s.tests {
+		runs = append(runs, t.TestRun)
+	}
+	return runs
+}
+
+func (s *Server) handleCreateTest(w http.ResponseWriter, r *http.Request) {
+	var req testCreationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeText(w, http.StatusBadRequest, "Malformed test request: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	svc := s.findService(req.ServiceID)
+	if svc == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	outcome, ok := s.outcomes[svc.Ref()]
+	if !ok {
+		if outcome, ok = s.outcomes[svc.ID]; !ok {
+			outcome = s.defaultOutcome
+		}
+	}
+
+	var operations []string
+	var testNumber int32 = 1
+	for _, op := range svc.Operations {
+		if len(req.FilteredOperations) == 0 || slices.Contains(req.FilteredOperations, op.Name) {
+			operations = append(operations, op.Name)
+		}
+	}
+	for _, t := range s.tests {
+		if t.ServiceID == svc.ID {
+			testNumber++
+		}
+	}
+
+	run := &testRun{
+		TestRun: TestRun{
+			ID:                 s.nextID(),
+			ServiceID:          svc.ID,
+			TestedEndpoint:     req.TestEndpoint,
+			RunnerType:         req.RunnerType,
+			SecretName:         req.SecretName,
+			Timeout:            req.Timeout,
+			FilteredOperations: req.FilteredOperations,
+			OperationsHeaders:  req.OperationsHeaders,
+			OAuth2Context:      req.OAuth2Context,
+			CreatedAt:          s.now(),
+		},
+		testNumber: testNumber,
+		operations: operations,
+		outcome:    outcome,
+	}
+	s.tests = append(s.tests, run)
+
+	writeJSON(w, http.StatusCreated, s.renderTest(run))
+}
+
+func (s *Server) handleGetTest(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.tests {
+		if t.ID == r.PathValue("id") {
+			writeJSON(w, http.StatusOK, s.renderTest(t))
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+// handleListServiceTests lists the tests of a service, most recent first.
+func (s *Server) handleListServiceTests(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	svc := s.findService(r.PathValue("serviceId"))
+	if svc == nil {
+		writeJSON(w, http.StatusOK, []testResult{})
+		return
+	}
+
+	var runs []*testRun
+	for i := len(s.tests) - 1; i >= 0; i-- {
+		if s.tests[i].ServiceID == svc.ID {
+			runs = append(runs, s.tests[i])
+		}
+	}
+	page, size := pageParams(r)
+	results := []testResult{}
+	for i := page * size; i < len(runs) && i < (page+1)*size; i++ {
+		results = append(results, s.renderTest(runs[i]))
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+// renderTest computes the test result as seen at the current time: operations
+// complete one after the other over InProgressFor. Callers hold mu.
+func (s *Server) renderTest(t *testRun) testResult {
+	elapsed := s.now().Sub(t.CreatedAt)
+	inProgress := elapsed < t.outcome.InProgressFor
+
+	completed := len(t.operations)
+	if inProgress {
+		// A clock set before the test creation makes elapsed negative.
+		completed = int(int64(len(t.operations)) * int64(max(elapsed, 0)) / int64(t.outcome.InProgressFor))
+	}
+	completed = min(max(completed, 0), len(t.operations))
+
+	result := testResult{
+		ID:              t.ID,
+		TestNumber:      t.testNumber,
+		TestDate:        t.CreatedAt.UnixMilli(),
+		TestedEndpoint:  t.TestedEndpoint,
+		ServiceID:       t.ServiceID,
+		RunnerType:      t.RunnerType,
+		Timeout:         t.Timeout,
+		InProgress:      inProgress,
+		TestCaseResults: []testCaseResult{},
+	}
+
+	allSucceeded := true
+	for _, op := range t.operations[:completed] {
+		success := t.outcome.Success ||
+			(len(t.outcome.FailedOperations) > 0 && !slices.Contains(t.outcome.FailedOperations, op))
+		elapsedTime, ok := t.outcome.ElapsedTimes[op]
+		if !ok {
+			elapsedTime = 10 * time.Millisecond
+		}
+		step := testStepResult{Success: success, ElapsedTime: int32(elapsedTime.Milliseconds()), RequestName: op}
+		if !success {
+			step.Message = "Response does not conform to the expected schema"
+		}
+		result.TestCaseResults = append(result.TestCaseResults, testCaseResult{
+			Success:         success,
+			ElapsedTime:     step.ElapsedTime,
+			OperationName:   op,
+			TestStepResults: []testStepResult{step},
+		})
+		result.ElapsedTime += step.ElapsedTime
+		allSucceeded = allSucceeded && success
+	}
+	if !inProgress {
+		result.Success = t.outcome.Success && allSucceeded
+	}
+	return result
+}
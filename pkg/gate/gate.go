@@ -0,0 +1,248 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package gate evaluates quality gates on test results: instead of requiring
+// every operation to pass, a Policy sets a minimum pass rate, tolerates the
+// failure of some operations until a date, and caps response times.
+package gate
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/microcks/microcks-cli/pkg/util/duration"
+	"gopkg.in/yaml.v3"
+)
+
+// DateLayout is the layout of allowed failure expiry dates.
+const DateLayout = "2006-01-02"
+
+// Policy is a quality gate. The zero value requires every operation to pass,
+// like a test without gate.
+type Policy struct {
+	// MinPassRate is the minimum percentage of passing operations, tolerated
+	// failures excluded. Nil means 100.
+	MinPassRate *float64
+	// MaxResponseTime caps the elapsed time of every operation. Zero means no cap.
+	MaxResponseTime time.Duration
+	// ResponseTimes caps the elapsed time of specific operations, overriding
+	// MaxResponseTime.
+	ResponseTimes map[string]time.Duration
+	// AllowedFailures lists operations whose failure does not fail the gate.
+	AllowedFailures []AllowedFailure
+}
+
+// AllowedFailure tolerates the failure of an operation until the end of the
+// Until day. A zero Until never expires.
+type AllowedFailure struct {
+	Operation string
+	Until     time.Time
+	Reason    string
+}
+
+// Expired tells whether the tolerance is over at now.
+func (a AllowedFailure) Expired(now time.Time) bool {
+	return !a.Until.IsZero() && !now.Before(a.Until.AddDate(0, 0, 1))
+}
+
+type policyFile struct {
+	MinPassRate     *float64            `yaml:"minPassRate"`
+	MaxResponseTime string              `yaml:"maxResponseTime"`
+	ResponseTimes   map[string]string   `yaml:"responseTimes"`
+	AllowedFailures []allowedFailureDoc `yaml:"allowedFailures"`
+}
+
+type allowedFailureDoc struct {
+	Operation string `yaml:"operation"`
+	Until     string `yaml:"until"`
+	Reason    string `yaml:"reason"`
+}
+
+// LoadFile reads a YAML gate policy file.
+func LoadFile(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(errors.KindUsage, "--gate-file: cannot read %s: %v", path, err)
+	}
+
+	var doc policyFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, errors.Wrapf(errors.KindUsage, "--gate-file: invalid %s: %v", path, err)
+	}
+
+	policy := &Policy{MinPassRate: doc.MinPassRate}
+	if doc.MaxResponseTime != "" {
+		if policy.MaxResponseTime, err = duration.Parse(doc.MaxResponseTime); err != nil {
+			return nil, errors.Wrapf(errors.KindUsage, "--gate-file: maxResponseTime: %v", err)
+		}
+	}
+	for operation, value := range doc.ResponseTimes {
+		d, err := duration.Parse(value)
+		if err != nil {
+			return nil, errors.Wrapf(errors.KindUsage, "--gate-file: responseTimes of %q: %v", operation, err)
+		}
+		if policy.ResponseTimes == nil {
+			policy.ResponseTimes = map[string]time.Duration{}
+		}
+		policy.ResponseTimes[operation] = d
+	}
+	for i, a := range doc.AllowedFailures {
+		if strings.TrimSpace(a.Operation) == "" {
+			return nil, errors.Wrapf(errors.KindUsage, "--gate-file: allowed failure #%d has no operation", i+1)
+		}
+		allowed := AllowedFailure{Operation: a.Operation, Reason: a.Reason}
+		if a.Until != "" {
+			if allowed.Until, err = time.ParseInLocation(DateLayout, a.Until, time.Local); err != nil {
+				return nil, errors.Wrapf(errors.KindUsage, "--gate-file: until of %q must be a YYYY-MM-DD date", a.Operation)
+			}
+		}
+		policy.AllowedFailures = append(policy.AllowedFailures, allowed)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// untilSuffix matches the `@YYYY-MM-DD` expiry ending an --allow-failure value.
+var untilSuffix = regexp.MustCompile(`@\d{4}-\d{2}-\d{2}$`)
+
+// ParseAllowedFailure parses an --allow-failure value: an operation name,
+// optionally followed by `@YYYY-MM-DD` for the last tolerated day. Any other
+// `@`, like in `GET /users/@me`, belongs to the operation name.
+func ParseAllowedFailure(value string) (AllowedFailure, error) {
+	allowed := AllowedFailure{Operation: value}
+	if untilSuffix.MatchString(value) {
+		i := strings.LastIndex(value, "@")
+		until, err := time.ParseInLocation(DateLayout, value[i+1:], time.Local)
+		if err != nil {
+			return allowed, errors.Wrapf(errors.KindUsage, "--allow-failure: %q must be an operation, optionally followed by @YYYY-MM-DD", value)
+		}
+		allowed.Operation, allowed.Until = value[:i], until
+	}
+	if strings.TrimSpace(allowed.Operation) == "" {
+		return allowed, errors.Wrapf(errors.KindUsage, "--allow-failure: %q has no operation", value)
+	}
+	return allowed, nil
+}
+
+// Validate checks the policy values are in range.
+func (p *Policy) Validate() error {
+	if p.MinPassRate != nil && (*p.MinPassRate < 0 || *p.MinPassRate > 100) {
+		return errors.Wrapf(errors.KindUsage, "minimum pass rate must be between 0 and 100, got %g", *p.MinPassRate)
+	}
+	if p.MaxResponseTime < 0 {
+		return errors.Wrapf(errors.KindUsage, "maximum response time must not be negative")
+	}
+	return nil
+}
+
+// Finding is an operation that made the gate fail or that was tolerated.
+type Finding struct {
+	Operation string
+	Message   string
+}
+
+// Report is the outcome of a gate on a test result.
+type Report struct {
+	Passed      bool
+	Incomplete  bool
+	Operations  int
+	PassedCount int
+	PassRate    float64
+	MinPassRate float64
+	// Tolerated lists failed operations covered by an allowed failure.
+	Tolerated []Finding
+	// Violations lists response time caps exceeded and expired tolerances.
+	Violations []Finding
+}
+
+// Evaluate applies the policy to a test result at now.
+func (p *Policy) Evaluate(result *connectors.TestResultSummary, now time.Time) *Report {
+	report := &Report{Incomplete: result.InProgress, Operations: len(result.TestCaseResults), MinPassRate: 100}
+	if p.MinPassRate != nil {
+		report.MinPassRate = *p.MinPassRate
+	}
+
+	failed := 0
+	for _, tc := range result.TestCaseResults {
+		if limit := p.responseTimeLimit(tc.OperationName); limit > 0 && time.Duration(tc.ElapsedTime)*time.Millisecond > limit {
+			report.Violations = append(report.Violations, Finding{tc.OperationName,
+				fmt.Sprintf("took %d ms, over the %d ms limit", tc.ElapsedTime, limit.Milliseconds())})
+		}
+		if tc.Success {
+			report.PassedCount++
+			continue
+		}
+
+		allowed, ok := p.allowedFailure(tc.OperationName)
+		switch {
+		case ok && allowed.Expired(now):
+			report.Violations = append(report.Violations, Finding{tc.OperationName,
+				"failed, and its tolerance expired on " + allowed.Until.Format(DateLayout)})
+		case ok:
+			report.Tolerated = append(report.Tolerated, Finding{tc.OperationName, describeAllowance(allowed)})
+			continue
+		}
+		failed++
+	}
+
+	report.PassRate = 100
+	if counted := report.PassedCount + failed; counted > 0 {
+		report.PassRate = float64(report.PassedCount) * 100 / float64(counted)
+	}
+	report.Passed = !report.Incomplete && report.PassRate >= report.MinPassRate && len(report.Violations) == 0
+	return report
+}
+
+func (p *Policy) responseTimeLimit(operation string) time.Duration {
+	if limit, ok := p.ResponseTimes[operation]; ok {
+		return limit
+	}
+	return p.MaxResponseTime
+}
+
+// allowedFailure returns the allowance of an operation, preferring one that
+// is still valid when an operation is listed more than once.
+func (p *Policy) allowedFailure(operation string) (AllowedFailure, bool) {
+	var found AllowedFailure
+	ok := false
+	for _, a := range p.AllowedFailures {
+		if a.Operation == operation && (!ok || a.Until.IsZero() || (!found.Until.IsZero() && a.Until.After(found.Until))) {
+			found, ok = a, true
+		}
+	}
+	return found, ok
+}
+
+func describeAllowance(a AllowedFailure) string {
+	message := "failure tolerated"
+	if !a.Until.IsZero() {
+		message += " until " + a.Until.Format(DateLayout)
+	}
+	if a.Reason != "" {
+		message += " (" + a.Reason + ")"
+	}
+	return message
+}
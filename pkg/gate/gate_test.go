@@ -0,0 +1,162 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package gate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"github.com/microcks/microcks-cli/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testResult(cases ...connectors.TestCaseResult) *connectors.TestResultSummary {
+	return &connectors.TestResultSummary{ID: "t1", TestCaseResults: cases}
+}
+
+func day(s string) time.Time {
+	d, _ := time.ParseInLocation(DateLayout, s, time.Local)
+	return d
+}
+
+func TestZeroPolicyRequiresEveryOperation(t *testing.T) {
+	policy := &Policy{}
+	result := testResult(
+		connectors.TestCaseResult{OperationName: "GET /pets", Success: true},
+		connectors.TestCaseResult{OperationName: "POST /pets"},
+	)
+	report := policy.Evaluate(result, time.Now())
+	assert.False(t, report.Passed)
+	assert.Equal(t, 50.0, report.PassRate)
+
+	result.TestCaseResults[1].Success = true
+	assert.True(t, policy.Evaluate(result, time.Now()).Passed)
+}
+
+func TestMinPassRate(t *testing.T) {
+	rate := 60.0
+	policy := &Policy{MinPassRate: &rate}
+	result := testResult(
+		connectors.TestCaseResult{OperationName: "a", Success: true},
+		connectors.TestCaseResult{OperationName: "b", Success: true},
+		connectors.TestCaseResult{OperationName: "c"},
+	)
+	assert.True(t, policy.Evaluate(result, time.Now()).Passed)
+
+	rate = 70
+	assert.False(t, policy.Evaluate(result, time.Now()).Passed)
+}
+
+func TestAllowedFailuresAndExpiry(t *testing.T) {
+	policy := &Policy{AllowedFailures: []AllowedFailure{
+		{Operation: "POST /pets", Until: day("2026-12-31"), Reason: "v2 migration"},
+		{Operation: "DELETE /pets"},
+	}}
+	result := testResult(
+		connectors.TestCaseResult{OperationName: "GET /pets", Success: true},
+		connectors.TestCaseResult{OperationName: "POST /pets"},
+		connectors.TestCaseResult{OperationName: "DELETE /pets"},
+	)
+
+	report := policy.Evaluate(result, day("2026-12-31").Add(23*time.Hour))
+	assert.True(t, report.Passed)
+	assert.Equal(t, 100.0, report.PassRate)
+	assert.Equal(t, []Finding{
+		{"POST /pets", "failure tolerated until 2026-12-31 (v2 migration)"},
+		{"DELETE /pets", "failure tolerated"},
+	}, report.Tolerated)
+
+	report = policy.Evaluate(result, day("2027-01-01"))
+	assert.False(t, report.Passed)
+	assert.Equal(t, []Finding{{"POST /pets", "failed, and its tolerance expired on 2026-12-31"}}, report.Violations)
+}
+
+func TestResponseTimeLimits(t *testing.T) {
+	policy := &Policy{MaxResponseTime: 100 * time.Millisecond, ResponseTimes: map[string]time.Duration{"GET /slow": time.Second}}
+	result := testResult(
+		connectors.TestCaseResult{OperationName: "GET /slow", Success: true, ElapsedTime: 800},
+		connectors.TestCaseResult{OperationName: "GET /fast", Success: true, ElapsedTime: 150},
+	)
+	report := policy.Evaluate(result, time.Now())
+	assert.False(t, report.Passed)
+	assert.Equal(t, []Finding{{"GET /fast", "took 150 ms, over the 100 ms limit"}}, report.Violations)
+}
+
+func TestIncompleteTestFailsGate(t *testing.T) {
+	rate := 0.0
+	result := testResult()
+	result.InProgress = true
+	report := (&Policy{MinPassRate: &rate}).Evaluate(result, time.Now())
+	assert.False(t, report.Passed)
+	assert.True(t, report.Incomplete)
+}
+
+func TestParseAllowedFailure(t *testing.T) {
+	allowed, err := ParseAllowedFailure("POST /pets@2026-12-31")
+	require.NoError(t, err)
+	assert.Equal(t, "POST /pets", allowed.Operation)
+	assert.Equal(t, day("2026-12-31"), allowed.Until)
+
+	allowed, err = ParseAllowedFailure("SUBSCRIBE user/signedup")
+	require.NoError(t, err)
+	assert.True(t, allowed.Until.IsZero())
+
+	allowed, err = ParseAllowedFailure("GET /users/@me")
+	require.NoError(t, err)
+	assert.Equal(t, "GET /users/@me", allowed.Operation)
+	assert.True(t, allowed.Until.IsZero())
+
+	allowed, err = ParseAllowedFailure("GET /users/@me@2026-12-31")
+	require.NoError(t, err)
+	assert.Equal(t, "GET /users/@me", allowed.Operation)
+	assert.Equal(t, day("2026-12-31"), allowed.Until)
+
+	for _, value := range []string{"POST /pets@2026-13-45", "@2026-12-31", ""} {
+		_, err := ParseAllowedFailure(value)
+		assert.Equal(t, errors.KindUsage, errors.KindOf(err), value)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gate.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+minPassRate: 90
+maxResponseTime: 500ms
+responseTimes:
+  GET /reports: PT2S
+allowedFailures:
+  - operation: POST /pets
+    until: 2026-12-31
+    reason: v2 migration
+`), 0o600))
+
+	policy, err := LoadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 90.0, *policy.MinPassRate)
+	assert.Equal(t, 500*time.Millisecond, policy.MaxResponseTime)
+	assert.Equal(t, 2*time.Second, policy.ResponseTimes["GET /reports"])
+	assert.Equal(t, []AllowedFailure{{Operation: "POST /pets", Until: day("2026-12-31"), Reason: "v2 migration"}}, policy.AllowedFailures)
+
+	for _, content := range []string{"minPassRate: 120", "minPasRate: 90", "maxResponseTime: soon", "allowedFailures:\n  - until: 2026-12-31"} {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		_, err := LoadFile(path)
+		assert.Equal(t, errors.KindUsage, errors.KindOf(err), content)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package lint
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+func (l *linter) asyncAPI() {
+	version := field(l.root, "asyncapi")
+	v3 := strings.HasPrefix(version.Value, "3.")
+	if !v3 && !strings.HasPrefix(version.Value, "2.") {
+		l.errorf(version, "unsupported AsyncAPI version '%s', expected 2.x or 3.x", version.Value)
+	}
+	if info := l.requireMapping(l.root, "info", "info"); info != nil {
+		l.requireScalar(info, "title", "info.title", "Microcks names the service after it")
+		l.requireScalar(info, "version", "info.version", "Microcks versions the service with it")
+		if extension := field(info, "x-microcks"); extension != nil {
+			l.knownKeys(extension, "info.x-microcks", "labels")
+			l.labels(field(extension, "labels"), "info.x-microcks.labels")
+		}
+	}
+
+	channels := l.requireMapping(l.root, "channels", "channels")
+	if v3 {
+		// AsyncAPI 3 declares operations at the top level, and messages in
+		// channels.
+		operations := field(l.root, "operations")
+		for _, name := range keys(operations) {
+			l.microcksOperation(field(field(operations, name.Value), "x-microcks-operation"), name.Value+" x-microcks-operation")
+		}
+		for _, channel := range keys(channels) {
+			messages := field(field(channels, channel.Value), "messages")
+			for _, message := range keys(messages) {
+				l.messageExamples(field(messages, message.Value), message.Value)
+			}
+		}
+	} else {
+		for _, channel := range keys(channels) {
+			item := field(channels, channel.Value)
+			for _, action := range []string{"subscribe", "publish"} {
+				operation := field(item, action)
+				if operation == nil {
+					continue
+				}
+				name := strings.ToUpper(action) + " " + channel.Value
+				l.microcksOperation(field(operation, "x-microcks-operation"), name+" x-microcks-operation")
+				message := field(operation, "message")
+				if oneOf := sequence(field(message, "oneOf")); oneOf != nil {
+					for _, m := range oneOf {
+						l.messageExamples(m, name)
+					}
+				} else {
+					l.messageExamples(message, name)
+				}
+			}
+		}
+	}
+
+	messages := field(field(l.root, "components"), "messages")
+	for _, message := range keys(messages) {
+		l.messageExamples(field(messages, message.Value), message.Value)
+	}
+	l.localRefs(l.root)
+}
+
+// messageExamples checks the examples of a message: Microcks publishes
+// named examples only.
+func (l *linter) messageExamples(message *yaml.Node, name string) {
+	examples := field(message, "examples")
+	if examples == nil {
+		return
+	}
+	if examples.Kind != yaml.SequenceNode {
+		l.errorf(examples, "examples of message %s must be a list", name)
+		return
+	}
+	for _, example := range examples.Content {
+		if scalar(example, "name") == "" {
+			l.warnf(example, "example of message %s has no name: Microcks ignores it", name)
+		}
+		if field(example, "payload") == nil && field(example, "headers") == nil {
+			l.warnf(example, "example of message %s has no payload nor headers", name)
+		}
+	}
+}
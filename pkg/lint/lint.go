@@ -0,0 +1,315 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package lint checks Microcks artifacts locally: OpenAPI and AsyncAPI
+// documents, Postman collections and APIMetadata/APIExamples files. Beyond
+// the structure Microcks needs to import them, it checks its conventions,
+// like examples paired by name and `x-microcks` extensions, and reports each
+// problem with its file and line. The other artifacts Microcks imports, like
+// SoapUI projects or Protobuf schemas, are noted as not checked.
+package lint
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/microcks/microcks-cli/pkg/connectors"
+	"gopkg.in/yaml.v3"
+)
+
+// Severity tells whether a problem makes Microcks reject or misread an
+// artifact (error) or is likely a mistake (warning). Info notes are not
+// problems.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Diagnostic is a problem found at a line and column of an artifact.
+type Diagnostic struct {
+	File     string
+	Line     int
+	Column   int
+	Severity Severity
+	Message  string
+}
+
+// String renders the diagnostic as `file:line:column: severity: message`.
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s", d.File, d.Line, d.Column, d.Severity, d.Message)
+}
+
+var yamlLineRegexp = regexp.MustCompile(`line (\d+)`)
+
+// uncheckedExtensions are the artifacts Microcks imports that aren't YAML
+// nor JSON: SoapUI projects, WSDL, Protobuf and GraphQL schemas.
+var uncheckedExtensions = []string{".xml", ".wsdl", ".proto", ".graphql", ".gql"}
+
+// Document lints the content of an artifact, named file in the diagnostics.
+// JSON is valid YAML, so one parser gives the lines of both.
+func Document(file string, content []byte) []Diagnostic {
+	l := &linter{file: file}
+	if slices.Contains(uncheckedExtensions, strings.ToLower(filepath.Ext(file))) || bytes.HasPrefix(bytes.TrimSpace(content), []byte("<")) {
+		l.diagnostics = append(l.diagnostics, Diagnostic{File: file, Line: 1, Column: 1, Severity: SeverityInfo,
+			Message: "not checked: only OpenAPI, AsyncAPI, Postman collection, APIMetadata and APIExamples documents are linted"})
+		return l.diagnostics
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		line := 1
+		if m := yamlLineRegexp.FindStringSubmatch(err.Error()); m != nil {
+			line, _ = strconv.Atoi(m[1])
+		}
+		l.diagnostics = append(l.diagnostics, Diagnostic{File: file, Line: line, Column: 1, Severity: SeverityError,
+			Message: strings.TrimPrefix(err.Error(), "yaml: ")})
+		return l.diagnostics
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		l.diagnostics = append(l.diagnostics, Diagnostic{File: file, Line: 1, Column: 1, Severity: SeverityError,
+			Message: "not a YAML or JSON object"})
+		return l.diagnostics
+	}
+	l.root = doc.Content[0]
+
+	info := field(l.root, "info")
+	switch {
+	case field(l.root, "openapi") != nil || field(l.root, "swagger") != nil:
+		l.openAPI()
+	case field(l.root, "asyncapi") != nil:
+		l.asyncAPI()
+	case scalar(l.root, "kind") == "APIMetadata":
+		l.apiMetadata()
+	case scalar(l.root, "kind") == "APIExamples":
+		l.apiExamples()
+	case info != nil && (field(info, "_postman_id") != nil || strings.Contains(scalar(info, "schema"), "postman")):
+		l.postman()
+	default:
+		l.errorf(l.root, "unknown artifact type: expected an OpenAPI, AsyncAPI, Postman collection, APIMetadata or APIExamples document")
+	}
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		return l.diagnostics[i].Line < l.diagnostics[j].Line
+	})
+	return l.diagnostics
+}
+
+type linter struct {
+	file        string
+	root        *yaml.Node
+	diagnostics []Diagnostic
+}
+
+func (l *linter) report(node *yaml.Node, severity Severity, format string, a ...interface{}) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{
+		File:     l.file,
+		Line:     node.Line,
+		Column:   node.Column,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, a...),
+	})
+}
+
+func (l *linter) errorf(node *yaml.Node, format string, a ...interface{}) {
+	l.report(node, SeverityError, format, a...)
+}
+
+func (l *linter) warnf(node *yaml.Node, format string, a ...interface{}) {
+	l.report(node, SeverityWarning, format, a...)
+}
+
+// requireScalar reports a missing or empty field of a section, named path in
+// the message, and returns its value.
+func (l *linter) requireScalar(section *yaml.Node, name, path, why string) string {
+	value := field(section, name)
+	if value == nil || value.Kind != yaml.ScalarNode || value.Value == "" {
+		l.errorf(section, "%s is required: %s", path, why)
+		return ""
+	}
+	return value.Value
+}
+
+// requireMapping reports a missing field of a section that must be a
+// mapping, and returns it.
+func (l *linter) requireMapping(section *yaml.Node, name, path string) *yaml.Node {
+	value := field(section, name)
+	switch {
+	case value == nil:
+		l.errorf(section, "%s is required", path)
+		return nil
+	case value.Kind != yaml.MappingNode:
+		l.errorf(value, "%s must be an object", path)
+		return nil
+	}
+	return value
+}
+
+// knownKeys warns about the keys of a mapping Microcks doesn't know, which
+// are most likely typos.
+func (l *linter) knownKeys(section *yaml.Node, path string, known ...string) {
+	for _, key := range keys(section) {
+		if !slices.Contains(known, key.Value) {
+			l.warnf(key, "unknown key '%s' in %s, expected one of %s", key.Value, path, strings.Join(known, ", "))
+		}
+	}
+}
+
+// labels checks a mapping of labels, whose values must be strings.
+func (l *linter) labels(labels *yaml.Node, path string) {
+	if labels == nil {
+		return
+	}
+	if labels.Kind != yaml.MappingNode {
+		l.errorf(labels, "%s must be an object of strings", path)
+		return
+	}
+	for i := 0; i+1 < len(labels.Content); i += 2 {
+		if labels.Content[i+1].Kind != yaml.ScalarNode {
+			l.errorf(labels.Content[i+1], "label '%s' of %s must be a string", labels.Content[i].Value, path)
+		}
+	}
+}
+
+// microcksOperation checks the `x-microcks-operation` extension of an
+// operation, or an operation of an APIMetadata document.
+func (l *linter) microcksOperation(extension *yaml.Node, path string) {
+	if extension == nil {
+		return
+	}
+	if extension.Kind != yaml.MappingNode {
+		l.errorf(extension, "%s must be an object", path)
+		return
+	}
+	l.knownKeys(extension, path, "delay", "frequency", "dispatcher", "dispatcherRules", "parameterConstraints")
+	for name, unit := range map[string]string{"delay": "milliseconds", "frequency": "seconds"} {
+		if value := field(extension, name); value != nil {
+			if _, err := strconv.Atoi(value.Value); err != nil || value.Kind != yaml.ScalarNode {
+				l.errorf(value, "%s.%s must be a number of %s", path, name, unit)
+			}
+		}
+	}
+	dispatcher := field(extension, "dispatcher")
+	if dispatcher == nil {
+		if field(extension, "dispatcherRules") != nil {
+			l.warnf(extension, "%s has dispatcherRules but no dispatcher to apply them", path)
+		}
+		return
+	}
+	needsRules, known := connectors.DispatcherTypes[dispatcher.Value]
+	switch {
+	case !known:
+		l.errorf(dispatcher, "unknown dispatcher '%s' in %s", dispatcher.Value, path)
+	case needsRules && scalar(extension, "dispatcherRules") == "":
+		l.errorf(dispatcher, "dispatcher %s of %s needs dispatcherRules", dispatcher.Value, path)
+	}
+	if constraints := field(extension, "parameterConstraints"); constraints != nil && constraints.Kind != yaml.SequenceNode {
+		l.errorf(constraints, "%s.parameterConstraints must be a list", path)
+	}
+}
+
+// localRefs reports the `$ref` pointing into the document itself that
+// don't resolve.
+func (l *linter) localRefs(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "$ref" && value.Kind == yaml.ScalarNode && strings.HasPrefix(value.Value, "#") {
+				if resolve(l.root, value.Value) == nil {
+					l.errorf(value, "$ref '%s' does not resolve in the document", value.Value)
+				}
+				continue
+			}
+			l.localRefs(value)
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			l.localRefs(item)
+		}
+	}
+}
+
+// resolve follows a local JSON pointer like `#/components/schemas/Pet`.
+func resolve(root *yaml.Node, ref string) *yaml.Node {
+	pointer, err := url.PathUnescape(strings.TrimPrefix(ref, "#"))
+	if err != nil {
+		return nil
+	}
+	if pointer == "" {
+		return root
+	}
+	node := root
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch node.Kind {
+		case yaml.MappingNode:
+			node = field(node, token)
+		case yaml.SequenceNode:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(node.Content) {
+				return nil
+			}
+			node = node.Content[i]
+		default:
+			return nil
+		}
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// field returns the value of a key of a mapping, nil when absent.
+func field(node *yaml.Node, name string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == name {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// scalar returns the value of a scalar field, "" when absent.
+func scalar(node *yaml.Node, name string) string {
+	if value := field(node, name); value != nil && value.Kind == yaml.ScalarNode {
+		return value.Value
+	}
+	return ""
+}
+
+// keys returns the key nodes of a mapping, in document order.
+func keys(node *yaml.Node) []*yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	var keys []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keys = append(keys, node.Content[i])
+	}
+	return keys
+}
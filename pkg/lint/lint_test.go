@@ -0,0 +1,174 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package lint
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lintStrings(file, content string) []string {
+	var lines []string
+	for _, d := range Document(file, []byte(content)) {
+		lines = append(lines, d.String())
+	}
+	return lines
+}
+
+func TestSamplesAreValid(t *testing.T) {
+	for _, sample := range []string{"weather-forecast-openapi.yml", "weather-forecast-postman.json"} {
+		content, err := os.ReadFile("../../samples/" + sample)
+		require.NoError(t, err)
+		assert.Empty(t, Document(sample, content), sample)
+	}
+}
+
+func TestOpenAPIConventions(t *testing.T) {
+	assert.Equal(t, []string{
+		"api.yml:3:3: error: info.version is required: Microcks versions the service with it",
+		"api.yml:5:5: warning: unknown key 'label' in info.x-microcks, expected one of labels",
+		"api.yml:11:9: warning: unknown key 'dispatch' in GET /pastries/{name} x-microcks-operation, expected one of delay, frequency, dispatcher, dispatcherRules, parameterConstraints",
+		"api.yml:12:21: error: dispatcher URI_PARTS of GET /pastries/{name} x-microcks-operation needs dispatcherRules",
+		"api.yml:13:16: error: GET /pastries/{name} x-microcks-operation.delay must be a number of milliseconds",
+		"api.yml:20:13: warning: request example 'tart' of GET /pastries/{name} has no response example of the same name: Microcks ignores it",
+		"api.yml:30:23: error: $ref '#/components/schemas/Pastry' does not resolve in the document",
+		"api.yml:35:17: warning: example 'eclair' of GET /pastries/{name} is defined for responses 200 and 404: Microcks only keeps one",
+		"api.yml:38:11: error: operation POST /pastries has no responses",
+	}, lintStrings("api.yml", `openapi: 3.0.2
+info:
+  title: Pastries
+  x-microcks:
+    label:
+      domain: pastry
+paths:
+  /pastries/{name}:
+    get:
+      x-microcks-operation:
+        dispatch: URI_PARTS
+        dispatcher: URI_PARTS
+        delay: fast
+      parameters:
+        - name: name
+          in: path
+          examples:
+            eclair:
+              value: eclair
+            tart:
+              value: tart
+      responses:
+        "200":
+          content:
+            application/json:
+              examples:
+                eclair:
+                  value: {name: eclair}
+              schema:
+                $ref: '#/components/schemas/Pastry'
+        "404":
+          content:
+            text/plain:
+              examples:
+                eclair:
+                  value: not found
+  /pastries:
+    post: {}
+`))
+}
+
+func TestAsyncAPIMessageExamples(t *testing.T) {
+	assert.Equal(t, []string{
+		"events.yml:7:20: error: SUBSCRIBE user/signedup x-microcks-operation.frequency must be a number of seconds",
+		"events.yml:10:13: warning: example of message SUBSCRIBE user/signedup has no name: Microcks ignores it",
+	}, lintStrings("events.yml", `asyncapi: 2.6.0
+info: {title: Users, version: 1.0.0}
+channels:
+  user/signedup:
+    subscribe:
+      x-microcks-operation:
+        frequency: often
+      message:
+        examples:
+          - payload: {id: 1}
+          - name: Bob
+            payload: {id: 2}
+`))
+}
+
+func TestPostmanConventions(t *testing.T) {
+	assert.Equal(t, []string{
+		`orders.json:2:11: error: info.description must contain 'version=<version>': Microcks versions the service with it`,
+		`orders.json:4:5: warning: request 'List orders' has no saved response: Microcks has no example to mock it with`,
+		`orders.json:6:9: warning: request 'Get order' has several saved responses named 'Found': Microcks only keeps one`,
+	}, lintStrings("orders.json", `{
+  "info": {"name": "Orders", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+  "item": [{"name": "orders", "item": [
+    {"name": "List orders", "request": {"method": "GET", "url": "{{baseUrl}}/orders"}},
+    {"name": "Get order", "request": {"method": "GET", "url": "{{baseUrl}}/orders/:id"}, "response": [{"name": "Found"},
+        {"name": "Found"}]}
+  ]}]
+}`))
+}
+
+func TestMicrocksDocuments(t *testing.T) {
+	assert.Equal(t, []string{
+		"metadata.yml:1:13: error: unsupported apiVersion 'mocks.microcks.io/v1', expected mocks.microcks.io/v1alpha1",
+		"metadata.yml:4:3: error: metadata.version is required: it is the version of the service to complete",
+		"metadata.yml:6:11: error: label 'team' of metadata.labels must be a string",
+		"metadata.yml:9:17: error: unknown dispatcher 'BY_NAME' in operation GET /orders",
+	}, lintStrings("metadata.yml", `apiVersion: mocks.microcks.io/v1
+kind: APIMetadata
+metadata:
+  name: Orders
+  labels:
+    team: [a, b]
+operations:
+  GET /orders:
+    dispatcher: BY_NAME
+`))
+
+	assert.Equal(t, []string{
+		"examples.yml:8:5: error: example Empty of operation GET /orders needs a response or an eventMessage",
+		"examples.yml:9:7: warning: unknown key 'reponse' in example Empty of operation GET /orders, expected one of request, response, eventMessage",
+	}, lintStrings("examples.yml", `apiVersion: mocks.microcks.io/v1alpha1
+kind: APIExamples
+metadata:
+  name: Orders
+  version: 1.0.0
+operations:
+  GET /orders:
+    Empty:
+      reponse:
+        body: []
+`))
+}
+
+func TestUnparsableDocuments(t *testing.T) {
+	assert.Equal(t, []string{"bad.yml:2:1: error: line 2: could not find expected ':'"}, lintStrings("bad.yml", "openapi: 3.0.0\ninfo\n"))
+	assert.Equal(t, []string{"notes.yml:1:1: error: unknown artifact type: expected an OpenAPI, AsyncAPI, Postman collection, APIMetadata or APIExamples document"},
+		lintStrings("notes.yml", "hello: world\n"))
+	assert.Equal(t, []string{"list.yml:1:1: error: not a YAML or JSON object"}, lintStrings("list.yml", "- a\n"))
+}
+
+func TestUncheckedArtifacts(t *testing.T) {
+	note := ":1:1: info: not checked: only OpenAPI, AsyncAPI, Postman collection, APIMetadata and APIExamples documents are linted"
+	assert.Equal(t, []string{"orders-soapui-project.xml" + note}, lintStrings("orders-soapui-project.xml", "<?xml version=\"1.0\"?>\n<con:soapui-project/>\n"))
+	assert.Equal(t, []string{"orders.proto" + note}, lintStrings("orders.proto", "syntax = \"proto3\";\n"))
+	assert.Equal(t, []string{"orders.graphql" + note}, lintStrings("orders.graphql", "type Query { orders: [Order] }\n"))
+	assert.Equal(t, []string{"orders.wsdl.txt" + note}, lintStrings("orders.wsdl.txt", "  <definitions/>\n"))
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package lint
+
+import "gopkg.in/yaml.v3"
+
+// microcksAPIVersion is the apiVersion of the APIMetadata and APIExamples
+// documents.
+const microcksAPIVersion = "mocks.microcks.io/v1alpha1"
+
+// microcksDocument checks what APIMetadata and APIExamples documents share
+// and returns their metadata.
+func (l *linter) microcksDocument(kind string, metadataKeys ...string) *yaml.Node {
+	l.knownKeys(l.root, kind, "apiVersion", "kind", "metadata", "operations")
+	if apiVersion := l.requireScalar(l.root, "apiVersion", "apiVersion", "expected "+microcksAPIVersion); apiVersion != "" && apiVersion != microcksAPIVersion {
+		l.errorf(field(l.root, "apiVersion"), "unsupported apiVersion '%s', expected %s", apiVersion, microcksAPIVersion)
+	}
+	metadata := l.requireMapping(l.root, "metadata", "metadata")
+	if metadata != nil {
+		l.knownKeys(metadata, "metadata", metadataKeys...)
+		l.requireScalar(metadata, "name", "metadata.name", "it names the service to complete")
+		l.requireScalar(metadata, "version", "metadata.version", "it is the version of the service to complete")
+	}
+	return metadata
+}
+
+func (l *linter) apiMetadata() {
+	if metadata := l.microcksDocument("APIMetadata", "name", "version", "labels"); metadata != nil {
+		l.labels(field(metadata, "labels"), "metadata.labels")
+	}
+	operations := field(l.root, "operations")
+	if operations != nil && operations.Kind != yaml.MappingNode {
+		l.errorf(operations, "operations must be an object")
+		return
+	}
+	for _, name := range keys(operations) {
+		l.microcksOperation(field(operations, name.Value), "operation "+name.Value)
+	}
+}
+
+func (l *linter) apiExamples() {
+	l.microcksDocument("APIExamples", "name", "version")
+	operations := l.requireMapping(l.root, "operations", "operations")
+	for _, operation := range keys(operations) {
+		examples := field(operations, operation.Value)
+		if examples.Kind != yaml.MappingNode {
+			l.errorf(examples, "examples of operation %s must be an object", operation.Value)
+			continue
+		}
+		for _, name := range keys(examples) {
+			example := field(examples, name.Value)
+			path := "example " + name.Value + " of operation " + operation.Value
+			l.knownKeys(example, path, "request", "response", "eventMessage")
+			if field(example, "response") == nil && field(example, "eventMessage") == nil {
+				l.errorf(name, "%s needs a response or an eventMessage", path)
+			}
+			if request := field(example, "request"); request != nil {
+				l.knownKeys(request, path+" request", "parameters", "headers", "body")
+			}
+			if response := field(example, "response"); response != nil {
+				l.knownKeys(response, path+" response", "status", "mediaType", "headers", "body", "dispatchCriteria")
+			}
+			if message := field(example, "eventMessage"); message != nil {
+				l.knownKeys(message, path+" eventMessage", "headers", "payload", "mediaType")
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package lint
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+func (l *linter) openAPI() {
+	if version := field(l.root, "openapi"); version != nil && !strings.HasPrefix(version.Value, "3.") {
+		l.errorf(version, "unsupported OpenAPI version '%s', expected 3.x", version.Value)
+	}
+	if info := l.requireMapping(l.root, "info", "info"); info != nil {
+		l.requireScalar(info, "title", "info.title", "Microcks names the service after it")
+		l.requireScalar(info, "version", "info.version", "Microcks versions the service with it")
+		if extension := field(info, "x-microcks"); extension != nil {
+			l.knownKeys(extension, "info.x-microcks", "labels")
+			l.labels(field(extension, "labels"), "info.x-microcks.labels")
+		}
+	}
+
+	if paths := l.requireMapping(l.root, "paths", "paths"); paths != nil {
+		for _, path := range keys(paths) {
+			item := field(paths, path.Value)
+			for _, method := range httpMethods {
+				if operation := field(item, method); operation != nil {
+					l.openAPIOperation(item, operation, strings.ToUpper(method)+" "+path.Value)
+				}
+			}
+		}
+	}
+	l.localRefs(l.root)
+}
+
+// openAPIOperation checks the responses of an operation and how its
+// examples pair: Microcks matches the examples of parameters and request
+// body with the response examples of the same name.
+func (l *linter) openAPIOperation(item, operation *yaml.Node, name string) {
+	l.microcksOperation(field(operation, "x-microcks-operation"), name+" x-microcks-operation")
+
+	responses := field(operation, "responses")
+	if responses == nil || len(responses.Content) == 0 {
+		l.errorf(operation, "operation %s has no responses", name)
+	}
+	responseExamples := map[string]string{}
+	for _, code := range keys(responses) {
+		for _, example := range mediaExamples(field(field(responses, code.Value), "content")) {
+			if previous, ok := responseExamples[example.Value]; ok && previous != code.Value {
+				l.warnf(example, "example '%s' of %s is defined for responses %s and %s: Microcks only keeps one", example.Value, name, previous, code.Value)
+				continue
+			}
+			responseExamples[example.Value] = code.Value
+		}
+	}
+
+	var requestExamples []*yaml.Node
+	if body := field(operation, "requestBody"); body != nil {
+		requestExamples = append(requestExamples, mediaExamples(field(body, "content"))...)
+	}
+	params := append(sequence(field(item, "parameters")), sequence(field(operation, "parameters"))...)
+	for _, param := range params {
+		requestExamples = append(requestExamples, keys(field(param, "examples"))...)
+	}
+	for _, example := range requestExamples {
+		if _, ok := responseExamples[example.Value]; !ok {
+			l.warnf(example, "request example '%s' of %s has no response example of the same name: Microcks ignores it", example.Value, name)
+		}
+	}
+}
+
+// mediaExamples returns the example name nodes of the media types of a
+// content section.
+func mediaExamples(content *yaml.Node) []*yaml.Node {
+	var examples []*yaml.Node
+	for _, mediaType := range keys(content) {
+		examples = append(examples, keys(field(field(content, mediaType.Value), "examples"))...)
+	}
+	return examples
+}
+
+func sequence(node *yaml.Node) []*yaml.Node {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	return node.Content
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package lint
+
+import (
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+var postmanVersionRegexp = regexp.MustCompile(`version=[^\s]+`)
+
+func (l *linter) postman() {
+	info := field(l.root, "info")
+	l.requireScalar(info, "name", "info.name", "Microcks names the service after it")
+	if !postmanVersionRegexp.MatchString(scalar(info, "description")) {
+		l.errorf(info, "info.description must contain 'version=<version>': Microcks versions the service with it")
+	}
+
+	items := field(l.root, "item")
+	if items == nil || items.Kind != yaml.SequenceNode {
+		l.errorf(l.root, "item is required: the collection has no request")
+		return
+	}
+	l.postmanItems(items)
+}
+
+// postmanItems checks the requests of a collection, folders included:
+// Microcks mocks them with their saved responses, paired by name.
+func (l *linter) postmanItems(items *yaml.Node) {
+	for _, item := range items.Content {
+		if children := field(item, "item"); children != nil {
+			l.postmanItems(children)
+			continue
+		}
+		name := scalar(item, "name")
+		if field(item, "request") == nil {
+			l.errorf(item, "item '%s' has no request", name)
+			continue
+		}
+		responses := sequence(field(item, "response"))
+		if len(responses) == 0 {
+			l.warnf(item, "request '%s' has no saved response: Microcks has no example to mock it with", name)
+		}
+		seen := map[string]bool{}
+		for _, response := range responses {
+			responseName := scalar(response, "name")
+			switch {
+			case responseName == "":
+				l.warnf(response, "a saved response of request '%s' has no name: Microcks ignores it", name)
+			case seen[responseName]:
+				l.warnf(response, "request '%s' has several saved responses named '%s': Microcks only keeps one", name, responseName)
+			}
+			seen[responseName] = true
+		}
+	}
+}
@@ -0,0 +1,454 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package mockserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	httpMethods          = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+	postmanVersionRegexp = regexp.MustCompile(`version=([^\s]+)`)
+)
+
+// Parse extracts the service defined by an OpenAPI, AsyncAPI, Postman
+// collection or APIMetadata/APIExamples document. JSON is valid YAML, so one
+// decoder covers both.
+func Parse(content []byte) (Service, error) {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return Service{}, err
+	}
+	if doc == nil {
+		return Service{}, fmt.Errorf("empty document")
+	}
+	info, _ := doc["info"].(map[string]interface{})
+
+	switch {
+	case doc["openapi"] != nil || doc["swagger"] != nil:
+		return Service{
+			Name:       stringField(info, "title"),
+			Version:    stringField(info, "version"),
+			Type:       "REST",
+			Operations: openAPIOperations(doc),
+		}, requireNameVersion(info, "title")
+	case doc["asyncapi"] != nil:
+		return Service{
+			Name:       stringField(info, "title"),
+			Version:    stringField(info, "version"),
+			Type:       "EVENT",
+			Operations: asyncAPIOperations(doc),
+		}, requireNameVersion(info, "title")
+	case doc["kind"] == "APIMetadata" || doc["kind"] == "APIExamples":
+		metadata, _ := doc["metadata"].(map[string]interface{})
+		return Service{
+			Name:    stringField(metadata, "name"),
+			Version: stringField(metadata, "version"),
+		}, requireNameVersion(metadata, "name")
+	case info != nil && (info["_postman_id"] != nil || strings.Contains(stringField(info, "schema"), "postman")):
+		svc := Service{Name: stringField(info, "name"), Type: "REST", Operations: postmanOperations(doc)}
+		if m := postmanVersionRegexp.FindStringSubmatch(stringField(info, "description")); m != nil {
+			svc.Version = m[1]
+		}
+		if svc.Name == "" || svc.Version == "" {
+			return Service{}, fmt.Errorf("collection must have a name and a 'version=' in its description")
+		}
+		return svc, nil
+	}
+	return Service{}, fmt.Errorf("unknown artifact type")
+}
+
+func requireNameVersion(section map[string]interface{}, nameField string) error {
+	if stringField(section, nameField) == "" || stringField(section, "version") == "" {
+		return fmt.Errorf("%s and version are required", nameField)
+	}
+	return nil
+}
+
+func openAPIOperations(doc map[string]interface{}) []Operation {
+	paths, _ := doc["paths"].(map[string]interface{})
+	operations := []Operation{}
+	for _, path := range sortedKeys(paths) {
+		item, _ := paths[path].(map[string]interface{})
+		for _, method := range httpMethods {
+			if op, ok := item[method]; ok {
+				verb := strings.ToUpper(method)
+				operation, _ := op.(map[string]interface{})
+				exchanges := openAPIExchanges(item, operation)
+				operations = append(operations, Operation{Name: verb + " " + path, Method: verb, Examples: exampleNames(exchanges), Exchanges: exchanges})
+			}
+		}
+	}
+	return operations
+}
+
+// openAPIExchanges collects the examples of an operation's parameters,
+// request body and responses, paired by example name like Microcks does.
+func openAPIExchanges(item, operation map[string]interface{}) []Exchange {
+	var exchanges []Exchange
+	index := map[string]int{}
+	exchange := func(name string) *Exchange {
+		if i, ok := index[name]; ok {
+			return &exchanges[i]
+		}
+		index[name] = len(exchanges)
+		exchanges = append(exchanges, Exchange{Name: name})
+		return &exchanges[len(exchanges)-1]
+	}
+	examplesOf := func(content interface{}, visit func(mediaType, name string, value interface{})) {
+		media, _ := content.(map[string]interface{})
+		for _, mediaType := range sortedKeys(media) {
+			mt, _ := media[mediaType].(map[string]interface{})
+			examples, _ := mt["examples"].(map[string]interface{})
+			for _, name := range sortedKeys(examples) {
+				example, _ := examples[name].(map[string]interface{})
+				visit(mediaType, name, example["value"])
+			}
+		}
+	}
+
+	if body, ok := operation["requestBody"].(map[string]interface{}); ok {
+		examplesOf(body["content"], func(mediaType, name string, value interface{}) {
+			exchange(name).RequestBody = exampleContent(mediaType, value)
+		})
+	}
+	responses, _ := operation["responses"].(map[string]interface{})
+	for _, code := range sortedKeys(responses) {
+		response, _ := responses[code].(map[string]interface{})
+		examplesOf(response["content"], func(mediaType, name string, value interface{}) {
+			if e := exchange(name); e.Status == 0 {
+				e.Status, _ = strconv.Atoi(code)
+				e.MediaType = mediaType
+				e.ResponseBody = exampleContent(mediaType, value)
+			}
+		})
+	}
+
+	params, _ := item["parameters"].([]interface{})
+	params = append(params, listField(operation, "parameters")...)
+	for _, p := range params {
+		param, _ := p.(map[string]interface{})
+		examples, _ := param["examples"].(map[string]interface{})
+		for _, name := range sortedKeys(examples) {
+			if i, ok := index[name]; ok {
+				example, _ := examples[name].(map[string]interface{})
+				if exchanges[i].Parameters == nil {
+					exchanges[i].Parameters = map[string]string{}
+				}
+				exchanges[i].Parameters[stringField(param, "name")] = stringField(example, "value")
+			}
+		}
+	}
+	return exchanges
+}
+
+func exampleNames(exchanges []Exchange) []string {
+	var names []string
+	for _, e := range exchanges {
+		names = append(names, e.Name)
+	}
+	return names
+}
+
+// exampleContent renders an example value as a message body.
+func exampleContent(mediaType string, value interface{}) string {
+	if text, ok := value.(string); ok && !strings.Contains(mediaType, "json") {
+		return text
+	}
+	content, _ := json.Marshal(value)
+	return string(content)
+}
+
+// MergeExamples completes the operations of a stored service with the
+// examples of a secondary artifact, matching operations by method and path
+// shape since the artifacts may name path parameters differently.
+func MergeExamples(stored *Service, svc Service) {
+	for _, op := range svc.Operations {
+		for i := range stored.Operations {
+			target := &stored.Operations[i]
+			if target.Method != op.Method {
+				continue
+			}
+			renames, ok := samePath(operationPath(*target), operationPath(op))
+			if !ok {
+				continue
+			}
+			for _, e := range op.Exchanges {
+				if slices.Contains(target.Examples, e.Name) {
+					continue
+				}
+				params := map[string]string{}
+				for name, value := range e.Parameters {
+					if renamed, ok := renames[name]; ok {
+						name = renamed
+					}
+					params[name] = value
+				}
+				e.Parameters = params
+				target.Exchanges = append(target.Exchanges, e)
+				target.Examples = append(target.Examples, e.Name)
+			}
+			break
+		}
+	}
+}
+
+// samePath tells whether two path templates have the same shape, and maps
+// the parameter names of the second one to those of the first one.
+func samePath(template, other string) (map[string]string, bool) {
+	templateParts := strings.Split(strings.Trim(template, "/"), "/")
+	otherParts := strings.Split(strings.Trim(other, "/"), "/")
+	if len(templateParts) != len(otherParts) {
+		return nil, false
+	}
+	renames := map[string]string{}
+	for i, part := range templateParts {
+		name, isParam := pathParam(part)
+		otherName, otherIsParam := pathParam(otherParts[i])
+		switch {
+		case isParam && otherIsParam:
+			renames[otherName] = name
+		case isParam || otherIsParam || part != otherParts[i]:
+			return nil, false
+		}
+	}
+	return renames, true
+}
+
+// postmanOperations collects the requests of a collection, folders
+// included, as operations whose examples are the saved responses.
+func postmanOperations(doc map[string]interface{}) []Operation {
+	operations := []Operation{}
+	var visit func(items []interface{})
+	visit = func(items []interface{}) {
+		for _, it := range items {
+			item, _ := it.(map[string]interface{})
+			if children, ok := item["item"].([]interface{}); ok {
+				visit(children)
+				continue
+			}
+			request, _ := item["request"].(map[string]interface{})
+			if request == nil {
+				continue
+			}
+			verb := strings.ToUpper(stringField(request, "method"))
+			if verb == "" {
+				verb = "GET"
+			}
+			template, _, _ := postmanURL(request["url"])
+			name := verb + " " + template
+
+			i := slices.IndexFunc(operations, func(op Operation) bool { return op.Name == name })
+			if i < 0 {
+				operations = append(operations, Operation{Name: name, Method: verb})
+				i = len(operations) - 1
+			}
+			for _, r := range listField(item, "response") {
+				response, _ := r.(map[string]interface{})
+				e := postmanExchange(template, request, response)
+				operations[i].Exchanges = append(operations[i].Exchanges, e)
+				operations[i].Examples = append(operations[i].Examples, e.Name)
+			}
+		}
+	}
+	visit(listField(doc, "item"))
+	return operations
+}
+
+// postmanExchange turns a saved response into an example. Its parameters
+// come from the original request: path segments and variables matched
+// against the template, and query parameters. Unresolved ones, like a
+// `{{variable}}`, match any value.
+func postmanExchange(template string, request, response map[string]interface{}) Exchange {
+	original, _ := response["originalRequest"].(map[string]interface{})
+	if original == nil {
+		original = request
+	}
+	path, query, variables := postmanURL(original["url"])
+
+	params, _ := matchPath(template, path)
+	if params == nil {
+		params = map[string]string{}
+	}
+	for name, value := range params {
+		if variable, isParam := pathParam(value); isParam {
+			value = "*"
+			if v := variables[variable]; v != "" {
+				value = v
+			}
+		}
+		params[name] = value
+	}
+	for name, value := range query {
+		if _, isParam := pathParam(value); isParam {
+			value = "*"
+		}
+		params[name] = value
+	}
+
+	e := Exchange{Name: stringField(response, "name"), Parameters: params, ResponseBody: stringField(response, "body")}
+	e.Status, _ = strconv.Atoi(stringField(response, "code"))
+	if e.Status == 0 {
+		e.Status = http.StatusOK
+	}
+	for _, h := range listField(response, "header") {
+		header, _ := h.(map[string]interface{})
+		if strings.EqualFold(stringField(header, "key"), "Content-Type") {
+			e.MediaType = stringField(header, "value")
+		}
+	}
+	if e.MediaType == "" && stringField(response, "_postman_previewlanguage") == "json" {
+		e.MediaType = "application/json"
+	}
+	if body, ok := original["body"].(map[string]interface{}); ok {
+		e.RequestBody = stringField(body, "raw")
+	}
+	return e
+}
+
+// postmanURL splits a Postman URL, a raw string or its parsed form, into a
+// path template with `{name}` parameters, its query and its variables.
+func postmanURL(value interface{}) (string, map[string]string, map[string]string) {
+	query, variables := map[string]string{}, map[string]string{}
+	raw, _ := value.(string)
+	var segments []string
+	if u, ok := value.(map[string]interface{}); ok {
+		raw = stringField(u, "raw")
+		for _, p := range listField(u, "path") {
+			segments = append(segments, fmt.Sprint(p))
+		}
+		for _, q := range listField(u, "query") {
+			param, _ := q.(map[string]interface{})
+			if param["disabled"] != true {
+				query[stringField(param, "key")] = stringField(param, "value")
+			}
+		}
+		for _, v := range listField(u, "variable") {
+			variable, _ := v.(map[string]interface{})
+			variables[stringField(variable, "key")] = stringField(variable, "value")
+		}
+	}
+
+	path, rawQuery, _ := strings.Cut(raw, "?")
+	if len(segments) == 0 {
+		// Drop the scheme and host, or the {{baseUrl}} standing for them.
+		if _, rest, ok := strings.Cut(path, "://"); ok {
+			path = rest
+			if i := strings.Index(path, "/"); i >= 0 {
+				path = path[i:]
+			} else {
+				path = ""
+			}
+		} else if strings.HasPrefix(path, "{{") {
+			if i := strings.Index(path, "}}"); i >= 0 {
+				path = path[i+2:]
+			}
+		}
+		segments = strings.Split(strings.Trim(path, "/"), "/")
+		if len(query) == 0 && rawQuery != "" {
+			for _, pair := range strings.Split(rawQuery, "&") {
+				name, value, _ := strings.Cut(pair, "=")
+				query[name] = value
+			}
+		}
+	}
+
+	for i, segment := range segments {
+		if name, isParam := pathParam(segment); isParam {
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return "/" + strings.Join(segments, "/"), query, variables
+}
+
+// pathParam tells whether a path segment is a parameter, written `{name}`,
+// `{{name}}` or `:name`, and returns its name.
+func pathParam(segment string) (string, bool) {
+	switch {
+	case strings.HasPrefix(segment, "{{") && strings.HasSuffix(segment, "}}"):
+		return segment[2 : len(segment)-2], true
+	case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+		return segment[1 : len(segment)-1], true
+	case strings.HasPrefix(segment, ":") && len(segment) > 1:
+		return segment[1:], true
+	}
+	return "", false
+}
+
+func listField(section map[string]interface{}, name string) []interface{} {
+	list, _ := section[name].([]interface{})
+	return list
+}
+
+func asyncAPIOperations(doc map[string]interface{}) []Operation {
+	operations := []Operation{}
+
+	// AsyncAPI 3 declares operations at the top level.
+	if ops, ok := doc["operations"].(map[string]interface{}); ok {
+		for _, name := range sortedKeys(ops) {
+			op, _ := ops[name].(map[string]interface{})
+			action := strings.ToUpper(stringField(op, "action"))
+			operations = append(operations, Operation{Name: action + " " + name, Method: action})
+		}
+		return operations
+	}
+
+	// AsyncAPI 2 nests them in channels.
+	channels, _ := doc["channels"].(map[string]interface{})
+	for _, channel := range sortedKeys(channels) {
+		item, _ := channels[channel].(map[string]interface{})
+		for _, action := range []string{"subscribe", "publish"} {
+			if _, ok := item[action]; ok {
+				verb := strings.ToUpper(action)
+				operations = append(operations, Operation{Name: verb + " " + channel, Method: verb})
+			}
+		}
+	}
+	return operations
+}
+
+func stringField(section map[string]interface{}, name string) string {
+	switch v := section[name].(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package mockserver
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ServiceName decodes the service name of a mock URL: Microcks encodes the
+// spaces of service names as '+'.
+func ServiceName(segment string) string {
+	return strings.ReplaceAll(segment, "+", " ")
+}
+
+// Dispatch answers a mock request of a service from the examples of the
+// operation matching its method and path, relative to the service base URL.
+func Dispatch(w http.ResponseWriter, r *http.Request, svc Service, path string) {
+	for _, op := range svc.Operations {
+		if op.Method != r.Method {
+			continue
+		}
+		params, ok := matchPath(operationPath(op), path)
+		if !ok {
+			continue
+		}
+		for name, values := range r.URL.Query() {
+			params[name] = values[0]
+		}
+		if e := matchExchange(op.Exchanges, params); e != nil {
+			if e.MediaType != "" {
+				w.Header().Set("Content-Type", e.MediaType)
+			}
+			w.WriteHeader(e.Status)
+			_, _ = w.Write([]byte(e.ResponseBody))
+			return
+		}
+		writeText(w, http.StatusBadRequest, "No example of %s matches the request", op.Name)
+		return
+	}
+	writeText(w, http.StatusNotFound, "No operation of %s matches %s %s", svc.Ref(), r.Method, r.URL.Path)
+}
+
+// matchExchange picks the example whose parameters match the request,
+// preferring the one matching the most of them exactly over wildcards.
+func matchExchange(exchanges []Exchange, params map[string]string) *Exchange {
+	var best *Exchange
+	bestScore := -1
+	for i, e := range exchanges {
+		score, ok := matchParameters(e.Parameters, params)
+		if ok && score > bestScore {
+			best, bestScore = &exchanges[i], score
+		}
+	}
+	return best
+}
+
+// operationPath returns the path template of a REST operation.
+func operationPath(op Operation) string {
+	return strings.TrimPrefix(op.Name, op.Method+" ")
+}
+
+// matchPath matches a path against an OpenAPI template and extracts its
+// parameters.
+func matchPath(template, path string) (map[string]string, bool) {
+	templateParts := strings.Split(strings.Trim(template, "/"), "/")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(templateParts) != len(parts) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, part := range templateParts {
+		switch {
+		case strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}"):
+			params[part[1:len(part)-1]] = parts[i]
+		case part != parts[i]:
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// matchParameters tells whether the request parameters match the expected
+// ones, where `*` matches any value, and counts the exact matches.
+func matchParameters(expected, actual map[string]string) (int, bool) {
+	exact := 0
+	for name, value := range expected {
+		switch {
+		case value == "*":
+		case actual[name] == value:
+			exact++
+		default:
+			return 0, false
+		}
+	}
+	return exact, true
+}
+
+func writeText(w http.ResponseWriter, status int, format string, a ...interface{}) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(strings.TrimSpace(fmt.Sprintf(format, a...))))
+}
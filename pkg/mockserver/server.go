@@ -0,0 +1,106 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package mockserver
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Server serves the mocks of the services loaded into it. The zero value is
+// not usable; build one with New. All methods are safe for concurrent use.
+type Server struct {
+	mu       sync.Mutex
+	services []*Service
+	mux      *http.ServeMux
+}
+
+// New builds a mock server without services. Like Microcks, it serves
+// them under /rest/<name>/<version>/.
+func New() *Server {
+	s := &Server{mux: http.NewServeMux()}
+	s.mux.HandleFunc("/rest/{service}/{version}/{path...}", s.handleRestMock)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Load registers the service a primary artifact defines, or completes the
+// one a secondary artifact refers to with its examples, and returns it.
+func (s *Server) Load(name string, content []byte, mainArtifact bool) (Service, error) {
+	svc, err := Parse(content)
+	if err != nil {
+		return Service{}, fmt.Errorf("%s is not a supported artifact: %v", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := s.findService(svc.Ref())
+	switch {
+	case mainArtifact && stored == nil:
+		stored = &svc
+		s.services = append(s.services, stored)
+	case mainArtifact:
+		*stored = svc
+	case stored == nil:
+		return Service{}, fmt.Errorf("No main artifact has been imported for %s", svc.Ref())
+	default:
+		MergeExamples(stored, svc)
+	}
+	return *stored, nil
+}
+
+// Services returns a snapshot of the loaded services.
+func (s *Server) Services() []Service {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	services := make([]Service, 0, len(s.services))
+	for _, svc := range s.services {
+		services = append(services, *svc)
+	}
+	return services
+}
+
+// findService looks a service up by `name:version`. Callers hold mu.
+func (s *Server) findService(ref string) *Service {
+	for _, svc := range s.services {
+		if svc.Ref() == ref {
+			return svc
+		}
+	}
+	return nil
+}
+
+func (s *Server) handleRestMock(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	version := r.PathValue("version")
+	svc := s.findService(r.PathValue("service") + ":" + version)
+	if svc == nil {
+		svc = s.findService(ServiceName(r.PathValue("service")) + ":" + version)
+	}
+	if svc == nil {
+		writeText(w, http.StatusNotFound, "No service %s:%s", r.PathValue("service"), version)
+		return
+	}
+	Dispatch(w, r, *svc, "/"+r.PathValue("path"))
+}
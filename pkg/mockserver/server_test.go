@@ -0,0 +1,106 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package mockserver
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostmanExamplesCompleteOpenAPIOperations(t *testing.T) {
+	mocks := New()
+	for _, artifact := range []struct {
+		file string
+		main bool
+	}{{"weather-forecast-openapi.yml", true}, {"weather-forecast-postman.json", false}} {
+		content, err := os.ReadFile("../../samples/" + artifact.file)
+		require.NoError(t, err)
+		_, err = mocks.Load(artifact.file, content, artifact.main)
+		require.NoError(t, err)
+	}
+
+	op := mocks.Services()[0].Operations[0]
+	assert.Equal(t, "GET /forecast/{region}", op.Name)
+	assert.Equal(t, []string{"Unknown", "East", "North", "South", "West"}, op.Examples)
+	assert.Equal(t, map[string]string{"region": "*"}, op.Exchanges[0].Parameters)
+	assert.Equal(t, "application/json", op.Exchanges[1].MediaType)
+}
+
+func TestPostmanCollectionAsMainArtifact(t *testing.T) {
+	mocks := New()
+	_, err := mocks.Load("orders.json", []byte(`{
+		"info": {"name": "Orders", "description": "version=1.0", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+		"item": [{"name": "Get order", "request": {"method": "GET", "url": "{{baseUrl}}/orders/:id"},
+			"response": [
+				{"name": "Shipped", "code": 200, "header": [{"key": "Content-Type", "value": "application/json"}],
+				 "originalRequest": {"method": "GET", "url": {"raw": "{{baseUrl}}/orders/42?expand=lines", "query": [{"key": "expand", "value": "lines"}]}},
+				 "body": "{\"id\": 42}"}
+			]}]}`), true)
+	require.NoError(t, err)
+	server := httptest.NewServer(mocks)
+	defer server.Close()
+
+	op := mocks.Services()[0].Operations[0]
+	assert.Equal(t, "GET /orders/{id}", op.Name)
+	assert.Equal(t, map[string]string{"id": "42", "expand": "lines"}, op.Exchanges[0].Parameters)
+
+	resp, err := http.Get(server.URL + "/rest/Orders/1.0/orders/42?expand=lines")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"id": 42}`, string(body))
+
+	resp, err = http.Get(server.URL + "/rest/Orders/1.0/orders/43")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestLoadSecondaryArtifactRequiresMainOne(t *testing.T) {
+	content, err := os.ReadFile("../../samples/weather-forecast-postman.json")
+	require.NoError(t, err)
+	_, err = New().Load("weather-forecast-postman.json", content, false)
+	assert.EqualError(t, err, "No main artifact has been imported for WeatherForecast API:1.1.0")
+
+	_, err = New().Load("notes.yaml", []byte("hello: world"), true)
+	assert.EqualError(t, err, "notes.yaml is not a supported artifact: unknown artifact type")
+}
+
+func TestDispatchPrefersExactParametersOverWildcards(t *testing.T) {
+	svc := Service{Name: "Weather", Version: "1.0", Operations: []Operation{{
+		Name: "GET /forecast/{region}", Method: "GET",
+		Exchanges: []Exchange{
+			{Name: "Unknown", Parameters: map[string]string{"region": "*"}, Status: http.StatusNotFound, ResponseBody: "unknown"},
+			{Name: "East", Parameters: map[string]string{"region": "east"}, Status: http.StatusOK, ResponseBody: "east"},
+		},
+	}}}
+	for path, expected := range map[string]string{"/forecast/east": "east", "/forecast/west": "unknown"} {
+		w := httptest.NewRecorder()
+		Dispatch(w, httptest.NewRequest(http.MethodGet, "/rest/Weather/1.0"+path, nil), svc, path)
+		assert.Equal(t, expected, w.Body.String(), path)
+	}
+
+	w := httptest.NewRecorder()
+	Dispatch(w, httptest.NewRequest(http.MethodPost, "/rest/Weather/1.0/forecast/east", nil), svc, "/forecast/east")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
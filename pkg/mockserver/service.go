@@ -0,0 +1,69 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+// Package mockserver reads the services defined by API artifacts (OpenAPI,
+// AsyncAPI, Postman collections, APIMetadata and APIExamples) and answers
+// REST mock requests from their examples, dispatching on path and query
+// parameters like Microcks does.
+//
+// It backs `microcks serve`, and the mocks of the fake Microcks of package
+// fakeserver.
+package mockserver
+
+// Service is a Microcks Service (an API name and version) and the examples
+// of its operations.
+type Service struct {
+	ID         string      `json:"id"`
+	Name       string      `json:"name"`
+	Version    string      `json:"version"`
+	Type       string      `json:"type"`
+	Metadata   *Metadata   `json:"metadata,omitempty"`
+	Operations []Operation `json:"operations"`
+}
+
+// Metadata holds the labels and annotations of a resource.
+type Metadata struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Operation is a single operation of a Service, e.g. "GET /pastries/{name}".
+type Operation struct {
+	Name            string `json:"name"`
+	Method          string `json:"method"`
+	Dispatcher      string `json:"dispatcher,omitempty"`
+	DispatcherRules string `json:"dispatcherRules,omitempty"`
+	DefaultDelay    int64  `json:"defaultDelay,omitempty"`
+	// Examples names the request/response examples defined for the operation.
+	Examples []string `json:"-"`
+	// Exchanges holds the content of the examples, when known.
+	Exchanges []Exchange `json:"-"`
+}
+
+// Exchange is a named request/response example of an operation.
+type Exchange struct {
+	Name string
+	// Parameters holds the path and query parameters of the request.
+	Parameters   map[string]string
+	RequestBody  string
+	Status       int
+	MediaType    string
+	ResponseBody string
+}
+
+// Ref returns the `name:version` reference of the service.
+func (s Service) Ref() string {
+	return s.Name + ":" + s.Version
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package duration parses the human durations accepted by CLI flags.
+package duration
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// legacyPattern matches the historical --waitFor syntax: 500milli, 30sec, 5min.
+	legacyPattern = regexp.MustCompile(`^(\d+)(milli|sec|min)$`)
+	// isoPattern matches ISO-8601 durations limited to days and time parts.
+	// Years and months have no fixed length, so they are rejected.
+	isoPattern = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+	legacyUnits = map[string]time.Duration{"milli": time.Millisecond, "sec": time.Second, "min": time.Minute}
+)
+
+// Parse reads a duration written with the legacy suffixes (`500milli`,
+// `30sec`, `5min`), Go syntax (`90s`, `2m30s`) or ISO-8601 (`PT5M`, `P1DT2H`).
+func Parse(s string) (time.Duration, error) {
+	if m := legacyPattern.FindStringSubmatch(s); m != nil {
+		n, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+		}
+		return scale(s, n, legacyUnits[m[2]])
+	}
+
+	// The pattern lets every part be empty: a bare `P`, or a `T` with no
+	// time part after it, like `P1DT`, is not a duration.
+	if m := isoPattern.FindStringSubmatch(s); m != nil && s != "P" && !strings.HasSuffix(s, "T") {
+		var d time.Duration
+		for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute} {
+			if m[i+1] != "" {
+				n, err := strconv.ParseInt(m[i+1], 10, 64)
+				if err != nil {
+					return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+				}
+				part, err := scale(s, n, unit)
+				if err != nil {
+					return 0, err
+				}
+				if d, err = add(s, d, part); err != nil {
+					return 0, err
+				}
+			}
+		}
+		if m[4] != "" {
+			seconds, err := strconv.ParseFloat(m[4], 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+			}
+			if seconds*float64(time.Second) >= math.MaxInt64 {
+				return 0, outOfRange(s)
+			}
+			return add(s, d, time.Duration(seconds*float64(time.Second)))
+		}
+		return d, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: use e.g. 90s, 2m30s, PT5M or 30sec", s)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid duration %q: must not be negative", s)
+	}
+	return d, nil
+}
+
+// scale returns n units, refusing counts that overflow a time.Duration.
+func scale(s string, n int64, unit time.Duration) (time.Duration, error) {
+	if n > int64(math.MaxInt64/unit) {
+		return 0, outOfRange(s)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// add sums two non-negative durations, refusing a sum that overflows.
+func add(s string, d, part time.Duration) (time.Duration, error) {
+	if d > math.MaxInt64-part {
+		return 0, outOfRange(s)
+	}
+	return d + part, nil
+}
+
+func outOfRange(s string) error {
+	return fmt.Errorf("invalid duration %q: out of range", s)
+}
+
+// Value is a time.Duration usable as a pflag.Value, accepting every syntax
+// understood by Parse.
+type Value time.Duration
+
+// Set implements pflag.Value.
+func (v *Value) Set(s string) error {
+	d, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*v = Value(d)
+	return nil
+}
+
+// String implements pflag.Value. Zero renders as "0" so that pflag treats it
+// as an unset default in help output.
+func (v *Value) String() string {
+	if *v == 0 {
+		return "0"
+	}
+	return time.Duration(*v).String()
+}
+
+// Type implements pflag.Value.
+func (v *Value) Type() string {
+	return "duration"
+}
+
+// Duration returns the value as a time.Duration.
+func (v Value) Duration() time.Duration {
+	return time.Duration(v)
+}
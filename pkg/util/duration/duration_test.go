@@ -0,0 +1,81 @@
+/*
+ * Copyright The Microcks Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package duration
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+	}{
+		{"500milli", 500 * time.Millisecond},
+		{"30sec", 30 * time.Second},
+		{"5min", 5 * time.Minute},
+		{"90s", 90 * time.Second},
+		{"2m30s", 150 * time.Second},
+		{"1500ms", 1500 * time.Millisecond},
+		{"PT5M", 5 * time.Minute},
+		{"PT1H30M", 90 * time.Minute},
+		{"PT2.5S", 2500 * time.Millisecond},
+		{"P1DT2H", 26 * time.Hour},
+		{"0s", 0},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, input := range []string{"", "5", "5hours", "P", "PT", "P1DT", "P1M", "-5s", "sec", "PT5X"} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) expected an error", input)
+		}
+	}
+}
+
+func TestParseOutOfRange(t *testing.T) {
+	for _, input := range []string{"200000000min", "99999999999999999sec", "P200000D", "P106751DT24H", "PT9999999999999S", "P106751DT23H47M17S"} {
+		_, err := Parse(input)
+		if err == nil || !strings.Contains(err.Error(), "out of range") {
+			t.Errorf("Parse(%q) = %v, want an out of range error", input, err)
+		}
+	}
+}
+
+func TestValueImplementsFlag(t *testing.T) {
+	v := Value(5 * time.Second)
+	if err := v.Set("PT1M"); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	if v.Duration() != time.Minute || v.String() != "1m0s" || v.Type() != "duration" {
+		t.Errorf("unexpected value state: %s %s %s", v.Duration(), v.String(), v.Type())
+	}
+	if err := v.Set("soon"); err == nil {
+		t.Error("expected Set to reject an invalid duration")
+	}
+}